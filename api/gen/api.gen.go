@@ -3,6 +3,22 @@
 // Code generated by github.com/deepmap/oapi-codegen/v2 version v2.2.0 DO NOT EDIT.
 package api
 
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/oapi-codegen/runtime"
+)
+
 // Defines values for ActionItemResponseEnableExternalCommands.
 const (
 	ActionItemResponseEnableExternalCommandsAll     ActionItemResponseEnableExternalCommands = "all"
@@ -11,6 +27,36 @@ const (
 	ActionItemResponseEnableExternalCommandsRun     ActionItemResponseEnableExternalCommands = "run"
 )
 
+// Defines values for ActionStepOutput.
+const (
+	ActionStepOutputAll     ActionStepOutput = "all"
+	ActionStepOutputNone    ActionStepOutput = "none"
+	ActionStepOutputOnError ActionStepOutput = "on_error"
+)
+
+// Defines values for DiagnosticSeverity.
+const (
+	Error   DiagnosticSeverity = "error"
+	Info    DiagnosticSeverity = "info"
+	Warning DiagnosticSeverity = "warning"
+)
+
+// Defines values for ExecutionStatus.
+const (
+	ExecutionStatusCompileError  ExecutionStatus = "compile_error"
+	ExecutionStatusInternalError ExecutionStatus = "internal_error"
+	ExecutionStatusOk            ExecutionStatus = "ok"
+	ExecutionStatusOomKilled     ExecutionStatus = "oom_killed"
+	ExecutionStatusRuntimeError  ExecutionStatus = "runtime_error"
+	ExecutionStatusTimeout       ExecutionStatus = "timeout"
+)
+
+// Defines values for FileMetaEncoding.
+const (
+	Base64 FileMetaEncoding = "base64"
+	Text   FileMetaEncoding = "text"
+)
+
 // Defines values for ImageActionConfigEnableExternalCommands.
 const (
 	ImageActionConfigEnableExternalCommandsAll     ImageActionConfigEnableExternalCommands = "all"
@@ -19,8 +65,102 @@ const (
 	ImageActionConfigEnableExternalCommandsRun     ImageActionConfigEnableExternalCommands = "run"
 )
 
+// Defines values for JobStatus.
+const (
+	Canceled  JobStatus = "canceled"
+	Compiling JobStatus = "compiling"
+	Done      JobStatus = "done"
+	Failed    JobStatus = "failed"
+	Queued    JobStatus = "queued"
+	Running   JobStatus = "running"
+)
+
+// Defines values for JudgeCompareMode.
+const (
+	Exact   JudgeCompareMode = "exact"
+	Float   JudgeCompareMode = "float"
+	Trimmed JudgeCompareMode = "trimmed"
+)
+
+// Defines values for JudgeVerdict.
+const (
+	JudgeVerdictAccepted         JudgeVerdict = "accepted"
+	JudgeVerdictCompilationError JudgeVerdict = "compilation_error"
+	JudgeVerdictMemoryLimit      JudgeVerdict = "memory_limit"
+	JudgeVerdictNotRun           JudgeVerdict = "not_run"
+	JudgeVerdictRuntimeError     JudgeVerdict = "runtime_error"
+	JudgeVerdictTimeLimit        JudgeVerdict = "time_limit"
+	JudgeVerdictWrongAnswer      JudgeVerdict = "wrong_answer"
+)
+
+// Defines values for ProblemCode.
+const (
+	ActionNotFound          ProblemCode = "action_not_found"
+	CapacityExceeded        ProblemCode = "capacity_exceeded"
+	DockerError             ProblemCode = "docker_error"
+	Forbidden               ProblemCode = "forbidden"
+	FormatNotSupported      ProblemCode = "format_not_supported"
+	HookFailed              ProblemCode = "hook_failed"
+	InternalError           ProblemCode = "internal_error"
+	InvalidRequest          ProblemCode = "invalid_request"
+	JobNotFound             ProblemCode = "job_not_found"
+	MethodNotAllowed        ProblemCode = "method_not_allowed"
+	NotFound                ProblemCode = "not_found"
+	QuotaExceeded           ProblemCode = "quota_exceeded"
+	RateLimited             ProblemCode = "rate_limited"
+	RejectedByHook          ProblemCode = "rejected_by_hook"
+	RequestTooLarge         ProblemCode = "request_too_large"
+	SandboxError            ProblemCode = "sandbox_error"
+	SandboxSaturated        ProblemCode = "sandbox_saturated"
+	SandboxUnavailable      ProblemCode = "sandbox_unavailable"
+	SnippetNotFound         ProblemCode = "snippet_not_found"
+	TemplateNotFound        ProblemCode = "template_not_found"
+	TemplatesRefreshFailed  ProblemCode = "templates_refresh_failed"
+	TerminalSessionNotFound ProblemCode = "terminal_session_not_found"
+	TooManyTestCases        ProblemCode = "too_many_test_cases"
+	Unauthorized            ProblemCode = "unauthorized"
+)
+
+// Defines values for SandboxTemplateStatusState.
+const (
+	Active   SandboxTemplateStatusState = "active"
+	Disabled SandboxTemplateStatusState = "disabled"
+	Draining SandboxTemplateStatusState = "draining"
+)
+
+// Defines values for StepPhase.
+const (
+	StepPhaseCompile StepPhase = "compile"
+	StepPhaseRun     StepPhase = "run"
+)
+
+// Defines values for StepResultStatus.
+const (
+	Errored  StepResultStatus = "errored"
+	Passed   StepResultStatus = "passed"
+	Skipped  StepResultStatus = "skipped"
+	TimedOut StepResultStatus = "timed_out"
+)
+
+// Defines values for TemplateChangeKind.
+const (
+	Added   TemplateChangeKind = "added"
+	Changed TemplateChangeKind = "changed"
+	Removed TemplateChangeKind = "removed"
+)
+
+// Defines values for TerminalControlType.
+const (
+	Resize TerminalControlType = "resize"
+)
+
 // ActionItemResponse defines model for ActionItemResponse.
 type ActionItemResponse struct {
+	// Artifacts globs of the files which are returned after the run, relative to the workdir (like out/*.png)
+	Artifacts *[]string `json:"Artifacts,omitempty"`
+
+	// Cacheable The programs are deterministic, so the playground may cache their results.
+	Cacheable  bool   `json:"Cacheable"`
 	CompileCmd string `json:"CompileCmd"`
 
 	// Connections Databases. Currently available only ['postgres']
@@ -31,17 +171,23 @@ type ActionItemResponse struct {
 	// EnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
 	EnableExternalCommands ActionItemResponseEnableExternalCommands `json:"EnableExternalCommands"`
 	Enabled                bool                                     `json:"Enabled"`
-	Groups                 []string                                 `json:"Groups"`
-	Id                     string                                   `json:"Id"`
-	IsDefault              bool                                     `json:"IsDefault"`
-	IsSupportPackage       bool                                     `json:"IsSupportPackage"`
-	Name                   string                                   `json:"Name"`
-	Provider               string                                   `json:"Provider"`
-	RunCmd                 string                                   `json:"RunCmd"`
-	ScriptOptions          ImageConfigScriptOptions                 `json:"ScriptOptions"`
-	Template               string                                   `json:"Template"`
-	Version                string                                   `json:"Version"`
-	Workdir                string                                   `json:"Workdir"`
+
+	// FormatCmd command formatting the files in place in the workdir, like gofmt -w .
+	FormatCmd        *string                  `json:"FormatCmd,omitempty"`
+	Groups           []string                 `json:"Groups"`
+	Id               string                   `json:"Id"`
+	IsDefault        bool                     `json:"IsDefault"`
+	IsSupportPackage bool                     `json:"IsSupportPackage"`
+	Name             string                   `json:"Name"`
+	Provider         string                   `json:"Provider"`
+	RunCmd           string                   `json:"RunCmd"`
+	ScriptOptions    ImageConfigScriptOptions `json:"ScriptOptions"`
+
+	// Steps ordered steps replacing CompileCmd and RunCmd, like install, build, test and run. ExternalOptions don't override them
+	Steps    *[]ActionStep `json:"Steps,omitempty"`
+	Template string        `json:"Template"`
+	Version  string        `json:"Version"`
+	Workdir  string        `json:"Workdir"`
 }
 
 // ActionItemResponseEnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
@@ -50,6 +196,39 @@ type ActionItemResponseEnableExternalCommands string
 // ActionListResponse defines model for ActionListResponse.
 type ActionListResponse = []ActionItemResponse
 
+// ActionStep defines model for ActionStep.
+type ActionStep struct {
+	// Cmd shell command run in the workdir, {ARGS} and {STDIN} are replaced like in RunCmd
+	Cmd string `json:"Cmd"`
+
+	// ContinueOnError a failing or timed out step doesn't stop the action and doesn't fail the run
+	ContinueOnError *bool  `json:"ContinueOnError,omitempty"`
+	Name            string `json:"Name"`
+
+	// Output all streams the output of the step, on_error only returns it if the step fails, none discards it
+	Output *ActionStepOutput `json:"Output,omitempty"`
+
+	// Phase a failing compile step is reported as compile_error and a failing run step as runtime_error, the time and usage of a step are added to the ones of its phase
+	Phase *StepPhase `json:"Phase,omitempty"`
+
+	// TTL seconds the command may run, CompileTTL or RunTTL of the template by phase if not set
+	TTL *int `json:"TTL,omitempty"`
+}
+
+// ActionStepOutput all streams the output of the step, on_error only returns it if the step fails, none discards it
+type ActionStepOutput string
+
+// Artifact File written by the program which matches an artifact glob of the action
+type Artifact struct {
+	// Content file content, empty if the file exceeds the size caps of the sandbox
+	Content  []byte `json:"Content"`
+	MimeType string `json:"MimeType"`
+
+	// Name path relative to the workdir
+	Name string `json:"Name"`
+	Size int64  `json:"Size"`
+}
+
 // CommonSubmissionRequest defines model for CommonSubmissionRequest.
 type CommonSubmissionRequest struct {
 	ActionId *string `json:"ActionId,omitempty"`
@@ -70,18 +249,88 @@ type ContainerOptions struct {
 	RunTTL      *int `json:"RunTTL,omitempty"`
 }
 
+// Diagnostic defines model for Diagnostic.
+type Diagnostic struct {
+	// Column 1-based column, 0 if the compiler doesn't report it
+	Column int `json:"Column"`
+
+	// File path of the file relative to the workdir
+	File     string             `json:"File"`
+	Line     int                `json:"Line"`
+	Message  string             `json:"Message"`
+	Severity DiagnosticSeverity `json:"Severity"`
+}
+
+// DiagnosticSeverity defines model for Diagnostic.Severity.
+type DiagnosticSeverity string
+
+// ExecutionResult defines model for ExecutionResult.
+type ExecutionResult struct {
+	ExitCode int     `json:"ExitCode"`
+	Signal   *string `json:"Signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"Status"`
+}
+
+// ExecutionStatus ok, compile_error and runtime_error if the compile or run command exited with
+// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+// internal_error if the sandbox failed to run the submission
+type ExecutionStatus string
+
+// FileMeta defines model for FileMeta.
+type FileMeta struct {
+	// Encoding base64 for binary files whose content is base64 encoded
+	Encoding *FileMetaEncoding `json:"Encoding,omitempty"`
+
+	// Mode permission bits of the file, like 493 (0755) for an executable script
+	Mode *int `json:"Mode,omitempty"`
+}
+
+// FileMetaEncoding base64 for binary files whose content is base64 encoded
+type FileMetaEncoding string
+
+// FormatRequest defines model for FormatRequest.
+type FormatRequest struct {
+	ActionId *string           `json:"ActionId,omitempty"`
+	Files    map[string]string `json:"Files"`
+
+	// FilesMeta encoding and mode of the files, by the file name
+	FilesMeta  *map[string]FileMeta `json:"FilesMeta,omitempty"`
+	TemplateId string               `json:"TemplateId"`
+}
+
+// FormatResponse defines model for FormatResponse.
+type FormatResponse struct {
+	// Error output of the formatter if it failed, like syntax errors; Files is empty then
+	Error *string `json:"Error,omitempty"`
+
+	// Files formatted text files, by the file name; binary files aren't returned
+	Files map[string]string `json:"Files"`
+}
+
 // ImageActionConfig defines model for ImageActionConfig.
 type ImageActionConfig struct {
+	// Artifacts globs of the files which are returned after the run, relative to the workdir (like out/*.png)
+	Artifacts    *[]string         `json:"Artifacts,omitempty"`
 	CompileCmd   string            `json:"CompileCmd"`
 	DefaultFiles map[string]string `json:"DefaultFiles"`
 
 	// EnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
 	EnableExternalCommands ImageActionConfigEnableExternalCommands `json:"EnableExternalCommands"`
-	Id                     string                                  `json:"Id"`
-	IsDefault              bool                                    `json:"IsDefault"`
-	Name                   string                                  `json:"Name"`
-	RunCmd                 string                                  `json:"RunCmd"`
-	ScriptOptions          ImageConfigScriptOptions                `json:"ScriptOptions"`
+
+	// FormatCmd command formatting the files in place in the workdir, like gofmt -w .
+	FormatCmd     *string                  `json:"FormatCmd,omitempty"`
+	Id            string                   `json:"Id"`
+	IsDefault     bool                     `json:"IsDefault"`
+	Name          string                   `json:"Name"`
+	RunCmd        string                   `json:"RunCmd"`
+	ScriptOptions ImageConfigScriptOptions `json:"ScriptOptions"`
+
+	// Steps ordered steps replacing CompileCmd and RunCmd, like install, build, test and run. ExternalOptions don't override them
+	Steps *[]ActionStep `json:"Steps,omitempty"`
 }
 
 // ImageActionConfigEnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
@@ -91,6 +340,9 @@ type ImageActionConfigEnableExternalCommands string
 type ImageConfig struct {
 	Actions map[string]ImageActionConfig `json:"Actions"`
 
+	// Cacheable The programs are deterministic, so the playground may cache their results.
+	Cacheable bool `json:"Cacheable"`
+
 	// Connections Databases. Currently available only ['postgres']
 	Connections      []string         `json:"Connections"`
 	ContainerOptions ContainerOptions `json:"ContainerOptions"`
@@ -110,6 +362,9 @@ type ImageConfigScriptOptions struct {
 
 // ImageTemplateConfig defines model for ImageTemplateConfig.
 type ImageTemplateConfig struct {
+	// Cacheable The programs are deterministic, so the playground may cache their results.
+	Cacheable bool `json:"Cacheable"`
+
 	// Connections Databases. Currently available only ['postgres']
 	Connections      []string         `json:"Connections"`
 	ContainerOptions ContainerOptions `json:"ContainerOptions"`
@@ -122,13 +377,201 @@ type ImageTemplateConfig struct {
 	Workdir          string           `json:"Workdir"`
 }
 
+// JobResponse defines model for JobResponse.
+type JobResponse struct {
+	CreatedAt time.Time `json:"CreatedAt"`
+	Error     *string   `json:"Error,omitempty"`
+	Id        string    `json:"Id"`
+
+	// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+	Problem   *Problem            `json:"Problem,omitempty"`
+	Response  *SubmissionResponse `json:"Response,omitempty"`
+	Status    JobStatus           `json:"Status"`
+	UpdatedAt time.Time           `json:"UpdatedAt"`
+}
+
+// JobStatus defines model for JobStatus.
+type JobStatus string
+
+// JudgeCase defines model for JudgeCase.
+type JudgeCase struct {
+	Args string `json:"Args"`
+
+	// Compare exact compares byte by byte, trimmed ignores trailing whitespace of lines and
+	// trailing empty lines, float compares numbers with the case tolerance
+	Compare        *JudgeCompareMode `json:"Compare,omitempty"`
+	ExpectedStdout string            `json:"ExpectedStdout"`
+	Stdin          string            `json:"Stdin"`
+
+	// Tolerance absolute or relative tolerance of the float mode, 1e-6 by default
+	Tolerance *float64 `json:"Tolerance,omitempty"`
+}
+
+// JudgeCaseResult defines model for JudgeCaseResult.
+type JudgeCaseResult struct {
+	ExitCode int     `json:"ExitCode"`
+	Stderr   string  `json:"Stderr"`
+	Stdout   string  `json:"Stdout"`
+	Time     float32 `json:"Time"`
+
+	// Verdict not_run is the verdict of the cases which weren't run, or not to the end, because
+	// the time of the request was used up before
+	Verdict JudgeVerdict `json:"Verdict"`
+}
+
+// JudgeCompareMode exact compares byte by byte, trimmed ignores trailing whitespace of lines and
+// trailing empty lines, float compares numbers with the case tolerance
+type JudgeCompareMode string
+
+// JudgeRequest defines model for JudgeRequest.
+type JudgeRequest struct {
+	ActionId *string     `json:"ActionId,omitempty"`
+	Args     string      `json:"Args"`
+	Cases    []JudgeCase `json:"Cases"`
+
+	// ExternalOptions external options like CompileCmd or RunCmd
+	ExternalOptions *map[string]string `json:"ExternalOptions,omitempty"`
+	Files           map[string]string  `json:"Files"`
+
+	// FilesMeta encoding and mode of the files, by the file name
+	FilesMeta *map[string]FileMeta `json:"FilesMeta,omitempty"`
+
+	// Stdin data which will available via stdin reader
+	Stdin      string `json:"Stdin"`
+	TemplateId string `json:"TemplateId"`
+
+	// TimeLimit seconds per case, limited by the RunTTL of the template
+	TimeLimit *float32 `json:"TimeLimit,omitempty"`
+}
+
+// JudgeResponse defines model for JudgeResponse.
+type JudgeResponse struct {
+	Cases          []JudgeCaseResult `json:"Cases"`
+	CompileOutput  *string           `json:"CompileOutput,omitempty"`
+	RunEnvironment RunEnvironment    `json:"RunEnvironment"`
+
+	// Verdict not_run is the verdict of the cases which weren't run, or not to the end, because
+	// the time of the request was used up before
+	Verdict JudgeVerdict `json:"Verdict"`
+}
+
+// JudgeVerdict not_run is the verdict of the cases which weren't run, or not to the end, because
+// the time of the request was used up before
+type JudgeVerdict string
+
+// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+type Problem struct {
+	// Code Stable code of the error, clients should rely on it instead of the title or detail.
+	Code   ProblemCode `json:"code"`
+	Detail *string     `json:"detail,omitempty"`
+	Status int         `json:"status"`
+	Title  string      `json:"title"`
+
+	// Type URI of the problem type, urn:codenire:problem:<code>
+	Type string `json:"type"`
+}
+
+// ProblemCode Stable code of the error, clients should rely on it instead of the title or detail.
+type ProblemCode string
+
+// ResourceUsage Resources used by the processes of a compile or run command, measured from the container stats
+type ResourceUsage struct {
+	// CPUSystemTime CPU time in kernel mode, in seconds
+	CPUSystemTime float32 `json:"CPUSystemTime"`
+
+	// CPUUserTime CPU time in user mode, in seconds
+	CPUUserTime float32 `json:"CPUUserTime"`
+
+	// PeakMemory peak memory usage of the container while the command runs, in bytes. It's the high-water
+	// mark of the memory cgroup if the command raised it, otherwise the usage without the page
+	// cache sampled while the command runs
+	PeakMemory int64 `json:"PeakMemory"`
+
+	// Processes peak number of processes started by the command sampled while it runs
+	Processes int `json:"Processes"`
+}
+
 // RunEnvironment defines model for RunEnvironment.
 type RunEnvironment struct {
 	ActionName  string  `json:"ActionName"`
 	CompileCmd  string  `json:"CompileCmd"`
 	CompileTime float32 `json:"CompileTime"`
-	RunCmd      string  `json:"RunCmd"`
-	RunTime     float32 `json:"RunTime"`
+
+	// CompileUsage Resources used by the processes of a compile or run command, measured from the container stats
+	CompileUsage *ResourceUsage `json:"CompileUsage,omitempty"`
+	RunCmd       string         `json:"RunCmd"`
+	RunTime      float32        `json:"RunTime"`
+
+	// RunUsage Resources used by the processes of a compile or run command, measured from the container stats
+	RunUsage *ResourceUsage `json:"RunUsage,omitempty"`
+
+	// Steps results of the steps of a multi-step action, in order
+	Steps *[]StepResult `json:"Steps,omitempty"`
+}
+
+// SandboxActiveRun run, judge or format request which holds a container
+type SandboxActiveRun struct {
+	Action string `json:"Action"`
+
+	// Age seconds since the request got its container
+	Age         float32   `json:"Age"`
+	ContainerId string    `json:"ContainerId"`
+	Id          string    `json:"Id"`
+	StartedAt   time.Time `json:"StartedAt"`
+	Template    string    `json:"Template"`
+}
+
+// SandboxJudgeCase defines model for SandboxJudgeCase.
+type SandboxJudgeCase struct {
+	Args  string `json:"args"`
+	Stdin string `json:"stdin"`
+}
+
+// SandboxJudgeCaseResult defines model for SandboxJudgeCaseResult.
+type SandboxJudgeCaseResult struct {
+	ExitCode int `json:"exitCode"`
+
+	// NotRun the case wasn't run, or not to the end, because the time of the request was used up
+	NotRun bool `json:"notRun"`
+
+	// OomKilled a process of the case was killed for running out of memory
+	OomKilled bool    `json:"oomKilled"`
+	Stderr    []byte  `json:"stderr"`
+	Stdout    []byte  `json:"stdout"`
+	Time      float32 `json:"time"`
+	TimedOut  bool    `json:"timedOut"`
+}
+
+// SandboxJudgeRequest defines model for SandboxJudgeRequest.
+type SandboxJudgeRequest struct {
+	Action string `json:"action"`
+	Args   string `json:"args"`
+
+	// Binary files in tar archive encoded with base64
+	Binary          string             `json:"binary"`
+	Cases           []SandboxJudgeCase `json:"cases"`
+	ExtendedOptions *map[string]string `json:"extendedOptions,omitempty"`
+	SandId          string             `json:"sandId"`
+
+	// Stdin data which will available via stdin reader
+	Stdin     string   `json:"stdin"`
+	TimeLimit *float32 `json:"timeLimit,omitempty"`
+}
+
+// SandboxJudgeResponse defines model for SandboxJudgeResponse.
+type SandboxJudgeResponse struct {
+	RunEnvironment RunEnvironment           `json:"RunEnvironment"`
+	Cases          []SandboxJudgeCaseResult `json:"cases"`
+	CompileFailed  bool                     `json:"compileFailed"`
+	CompileOutput  *[]byte                  `json:"compileOutput,omitempty"`
+	Error          *string                  `json:"error,omitempty"`
+}
+
+// SandboxKillAllResponse defines model for SandboxKillAllResponse.
+type SandboxKillAllResponse struct {
+	// Generation generation of the containers started from now on
+	Generation int64 `json:"Generation"`
+	KilledRuns int   `json:"KilledRuns"`
 }
 
 // SandboxRequest defines model for SandboxRequest.
@@ -148,10 +591,127 @@ type SandboxRequest struct {
 // SandboxResponse defines model for SandboxResponse.
 type SandboxResponse struct {
 	RunEnvironment RunEnvironment `json:"RunEnvironment"`
+	Artifacts      *[]Artifact    `json:"artifacts,omitempty"`
 	Error          *string        `json:"error,omitempty"`
 	ExitCode       int            `json:"exitCode"`
-	Stderr         []byte         `json:"stderr"`
-	Stdout         []byte         `json:"stdout"`
+	Signal         *string        `json:"signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"status"`
+	Stderr []byte          `json:"stderr"`
+	Stdout []byte          `json:"stdout"`
+}
+
+// SandboxStreamEvent defines model for SandboxStreamEvent.
+type SandboxStreamEvent struct {
+	// Artifacts artifacts of the run, sent with the done event
+	Artifacts *[]Artifact `json:"Artifacts,omitempty"`
+	Data      []byte      `json:"Data"`
+
+	// Kind stdout, stderr, phase, error or done
+	Kind string `json:"Kind"`
+
+	// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+	Problem        *Problem         `json:"Problem,omitempty"`
+	Result         *ExecutionResult `json:"Result,omitempty"`
+	RunEnvironment *RunEnvironment  `json:"RunEnvironment,omitempty"`
+}
+
+// SandboxTemplateStatus defines model for SandboxTemplateStatus.
+type SandboxTemplateStatus struct {
+	ActiveRuns int `json:"ActiveRuns"`
+
+	// State draining templates reject new runs and let the running ones finish, disabled templates also kill the running ones and aren't listed in /templates
+	State          SandboxTemplateStatusState `json:"State"`
+	Template       string                     `json:"Template"`
+	WarmContainers int                        `json:"WarmContainers"`
+}
+
+// SandboxTemplateStatusState draining templates reject new runs and let the running ones finish, disabled templates also kill the running ones and aren't listed in /templates
+type SandboxTemplateStatusState string
+
+// SandboxWarmContainer started container of a template waiting for a run
+type SandboxWarmContainer struct {
+	// Age seconds since the container was started
+	Age float32 `json:"Age"`
+
+	// Generation generation of the sandbox containers, incremented by kill-all
+	Generation int64     `json:"Generation"`
+	Id         string    `json:"Id"`
+	StartedAt  time.Time `json:"StartedAt"`
+	Template   string    `json:"Template"`
+}
+
+// ShareResponse defines model for ShareResponse.
+type ShareResponse struct {
+	// Id Snippet ID, the submission is served at /p/{Id}
+	Id string `json:"Id"`
+}
+
+// StepPhase a failing compile step is reported as compile_error and a failing run step as runtime_error, the time and usage of a step are added to the ones of its phase
+type StepPhase string
+
+// StepResult defines model for StepResult.
+type StepResult struct {
+	Cmd string `json:"Cmd"`
+
+	// ExitCode exit code of the command, -1 if it didn't exit on its own or was skipped
+	ExitCode int    `json:"ExitCode"`
+	Name     string `json:"Name"`
+
+	// Phase a failing compile step is reported as compile_error and a failing run step as runtime_error, the time and usage of a step are added to the ones of its phase
+	Phase StepPhase `json:"Phase"`
+
+	// Status skipped if an earlier step failed and stopped the action
+	Status StepResultStatus `json:"Status"`
+
+	// Time wall-clock time of the command, in seconds
+	Time float32 `json:"Time"`
+
+	// Usage Resources used by the processes of a compile or run command, measured from the container stats
+	Usage *ResourceUsage `json:"Usage,omitempty"`
+}
+
+// StepResultStatus skipped if an earlier step failed and stopped the action
+type StepResultStatus string
+
+// StreamDoneEvent defines model for StreamDoneEvent.
+type StreamDoneEvent struct {
+	ActionName  string      `json:"ActionName"`
+	Artifacts   *[]Artifact `json:"Artifacts,omitempty"`
+	CompileCmd  string      `json:"CompileCmd"`
+	CompileTime float32     `json:"CompileTime"`
+
+	// CompileUsage Resources used by the processes of a compile or run command, measured from the container stats
+	CompileUsage *ResourceUsage `json:"CompileUsage,omitempty"`
+	ExitCode     int            `json:"ExitCode"`
+	RunCmd       string         `json:"RunCmd"`
+	RunTime      float32        `json:"RunTime"`
+
+	// RunUsage Resources used by the processes of a compile or run command, measured from the container stats
+	RunUsage *ResourceUsage `json:"RunUsage,omitempty"`
+	Signal   *string        `json:"Signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"Status"`
+
+	// Steps results of the steps of a multi-step action, in order
+	Steps *[]StepResult `json:"Steps,omitempty"`
+}
+
+// SubmissionArchiveRequest defines model for SubmissionArchiveRequest.
+type SubmissionArchiveRequest struct {
+	ActionId *string `json:"ActionId,omitempty"`
+
+	// Archive .zip or .tar.gz archive of the project, sent as file part
+	Archive    string  `json:"Archive"`
+	Args       *string `json:"Args,omitempty"`
+	Stdin      *string `json:"Stdin,omitempty"`
+	TemplateId string  `json:"TemplateId"`
 }
 
 // SubmissionRequest defines model for SubmissionRequest.
@@ -163,6 +723,9 @@ type SubmissionRequest struct {
 	ExternalOptions *map[string]string `json:"ExternalOptions,omitempty"`
 	Files           map[string]string  `json:"Files"`
 
+	// FilesMeta encoding and mode of the files, by the file name
+	FilesMeta *map[string]FileMeta `json:"FilesMeta,omitempty"`
+
 	// Stdin data which will available via stdin reader
 	Stdin      string `json:"Stdin"`
 	TemplateId string `json:"TemplateId"`
@@ -170,12 +733,31 @@ type SubmissionRequest struct {
 
 // SubmissionResponse defines model for SubmissionResponse.
 type SubmissionResponse struct {
-	Events         []SubmissionResponseEvents `json:"Events"`
-	RunEnvironment RunEnvironment             `json:"RunEnvironment"`
+	// Annotations values added by the run hooks
+	Annotations *map[string]string `json:"Annotations,omitempty"`
+	Artifacts   *[]Artifact        `json:"Artifacts,omitempty"`
+
+	// Diagnostics errors and warnings parsed from the compiler output of a compile_error
+	Diagnostics *[]Diagnostic              `json:"Diagnostics,omitempty"`
+	Events      []SubmissionResponseEvents `json:"Events"`
+
+	// ExitCode exit code of the failed command, -1 if it didn't exit on its own
+	ExitCode       int            `json:"ExitCode"`
+	RunEnvironment RunEnvironment `json:"RunEnvironment"`
+
+	// Signal signal which terminated the failed command, like SIGKILL
+	Signal *string `json:"Signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"Status"`
 }
 
 // SubmissionResponseEvents defines model for SubmissionResponseEvents.
 type SubmissionResponseEvents struct {
+	// Delay nanoseconds to wait before showing the message, from the playback headers of faketime programs
+	Delay   int64  `json:"Delay"`
 	Kind    string `json:"Kind"`
 	Message string `json:"Message"`
 }
@@ -194,14 +776,897 @@ type SubmissionScriptRequest struct {
 	TemplateId string `json:"TemplateId"`
 }
 
+// TemplateChange defines model for TemplateChange.
+type TemplateChange struct {
+	Kind     TemplateChangeKind `json:"Kind"`
+	Template string             `json:"Template"`
+	Version  string             `json:"Version"`
+}
+
+// TemplateChangeKind defines model for TemplateChange.Kind.
+type TemplateChangeKind string
+
 // TemplateItemResponse defines model for TemplateItemResponse.
 type TemplateItemResponse struct {
 	Actions    *[]string `json:"Actions,omitempty"`
 	TemplateId *string   `json:"TemplateId,omitempty"`
 }
 
+// TerminalControl Text message sent to a terminal session to control it.
+type TerminalControl struct {
+	Cols *int                `json:"Cols,omitempty"`
+	Rows *int                `json:"Rows,omitempty"`
+	Type TerminalControlType `json:"Type"`
+}
+
+// TerminalControlType defines model for TerminalControl.Type.
+type TerminalControlType string
+
+// TerminalEvent Text message sent by a terminal session.
+type TerminalEvent struct {
+	// Kind phase, error or done, the session is closed after the done event
+	Kind string `json:"Kind"`
+
+	// Message the phase or the error
+	Message *string `json:"Message,omitempty"`
+
+	// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+	Problem *Problem         `json:"Problem,omitempty"`
+	Result  *StreamDoneEvent `json:"Result,omitempty"`
+}
+
+// TerminalSession defines model for TerminalSession.
+type TerminalSession struct {
+	ExpiresAt time.Time `json:"ExpiresAt"`
+
+	// Id Session ID, the terminal is connected at /run/terminal/{Id}
+	Id string `json:"Id"`
+}
+
+// FormatCodeJSONRequestBody defines body for FormatCode for application/json ContentType.
+type FormatCodeJSONRequestBody = FormatRequest
+
+// CreateSubmissionJobJSONRequestBody defines body for CreateSubmissionJob for application/json ContentType.
+type CreateSubmissionJobJSONRequestBody = SubmissionRequest
+
+// JudgeSubmissionJSONRequestBody defines body for JudgeSubmission for application/json ContentType.
+type JudgeSubmissionJSONRequestBody = JudgeRequest
+
 // RunFilesSubmissionJSONRequestBody defines body for RunFilesSubmission for application/json ContentType.
 type RunFilesSubmissionJSONRequestBody = SubmissionRequest
 
+// RunArchiveSubmissionMultipartRequestBody defines body for RunArchiveSubmission for multipart/form-data ContentType.
+type RunArchiveSubmissionMultipartRequestBody = SubmissionArchiveRequest
+
 // RunScriptSubmissionJSONRequestBody defines body for RunScriptSubmission for application/json ContentType.
 type RunScriptSubmissionJSONRequestBody = SubmissionScriptRequest
+
+// RunFilesSubmissionStreamJSONRequestBody defines body for RunFilesSubmissionStream for application/json ContentType.
+type RunFilesSubmissionStreamJSONRequestBody = SubmissionRequest
+
+// CreateTerminalSessionJSONRequestBody defines body for CreateTerminalSession for application/json ContentType.
+type CreateTerminalSessionJSONRequestBody = SubmissionRequest
+
+// ShareSubmissionJSONRequestBody defines body for ShareSubmission for application/json ContentType.
+type ShareSubmissionJSONRequestBody = SubmissionRequest
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// Get with refresh Action List
+	// (GET /actions)
+	RefreshActionList(w http.ResponseWriter, r *http.Request)
+	// Get Actual Action List
+	// (POST /actions)
+	GetActionList(w http.ResponseWriter, r *http.Request)
+	// Template Change Notifications
+	// (GET /actions/events)
+	ActionEvents(w http.ResponseWriter, r *http.Request)
+	// Refresh Templates
+	// (POST /admin/templates/refresh)
+	RefreshTemplates(w http.ResponseWriter, r *http.Request)
+	// Format Code
+	// (POST /fmt)
+	FormatCode(w http.ResponseWriter, r *http.Request)
+	// Create Asynchronous Submission Job
+	// (POST /jobs)
+	CreateSubmissionJob(w http.ResponseWriter, r *http.Request)
+	// Cancel Submission Job
+	// (DELETE /jobs/{id})
+	CancelSubmissionJob(w http.ResponseWriter, r *http.Request, id string)
+	// Get Submission Job
+	// (GET /jobs/{id})
+	GetSubmissionJob(w http.ResponseWriter, r *http.Request, id string)
+	// Judge Submission
+	// (POST /judge)
+	JudgeSubmission(w http.ResponseWriter, r *http.Request)
+	// Get Shared Submission
+	// (GET /p/{id})
+	GetSharedSubmission(w http.ResponseWriter, r *http.Request, id string)
+	// Run Multi Files Submission
+	// (POST /run)
+	RunFilesSubmission(w http.ResponseWriter, r *http.Request)
+	// Run Archive Submission
+	// (POST /run-archive)
+	RunArchiveSubmission(w http.ResponseWriter, r *http.Request)
+	// Run Script Submission
+	// (POST /run-script)
+	RunScriptSubmission(w http.ResponseWriter, r *http.Request)
+	// Run Multi Files Submission with streamed output
+	// (POST /run/stream)
+	RunFilesSubmissionStream(w http.ResponseWriter, r *http.Request)
+	// Create Terminal Session
+	// (POST /run/terminal)
+	CreateTerminalSession(w http.ResponseWriter, r *http.Request)
+	// Connect Terminal Session
+	// (GET /run/terminal/{id})
+	ConnectTerminalSession(w http.ResponseWriter, r *http.Request, id string)
+	// Share Submission
+	// (POST /share)
+	ShareSubmission(w http.ResponseWriter, r *http.Request)
+}
+
+// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+
+type Unimplemented struct{}
+
+// Get with refresh Action List
+// (GET /actions)
+func (_ Unimplemented) RefreshActionList(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get Actual Action List
+// (POST /actions)
+func (_ Unimplemented) GetActionList(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Template Change Notifications
+// (GET /actions/events)
+func (_ Unimplemented) ActionEvents(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Refresh Templates
+// (POST /admin/templates/refresh)
+func (_ Unimplemented) RefreshTemplates(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Format Code
+// (POST /fmt)
+func (_ Unimplemented) FormatCode(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Create Asynchronous Submission Job
+// (POST /jobs)
+func (_ Unimplemented) CreateSubmissionJob(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Cancel Submission Job
+// (DELETE /jobs/{id})
+func (_ Unimplemented) CancelSubmissionJob(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get Submission Job
+// (GET /jobs/{id})
+func (_ Unimplemented) GetSubmissionJob(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Judge Submission
+// (POST /judge)
+func (_ Unimplemented) JudgeSubmission(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get Shared Submission
+// (GET /p/{id})
+func (_ Unimplemented) GetSharedSubmission(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Run Multi Files Submission
+// (POST /run)
+func (_ Unimplemented) RunFilesSubmission(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Run Archive Submission
+// (POST /run-archive)
+func (_ Unimplemented) RunArchiveSubmission(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Run Script Submission
+// (POST /run-script)
+func (_ Unimplemented) RunScriptSubmission(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Run Multi Files Submission with streamed output
+// (POST /run/stream)
+func (_ Unimplemented) RunFilesSubmissionStream(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Create Terminal Session
+// (POST /run/terminal)
+func (_ Unimplemented) CreateTerminalSession(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Connect Terminal Session
+// (GET /run/terminal/{id})
+func (_ Unimplemented) ConnectTerminalSession(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Share Submission
+// (POST /share)
+func (_ Unimplemented) ShareSubmission(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// RefreshActionList operation middleware
+func (siw *ServerInterfaceWrapper) RefreshActionList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RefreshActionList(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetActionList operation middleware
+func (siw *ServerInterfaceWrapper) GetActionList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetActionList(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// ActionEvents operation middleware
+func (siw *ServerInterfaceWrapper) ActionEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ActionEvents(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RefreshTemplates operation middleware
+func (siw *ServerInterfaceWrapper) RefreshTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RefreshTemplates(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// FormatCode operation middleware
+func (siw *ServerInterfaceWrapper) FormatCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.FormatCode(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// CreateSubmissionJob operation middleware
+func (siw *ServerInterfaceWrapper) CreateSubmissionJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateSubmissionJob(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// CancelSubmissionJob operation middleware
+func (siw *ServerInterfaceWrapper) CancelSubmissionJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CancelSubmissionJob(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetSubmissionJob operation middleware
+func (siw *ServerInterfaceWrapper) GetSubmissionJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSubmissionJob(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// JudgeSubmission operation middleware
+func (siw *ServerInterfaceWrapper) JudgeSubmission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.JudgeSubmission(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetSharedSubmission operation middleware
+func (siw *ServerInterfaceWrapper) GetSharedSubmission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSharedSubmission(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RunFilesSubmission operation middleware
+func (siw *ServerInterfaceWrapper) RunFilesSubmission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RunFilesSubmission(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RunArchiveSubmission operation middleware
+func (siw *ServerInterfaceWrapper) RunArchiveSubmission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RunArchiveSubmission(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RunScriptSubmission operation middleware
+func (siw *ServerInterfaceWrapper) RunScriptSubmission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RunScriptSubmission(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RunFilesSubmissionStream operation middleware
+func (siw *ServerInterfaceWrapper) RunFilesSubmissionStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RunFilesSubmissionStream(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// CreateTerminalSession operation middleware
+func (siw *ServerInterfaceWrapper) CreateTerminalSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateTerminalSession(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// ConnectTerminalSession operation middleware
+func (siw *ServerInterfaceWrapper) ConnectTerminalSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ConnectTerminalSession(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// ShareSubmission operation middleware
+func (siw *ServerInterfaceWrapper) ShareSubmission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ShareSubmission(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{})
+}
+
+type ChiServerOptions struct {
+	BaseURL          string
+	BaseRouter       chi.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
+	r := options.BaseRouter
+
+	if r == nil {
+		r = chi.NewRouter()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/actions", wrapper.RefreshActionList)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/actions", wrapper.GetActionList)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/actions/events", wrapper.ActionEvents)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/admin/templates/refresh", wrapper.RefreshTemplates)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/fmt", wrapper.FormatCode)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/jobs", wrapper.CreateSubmissionJob)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/jobs/{id}", wrapper.CancelSubmissionJob)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/jobs/{id}", wrapper.GetSubmissionJob)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/judge", wrapper.JudgeSubmission)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/p/{id}", wrapper.GetSharedSubmission)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/run", wrapper.RunFilesSubmission)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/run-archive", wrapper.RunArchiveSubmission)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/run-script", wrapper.RunScriptSubmission)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/run/stream", wrapper.RunFilesSubmissionStream)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/run/terminal", wrapper.CreateTerminalSession)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/run/terminal/{id}", wrapper.ConnectTerminalSession)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/share", wrapper.ShareSubmission)
+	})
+
+	return r
+}
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+xde3MbN5L/Kqi5q0pyN6Ls3ezjtH9pZcelrLPxSfJtXUUuCZxpkrBmgAmAEcW4+N2v",
+	"ugHME0NStpTK7d1fojgYoNFv/NAAPyWZKislQVqTnHxKNJhKSQP0zzut5gWU+DFT0oK0+JFXVSEyboWS",
+	"x5Vr8e8fjZL4zGQrKDl++lcNi+Qk+Zfjtv9j99Qch363222a5GAyLSrsLjlJXmutNHPfzSFn8w3zY7Ac",
+	"LBeFSfAl3xMOdJrhq+cWygtPOxFZFD8ukpOfdhNyXvIluA7OlFyIZbJND3jjCsqq4BbCOx/SxG4qSE4S",
+	"Nf8ImU22qSfrrTC2S5awUJp97InMaNuMwLXmm3aASwsV9ldpVYG2wgnurMzxT5+1ZgVFwTJVllzmTNeS",
+	"CcnsCtha6btc6JR9Or14c7ll+PjT5dWr879vGdfANFQFzyBnhbgDfOmiljhCQ5OxWkjkXXKmpBWyhh8l",
+	"CXJMBGcLLgohl0xpZkUJOVO1ZcZCxXIFRn6F/6iKCOM0RaInPMO36ZmuZUvAXKkCuEQK/s5L4vSItB9r",
+	"W9U2QlFRMGM18NJQx4raMbWg/5CwlCl5A6SYShYbpsHWWhomLBNtKyLNpEwqCSwXJuM6xzZJmoCsy+Tk",
+	"JxwqSZPQWZIm2Db5EOHjuxU3sE9PUPau4TZNrq7eRkQOmZK5m1cQfMk3yLyUnamyEgVcXb1FWVzUkj65",
+	"CVmv4WR/OATOVCrLDNiW7UJaWIImi9Twcy005DhPkkFKWhg1DW3FgmcRWXwnCmBrLawFiUMjKZVWS81L",
+	"tl6JbMVKbrMVGMYl474btizUPBDuVCZJhwbRuq/+iAsc0Tu3lEFZ2U0QKj2ChwzAc9CIX4BlvDKNcnCZ",
+	"z9VDkiYLpUtuURM3FmKG8YMo4WpTxVUz6GyftIrbFdNQcCvugVnVtdXYEJfiF+qloUVI+8dvD5ZWQ6Hv",
+	"KW2YFpPhmSpLJS/reSmMEUpewM81GDt2Rd6ZeX+04HWBpIVPkWmc6qWJcun1gwUtefEjMYja8DwX+A8v",
+	"3vUGHb3b5yz4nphyXTnH5u3hrMy9PfR8XDv1S5sLORZXzi33SroWRcH4PRcFnxfA7gVnBl9iGngOUeGF",
+	"kOIYNXg8EFinrWdXICouKWm5kKA7fBsaR3AEnbEbbUmTH6BUevNWlMLGGzjfEXs2oPxS1ToDtPIoqa8E",
+	"X0plrMhiRBZ1GWH7y6M5N5CzjJ6n7EUw38zNSjehQ0OltHUeeTwHIipug6rjDx5hj2+FhCmGGsOXcV9w",
+	"Cfeghd3gwxA3QrhYcy2xWZoIuVCRwDFgN83JE5IGDnaGaCmJSeP1A2S1Jds2ZLVDkbx+EPZM5ROTvBRL",
+	"yYv4HC239d40qBnfNx8pk/s6benYOYt20L6I1V0adMUHeZ8eWVGGb/oqhe4B06cQUeFBWMjZWtjVteRM",
+	"Knn0C2jFMpVDSkmOqm3KlCpv7kRRQN72502TaS4pD1ILVpK1pUjFtRTSeao+HT7uUMYBOaoikkNPGod8",
+	"LTuJh7pL0qQ3ySRNelNM0sQTmqRJSympWpeEaLaCevYDWB5REZmpHFv1vL+FBxymLwc04z9+yxZKs7mQ",
+	"XG/I4Axbr5RpQjQThvmGgF1D3pml79Y9jxL6g9fWgZGD9kxjc2FN195TFxq+/Y/fs69f/OkPf/iG6OOS",
+	"AakVuXfXVTzOjtTxOwrOTxctkfWPC4VjkrCLIL6pbnYZaiP+caD14ieTKlUOXd6aNKR4+B+TLg0ZkffZ",
+	"kdGx5sMOIbTrsoHWxtcu/ZWBS7MskFUK643RK4zZSMsfGJmM+QsjUlB3XYJpVyCfRpiDZNaTlDM0hSkm",
+	"/6VvX1yDi464qIFIxhMJKnG2jlfTY/32OXvED2MW37M947MptwR11DG+QI77BWA6FY7Z1yQFVdvjf5tV",
+	"cvlNkrZL7ymLaFbWbSIYbf7KWePjhQUPvKwKn6Or4znXx44Bs48mOUlms5lRZePqyPXm8DD1sOLZHV/C",
+	"zEEvkQZGZzv7j/mC1xJdWsi0z1yAM31v5FaxfeGdW8aLQq0NU/egtSCj7yTUaP8uo2ZCMuAZrmzIB846",
+	"DpyWwxSY2nCVpDRgNOyQukexjhCavUUgNa1aCckI0BjhH6Q0S7UoLTtas1nMRM/jOnFuXgX+dFi14IWB",
+	"R4EUjkfxpImm10ni98JUzgj772FHFqpYHqRz0JATlmE85jMpxTRgQcbyokjZvBZFnjILxob0acYGCzaW",
+	"K3Q0XkEAWV927XI/JIaUjw124J/I9wcEorXkhrdDTg4MuivLSXuY9H6t32sByFiEN58ba2OI5R6HHUaM",
+	"EP1EWOekto38f2cBuDeY71ksxqgbrxp5tgIUYcwu+/p/1WJNFBQRbwZdCimMFVnKjIswVcE3S61qj6Rl",
+	"OAA+EJppWieZWRSXPFNSQiv7/tivuOWYtpoZO6u1BmmLTQdAINTxp68qZexSg/nqwyPD2RgA2CX2Ufsm",
+	"LvTTUqvrqHN7o1XtHMzhNJ6by7qqlLbvXFQ7zI2+0+pe5KCjQwTViD78L9BGKBl99g+/pD8420yaObeM",
+	"irC9HbUdozOHCBP6WpN2tDlmEN+r+XRKe6aBW8hPbQ8hzLmFIyvKKGTZZMGHBsHOdtFBuz9p0qV3J9Ld",
+	"wRnbHZHDYITv1dw33KbJ+yp/HBtiwaUBHlqmdnuekE1LbUh3fq6hJlVxqY5DdnQtPcaTu2zIrSuwFZcZ",
+	"4MdYLvR9nS/hjMdEPwmoYnTkei/zXdeuLS2gCVWpILOQX9pc1XYC5PEw6dgyVQEaJxPZjJkbVdTWQSxt",
+	"bu/bN6uDQnFL68mUvYSjP+ISp10jt2JV9bzoyFTW5TwGShKhDZI6mFpUmoHZn4mM2Ry0nmLaFD+vRC9n",
+	"DJMhr5KLzB4kxtB2yIPwvR+mg6k1NDV0T3OkoyMjycIDbtVkrolhuE+CYsO/KbNalLgVKJZS4VOr/Sbh",
+	"eiUsmIo72RdC0tZPfi2bFm5FTU9SrxjNII5HhpA5B7hx01GnHkRG9CVp4klBPcLOpo2tg+Ecttk83izZ",
+	"pkPFQaUyB28Ut0YfCakoyAazj28LVqCJJZjNl4Rheqwgvhm415Ic9WP9mNSYHfHq8xjhDXJ6Ud/uAsfW",
+	"Xq/lvdBKln6rcNeQg9ZPboeOASOqJlnZGb0vbKnsDW32u03Me9cuyBbFH4CWNXgoCJEVpWm/1+MqIPOU",
+	"zSHjtYFrid9guAyd+KU8W3PDatyMqSs2h4XSfRPjWQaVJdtaayWXN1yaNQTo+YZ0MIJLO0C8eeyCJZWf",
+	"3LT76DTH+FZ6m5XESk1CwUsAAy6+O2N/+vOLPw3LTjyUwL6eKn/5Zjbadc68JzwgGzrzgdWNFtVP0yQQ",
+	"45BihS1gR6Y9nPv7i/MgvDBPbJiyWssTJFsKDSf+0cl1/eLF7zP8mj7tT5PoaaCqoTx1DImpcJcLI2Iv",
+	"Hc6edfBjEnzKskIgM5lZqbrIMWPYMCWpMEMaCzwP7YkSRpVFyN8u6CTkPS9EfuOVmBTOrlR+g0pFoBZp",
+	"rH98Y5W6Kbim7Dw4Rmq6wDVhkiauAqH3Fb5Tcrm5sdhD5i27lry2K6XFLy7cKD0XeU7IsIaPlIPczDc3",
+	"K0W7N/jnpskFNY7qnXaSJj/XyvIbV6vgc8WKZ8Juut99VPMeVUaKqgLb/87tLN3Usll9dr413NY4crdl",
+	"MMFcZXegm3+dtVDfxi1qIO9wzNxoWGgwq3ZKbrHNixsDFCR7dHU/H7AhdQGGwIP3hi8jChUee2/VFppk",
+	"YNAdqgXjE5t9KSuBmxqBsoVW5WAbDxXdjItP3r2/3BgLZUjh+tScvXvvvKmQ7A60hMKntkIyH6nHkTfF",
+	"194b0Pv7rA3og3p8B/zO7fXH9sj4nd+aZDVytQkgzeTXK2RXt9RI19LQoJjlmRk7t1+5ILQSy9XRmlvQ",
+	"17Lk+i505gfIEGCpOruurjcuUFrCpkzZFei1MG44Rw8mebiFSqLkS7iWDpsxBLrnE+Rdy0ZZd1TMkINy",
+	"yjHBGsdFnEerRsZy3UmqwrB9ggTFW7O/TKcr73SgUz3ZdYmN+dpxnhNDKCch6j27I/7x5GrFP28sc2eO",
+	"1TPj3fA4pqxTY17U8vPGm4DKPdDXrRH0TqOsCyuO8AtfiUbqT9D6oVA3jjmVxg5VIgpvB070ZZF25RpT",
+	"i0vn0LHVPVzUkVIbygs/YrKJTtHnQ03uRynkShW5Ybz1CiNn6KiIivA05qvDWsUImUEv21wqy4Q1vbEa",
+	"S3aLt5jbDK2ndnLyqZIV/Uj8bAcAGUOVrtpV1mkoYuwS26XB8WqHFHfAQXwKDjITgM2AWOPhEurnEBKm",
+	"QBLYCZJIZaNK2Czj19wcsFhhB6xVoni9UuXfXAlMpIbZ+/juIoo69OU9C5czSCpz7lb1REcyDRy0t4rU",
+	"NPDQ3qZ2yhnig/zH3hq4IWYsaof8eBJ9r2kruk53XZY14tunH48HUdzL0whK9ijgYGQuEeTAdoGU3QBI",
+	"9ggApM+GKRzkS0GJL2PHNJzis+PvXPYeUaWmSYu47NVZmNhxGHK5N3aY40FQiZ8gqulpUUyz/Q1I0DxE",
+	"qkGhSvNslAO3OR8tDqRaM3Vgguks56KW5oA62g55vTd3THmy5IxPB+TJUOEKiOLV9FRfYblmXGcrhO99",
+	"oZ5DYX1xXkz4DxZkDvnnlHePJo2r04lIbp6+fHvoNt3gDZs8I9M2dDqW75TWczkE3q3AOqz2wr8RcwMw",
+	"uUe4O7qb6eJg83nFwc8TRweS7cS9BtMaB8nD/dAlnTt6fR9fh03XyjVCbLIazIIMSNvudeRKAoN7X0n2",
+	"pYLGCoWDWPs3IWNnz4hJKXM8St2ZopT5Q1Wa+b3Op9lS9tnmQerTRrgvM6yBohAXPNd2yD/k/O3e8Hix",
+	"RIsxM7WT6NcXA1+muaDks4HbmEMUmYQ1wQ1UpFWADcpDrZUEwxZCCrNKWS4MVTJ0+uCFUZTijt/C7nwh",
+	"aSEMBkAh2XHzam8TAKeUpA2R+NGPFcXydtZx/IPrslkkHRI5O6ssx7xRH2mX6ztk13stpvEuEWjxMQII",
+	"AkvYmgsqS6R6cn+acSD8w5bDHQCON/nHQUvhxyU54dBBMx5Be5kGNACHcqFuHLny0AMynt/CSnu4pO4x",
+	"JSr8Fdc70vXziO+7dDg7O3+VDs5oMGGYAX2Pdc2WHVfHn87z7SE1KFHKmtOgOw7dBlCbECph/JkoHN9E",
+	"DsG07yECTu9w0z8ak7YLbHyjgYa5b66B8Tx3h1SwJXkLtSDghoJAxzW0Fb9Te3kdbGzqzHPk2GCbiAxL",
+	"EoTt7So1GP/RS1/Un4scfRq1RHlhxF1Lpryx3aFk86h2T+Knjz/aO3VyyQ+PpHLJgOtCgG7PIoMr2DVW",
+	"Uave6djA8oobQxMgYdInWtDf+JTGTzDql6MbD2teFEdZobK7HurScHb3DsTnoLTTZ48Dr9PIUTFPf9yQ",
+	"MCl7pSQ0idlh2MQo2X5sDrIzA/zCFG57GCjR+KZTt3p7ynO91OFYZWa/iAotama5ni1/adaN7f40UufT",
+	"W27cQZaK68edHt5RjvaIA0ZhEr23djMyCnH1eflPeZTkN32s7NAzTfsqpeOH4Lf7NGIqfTiVUln+pcfb",
+	"73lRg/Fx1/MCAziWEJhkx4UMT4MJtKe4IzHLnYgjwfnTzAZt2fT30/3J7fbAHWfD06sHkdmSEiOU3Psj",
+	"wNGRAH0Hsa4PTzp8qD4094gmG18KCrVntfvEOpjGw2K+QMNCHqWbDgRdnr/52/nbt9HrKZ7k0Ldn+WjK",
+	"6YHHwSeFOLLFV1DwCMQpuVTNtSqKlnG+1o2ZlVqHk2alO1iftlqNB0fmPLtjK0IRKQte8DugNCkcPDkU",
+	"K5bxXHf6YoEBG0PDtEEqaLa7OebO9hwQzwa43wQNUzJ6Qp/bHA9acbmM+NvAyAacyHNf7FWqe/qU0Zuf",
+	"gUxMnzCJQ0WdRWl4ddeMhvddTR4yO/wMzr48KEKLq9hCFESriPu4wlPI3hJc+mYVYSDuPeYrvfDbzPXB",
+	"hJ1FLu8p6G8ppChRUC+jPlCtD2gV7uAJEteAt/rsv0qD3vuwgwnNWmEfC+abCAvGk47jqDHc1KMK0EAK",
+	"WaFM76h0Dwre5TTGW+00II7UVF3+mjDtcCkWtZ1dUrl0XImd0KiEBvMYlCmK7XiuB2ynkauguhRJVZwE",
+	"7uhaHoenB+M8aYfO8TS3W38RzIis03fnBCyWXPKlw33yLvBkmuLck45/Z6fvzpM0uQ+eK3k5ezF7gVNX",
+	"FUheieQk+T19ldLFOMTIY976miUQO5HPPKwOkwtX6Nneh0f+tXPL4O9evNhxw+D4ZsFHHBjuXb83XhKP",
+	"Muc34DdQfHUqc90wInubNsvbicGbabWavk0TU5cl7Zbu7j5NLF+a9sBu8mGbJpUyEZa+Afv/7HTdn2a2",
+	"5sU+Rm7TRk+Pocn3vLoOLVrfgz4iV+2a+tsBfRYslRULQWcmQHY3R6oKuGZf31IOcftNei1xWyN86zOK",
+	"22/Ql7qkgn196z7kt9/M2Ot70Bs3Isu41jgEZ/0EZkbVqn1dcHNs0uI9qoD3gjgOHLlZ9XVh6JBGEnUO",
+	"mZYunjI/GfME8gyTZW627O/E6oyH469xsealkO1O07FXNfL43niGxd+F4v5Gv1Z8TZoetjkwVQeZmxm7",
+	"cD7ZsNtTX69PFJ2wvwLXoJk7HUF0MKvuQNIXcDtL0rgrvOpsiz2/6Q5y4APM1rXEEy+1bAEEz9cvF7Pn",
+	"AuuyoREtctFLdlHaaSm6uz9M97KYsPHtC1NDuXXvYsjeHR9KX8tKg7UC9Ixddq/s6c+9veqnfxeNYXSG",
+	"KGaW/m4SBzX7msO/qnzzKPnuRMd6F0lt+8mD1TVsv1C5Dhk8hIKxDn3XXEVE0nkCtXE9Ms/SoDCXnZzG",
+	"qc1HNTfTevOfeObaDPfhUE+aW13x+KfgForNjOGlEB/VnLlqD2yXXkslM/D75JC7vG8MK7itr14R0ZvX",
+	"V4zIO/4k8m1Ma9yB8raz79X8mdQnsoAeC/Gilv7qqrZ5sl/PfvdkVHavNIjQ972aM3+I/sv1y/GenZqN",
+	"zFZaSVV3p82cKILafa/mXX0jgTplKyBWkXFGZ/cxojt6WadC+KOaz9hpo1D4v9uapYxhHEVcX2MleSZb",
+	"P0AGzc0E2zT53YtvI966Ozc/sacQGY27V0ppfGHyBuxviYmGylGeJivez5GKa16CpaKZnz4lIlx2mqQJ",
+	"7ZicJCIfmXq6I1X8QLaAhcPTztefRxm5X3Ko4UQW3aWnNKbCeuNulMq4gRl7jbeG4cdruQSLthTOMs83",
+	"/sw/mpOgjB3LzdqUAPydDkzJaBJNBc8jH/fUPrdX8R6LmcSbDl8c/YEDJvk1Q3y/Lj1CrT9w/hShncbq",
+	"x5jp+F41znbSqldcQz4Q5zOx6aA46gjqSPapzNz1G2ecqzp6TmvXteza+mCtU0vS52e3qufMZJ5HT6Zt",
+	"qm1FNwKbOsvAmEVdFJunWHjVkv2ABxRjrBgbnLc3Xcsj3tZuTCyqqaY01GqwusI1tlsiTZR34Glg015B",
+	"ei3hwWqeudJRXyzmyKTt35GU0+EdofIre+2O8rP3V98d/Rm/Y66+Ccno3nkaCwEXdSh5OVBj6ahnxbU9",
+	"xuXhUe4LpB+rDoM6m195IfdbUUvPhUeopKNvp/9x+5W/ogPqb5BOuCHX6P+6H4pxYZe8j1u4cocHGuSV",
+	"hPUc09mIeW2ZgfBbKL6whPvy2x7cey3bmwLCb48Iw4zFwne/Zgtw7a1LNG9T+gRa4yfaMrt111TdEpx0",
+	"O8J1p8oQmNvbccCDmzLjxZpvzLUk6ikl5Ow2VxJCt84Fdjrv75pNeLtBdHYv/RPE6KeDuDuaBE2lz7PF",
+	"YCdaR7L7VaSqtvusIuwo7lhzrSC7iwJeGQEeJrYhj+svEbQQ/O8JiN6NFn53k+pd1HB7U+TboKn/gPkl",
+	"Xg3TVMkIi7GedjVTXOtlXLI5dHZLlcxgGhwb7uz+71PYl09G5ZAXERqvhqJ1Un9CwKwZoZXIQSo7XMYN",
+	"LqiqlprnYFzFSKtEztfpiLMPx1SgY038Wl5d/feM/dUlf74Ow5Cv3Aw27CVFBBksz+G67oKpa+lCx6Dg",
+	"xd2y33RqFXPVJP2OHbacgbgHcy175SKDDha+zIICiElZp1bS0g4Mlkz4eV5LumL7avSDZeGER9j+QKsF",
+	"2VhfN6qRNWMyfOULRIVpTgu0xSMTPwymtN8B7cyVfITIC3BTUYoVCoNlx42wNor1K1PoEgcnNHktaeqd",
+	"qLkJdXVeFZtfQmnln+EVYFgwOafJRD2I8zExF9Kx0JcvXo718XItbLby3g5HblWy0sqqTBVPYVKOwINt",
+	"6rlW9mbl74WNx5RL627p3LGJ4u9lwSjM81wDLcPOX82uJYIWQZqGl/0ullzIppOmxfmrmDSxI/htwAtt",
+	"I1QPx71fNavvnUqLEejPoBlLp3y+XFNpxL3IE71DGbZTz1oXyUmysrYyJ8fH4Z7BmVDJ9sP2fwYAGnfQ",
+	"5It0AAA=",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}
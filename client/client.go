@@ -0,0 +1,304 @@
+// Package client is a Go client of the playground API.
+//
+// The requests and responses are the models generated from api/api.yaml,
+// errors answered with problem details are returned as *Error:
+//
+//	c, err := client.New("http://localhost:8081", client.WithToken(jwt))
+//	if err != nil {
+//		return err
+//	}
+//
+//	res, err := c.Run(ctx, api.SubmissionRequest{
+//		TemplateId: "golang",
+//		Files:      map[string]string{"main.go": src},
+//	})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultMinWait    = 500 * time.Millisecond
+	defaultMaxWait    = 30 * time.Second
+)
+
+// Client sends requests to a playground server. It's safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client sending the requests,
+// http.DefaultClient is used by default.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithToken authenticates the requests with the bearer token, the JWT of the
+// user or the admin token for the admin endpoints.
+func WithToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithAPIKey sends the key in the given header, like X-API-Key, for
+// deployments behind a gateway which checks API keys.
+func WithAPIKey(header, key string) Option {
+	return WithHeader(header, key)
+}
+
+// WithHeader sets a header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithRetries sets how many times the requests answered with 429 or 5xx are
+// retried, 3 by default, 0 disables the retries. Every request is retried on
+// 429. On other 5xx responses only idempotent requests (GET, HEAD, PUT, DELETE
+// and OPTIONS) are retried, since runs, jobs and shares may have been done and
+// charged to the quota already. The others are retried on 503 with the code
+// sandbox_unavailable only, which the playground answers before running anything.
+func WithRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithRetryWait sets the bounds of the wait between retries. Without a
+// Retry-After header the wait doubles from min, a Retry-After longer than
+// max isn't waited for and the error is returned.
+func WithRetryWait(minWait, maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.minWait = minWait
+		c.maxWait = maxWait
+	}
+}
+
+// New returns a client of the playground served at baseURL.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+		maxRetries: defaultMaxRetries,
+		minWait:    defaultMinWait,
+		maxWait:    defaultMaxWait,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// request is a request which can be sent again on retries.
+type request struct {
+	method      string
+	path        string
+	body        []byte
+	contentType string
+	accept      string
+}
+
+func jsonRequest(method, path string, v interface{}) (request, error) {
+	req := request{method: method, path: path, accept: "application/json"}
+	if v == nil {
+		return req, nil
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return req, fmt.Errorf("request marshal error: %w", err)
+	}
+	req.body = body
+	req.contentType = "application/json"
+
+	return req, nil
+}
+
+// call sends the request and decodes the JSON response into out.
+func (c *Client) call(ctx context.Context, method, path string, in, out interface{}) error {
+	req, err := jsonRequest(method, path, in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	return decodeResponse(resp, out)
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("response decode error: %w", err)
+	}
+
+	return nil
+}
+
+// do sends the request until it's answered with a status below 400 or one
+// which isn't retried. Error responses are returned as *Error, a successful
+// response must be closed by the caller.
+func (c *Client) do(ctx context.Context, req request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		apiErr := readError(resp)
+		_ = resp.Body.Close()
+
+		wait, ok := c.retryWait(req, apiErr, attempt)
+		if !ok {
+			return nil, apiErr
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	hreq, err := http.NewRequestWithContext(ctx, req.method, c.baseURL+req.path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	for k, v := range c.header {
+		hreq.Header[k] = v
+	}
+	if req.contentType != "" {
+		hreq.Header.Set("Content-Type", req.contentType)
+	}
+	if req.accept != "" {
+		hreq.Header.Set("Accept", req.accept)
+	}
+
+	resp, err := c.httpClient.Do(hreq)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+
+	return resp, nil
+}
+
+// retryWait returns how long to wait before the next attempt, false if the
+// error isn't retried.
+func (c *Client) retryWait(req request, err *Error, attempt int) (time.Duration, bool) {
+	if attempt >= c.maxRetries || !retried(req, err) {
+		return 0, false
+	}
+
+	if err.RetryAfter > 0 {
+		return err.RetryAfter, err.RetryAfter <= c.maxWait
+	}
+
+	wait := c.minWait << attempt
+	if wait <= 0 || wait > c.maxWait {
+		wait = c.maxWait
+	}
+
+	return wait, true
+}
+
+// retried reports whether the request is sent again after the error,
+// see WithRetries.
+func retried(req request, err *Error) bool {
+	switch {
+	case err.StatusCode == http.StatusTooManyRequests:
+		return true
+	case err.StatusCode < http.StatusInternalServerError:
+		return false
+	case idempotent(req.method):
+		return true
+	}
+
+	return err.StatusCode == http.StatusServiceUnavailable && err.Problem.Code == api.SandboxUnavailable
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// parseRetryAfter parses the Retry-After header given in seconds or as HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
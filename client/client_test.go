@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/gorilla/websocket"
+)
+
+func TestRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/run" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"TemplateId":"golang"`) {
+			t.Errorf("unexpected body %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Status":"ok","Events":[{"Kind":"stdout","Message":"hi\n","Delay":0}]}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Run(context.Background(), api.SubmissionRequest{TemplateId: "golang"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != api.ExecutionStatusOk || len(res.Events) != 1 || res.Events[0].Message != "hi\n" {
+		t.Errorf("unexpected response %+v", res)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	cases := []struct {
+		name       string
+		post       bool
+		statuses   []int
+		retryAfter string
+		wantCalls  int
+		wantCode   api.ProblemCode
+	}{
+		{
+			name:      "rate limited then ok",
+			statuses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "unavailable until retries run out",
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			wantCalls: 3,
+			wantCode:  api.SandboxUnavailable,
+		},
+		{
+			name:      "client errors aren't retried",
+			statuses:  []int{http.StatusBadRequest, http.StatusOK},
+			wantCalls: 1,
+			wantCode:  api.InvalidRequest,
+		},
+		{
+			name:      "run rate limited then ok",
+			post:      true,
+			statuses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "run with sandbox unavailable",
+			post:      true,
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "run with internal error isn't retried",
+			post:      true,
+			statuses:  []int{http.StatusInternalServerError, http.StatusOK},
+			wantCalls: 1,
+			wantCode:  api.InternalError,
+		},
+		{
+			name:      "run with gateway timeout isn't retried",
+			post:      true,
+			statuses:  []int{http.StatusGatewayTimeout, http.StatusOK},
+			wantCalls: 1,
+			wantCode:  api.SandboxUnavailable,
+		},
+		{
+			name:      "internal error of a get is retried",
+			statuses:  []int{http.StatusInternalServerError, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:       "retry after beyond max wait",
+			statuses:   []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter: "3600",
+			wantCalls:  1,
+			wantCode:   api.RateLimited,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				status := tc.statuses[calls]
+				calls++
+
+				if status == http.StatusOK && tc.post {
+					_, _ = w.Write([]byte(`{}`))
+					return
+				}
+				if status == http.StatusOK {
+					_, _ = w.Write([]byte(`[]`))
+					return
+				}
+
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"type":"urn:codenire:problem:x","title":"t","status":` +
+					strconv.Itoa(status) + `,"code":"` + string(statusCode(status)) + `"}`))
+			}))
+			defer srv.Close()
+
+			c, err := New(srv.URL, WithRetries(2), WithRetryWait(time.Millisecond, time.Second))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.post {
+				_, err = c.Run(context.Background(), api.SubmissionRequest{TemplateId: "golang"})
+			} else {
+				_, err = c.Actions(context.Background())
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("expected %d calls, got %d", tc.wantCalls, calls)
+			}
+			if got := Code(err); got != tc.wantCode {
+				t.Errorf("expected code %q, got %q (%v)", tc.wantCode, got, err)
+			}
+		})
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		status      int
+		body        string
+		wantCode    api.ProblemCode
+		wantStatus  int
+		wantDetail  string
+	}{
+		{
+			name:        "problem details",
+			contentType: "application/problem+json",
+			status:      http.StatusNotFound,
+			body:        `{"type":"urn:codenire:problem:template_not_found","title":"Not Found","status":404,"code":"template_not_found","detail":"template rust not found"}`,
+			wantCode:    api.TemplateNotFound,
+			wantStatus:  http.StatusNotFound,
+			wantDetail:  "template rust not found",
+		},
+		{
+			name:        "plain text of a proxy",
+			contentType: "text/plain",
+			status:      http.StatusRequestEntityTooLarge,
+			body:        "too large\n",
+			wantCode:    api.RequestTooLarge,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantDetail:  "too large",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			c, err := New(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.RunScript(context.Background(), api.SubmissionScriptRequest{})
+			e, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			if e.Problem.Code != tc.wantCode || e.StatusCode != tc.wantStatus || e.Problem.Status != tc.wantStatus {
+				t.Errorf("unexpected error %+v", e)
+			}
+			if e.Problem.Detail == nil || *e.Problem.Detail != tc.wantDetail {
+				t.Errorf("expected detail %q, got %v", tc.wantDetail, e.Problem.Detail)
+			}
+		})
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("unexpected accept %q", got)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, ": keep-alive\n\n"+
+			"event: phase\ndata: {\"Kind\":\"phase\",\"Message\":\"compile\"}\n\n"+
+			"event: stdout\ndata: {\"Kind\":\"stdout\",\"Message\":\"hi\\n\"}\n\n"+
+			"event: done\ndata: {\"Status\":\"ok\",\"ExitCode\":0,\"ActionName\":\"default\"}\n\n")
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	done, err := c.RunStream(context.Background(), api.SubmissionRequest{}, func(ev api.SubmissionResponseEvents) error {
+		kinds = append(kinds, ev.Kind+":"+ev.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(kinds, ",") != "phase:compile,stdout:hi\n" {
+		t.Errorf("unexpected events %q", kinds)
+	}
+	if done.Status != api.ExecutionStatusOk || done.ActionName != "default" {
+		t.Errorf("unexpected done event %+v", done)
+	}
+}
+
+func TestConnectTerminal(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/run/terminal/abc" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"type":"urn:codenire:problem:terminal_session_not_found","title":"Not Found","status":404,"code":"terminal_session_not_found"}`))
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.WriteMessage(websocket.BinaryMessage, []byte("$ "))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := c.ConnectTerminal(context.Background(), "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, data, rErr := conn.ReadMessage(); rErr != nil || string(data) != "$ " {
+		t.Errorf("unexpected output %q, %v", data, rErr)
+	}
+
+	if _, err = c.ConnectTerminal(context.Background(), "expired"); Code(err) != api.TerminalSessionNotFound {
+		t.Errorf("expected terminal_session_not_found, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"Mon, 01 Jan 2024 00:00:10 GMT": 10 * time.Second,
+		"Sun, 31 Dec 2023 00:00:00 GMT": 0,
+		"soon":                          0,
+	}
+
+	for v, want := range cases {
+		if got := parseRetryAfter(v, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
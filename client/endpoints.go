@@ -0,0 +1,274 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// Run runs a files submission (POST /run).
+func (c *Client) Run(ctx context.Context, req api.SubmissionRequest) (*api.SubmissionResponse, error) {
+	var res api.SubmissionResponse
+	if err := c.call(ctx, http.MethodPost, "/run", req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// RunScript runs a single file script (POST /run-script).
+func (c *Client) RunScript(ctx context.Context, req api.SubmissionScriptRequest) (*api.SubmissionResponse, error) {
+	var res api.SubmissionResponse
+	if err := c.call(ctx, http.MethodPost, "/run-script", req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// RunArchive runs a project uploaded as .zip or .tar.gz archive
+// (POST /run-archive). The Archive field holds the archive content.
+func (c *Client) RunArchive(ctx context.Context, req api.SubmissionArchiveRequest) (*api.SubmissionResponse, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"TemplateId", &req.TemplateId},
+		{"ActionId", req.ActionId},
+		{"Args", req.Args},
+		{"Stdin", req.Stdin},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		if err := mw.WriteField(f.name, *f.value); err != nil {
+			return nil, fmt.Errorf("write %s field: %w", f.name, err)
+		}
+	}
+
+	// The format is detected from the content, the file name doesn't matter.
+	part, err := mw.CreateFormFile("Archive", "archive")
+	if err != nil {
+		return nil, fmt.Errorf("write archive: %w", err)
+	}
+	if _, err = part.Write([]byte(req.Archive)); err != nil {
+		return nil, fmt.Errorf("write archive: %w", err)
+	}
+	if err = mw.Close(); err != nil {
+		return nil, fmt.Errorf("write archive: %w", err)
+	}
+
+	resp, err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/run-archive",
+		body:        buf.Bytes(),
+		contentType: mw.FormDataContentType(),
+		accept:      "application/json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var res api.SubmissionResponse
+	if err = decodeResponse(resp, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// RunStream runs a files submission and calls fn for every stdout, stderr,
+// phase and error event while the program is running (POST /run/stream).
+// It returns the done event which ends the stream.
+func (c *Client) RunStream(
+	ctx context.Context,
+	req api.SubmissionRequest,
+	fn func(api.SubmissionResponseEvents) error,
+) (*api.StreamDoneEvent, error) {
+	r, err := jsonRequest(http.MethodPost, "/run/stream", req)
+	if err != nil {
+		return nil, err
+	}
+	r.accept = "text/event-stream"
+
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var done *api.StreamDoneEvent
+	err = readEvents(resp.Body, func(ev event) error {
+		if ev.name == "done" {
+			done = new(api.StreamDoneEvent)
+			if err2 := ev.decode(done); err2 != nil {
+				return err2
+			}
+			return errStopEvents
+		}
+
+		var out api.SubmissionResponseEvents
+		if err2 := ev.decode(&out); err2 != nil {
+			return err2
+		}
+
+		return fn(out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if done == nil {
+		return nil, fmt.Errorf("stream ended without done event")
+	}
+
+	return done, nil
+}
+
+// CreateJob starts an asynchronous run of a files submission (POST /jobs).
+func (c *Client) CreateJob(ctx context.Context, req api.SubmissionRequest) (*api.JobResponse, error) {
+	var res api.JobResponse
+	if err := c.call(ctx, http.MethodPost, "/jobs", req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Job returns the status of an asynchronous run, with the response once it's done (GET /jobs/{id}).
+func (c *Client) Job(ctx context.Context, id string) (*api.JobResponse, error) {
+	var res api.JobResponse
+	if err := c.call(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// CancelJob cancels a running job, a finished job is deleted (DELETE /jobs/{id}).
+// The returned job is nil if it was deleted.
+//
+//nolint:nilnil
+func (c *Client) CancelJob(ctx context.Context, id string) (*api.JobResponse, error) {
+	r, err := jsonRequest(http.MethodDelete, "/jobs/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var res api.JobResponse
+	if err = decodeResponse(resp, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Judge runs a submission against test cases (POST /judge).
+func (c *Client) Judge(ctx context.Context, req api.JudgeRequest) (*api.JudgeResponse, error) {
+	var res api.JudgeResponse
+	if err := c.call(ctx, http.MethodPost, "/judge", req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Format formats the files with the format command of the action (POST /fmt).
+func (c *Client) Format(ctx context.Context, req api.FormatRequest) (*api.FormatResponse, error) {
+	var res api.FormatResponse
+	if err := c.call(ctx, http.MethodPost, "/fmt", req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Share stores the submission and returns the ID of the snippet (POST /share).
+func (c *Client) Share(ctx context.Context, req api.SubmissionRequest) (*api.ShareResponse, error) {
+	var res api.ShareResponse
+	if err := c.call(ctx, http.MethodPost, "/share", req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Snippet returns a shared submission (GET /p/{id}).
+func (c *Client) Snippet(ctx context.Context, id string) (*api.SubmissionRequest, error) {
+	var res api.SubmissionRequest
+	if err := c.call(ctx, http.MethodGet, "/p/"+url.PathEscape(id), nil, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Actions returns the actions the caller may run (GET /actions).
+func (c *Client) Actions(ctx context.Context) (api.ActionListResponse, error) {
+	var res api.ActionListResponse
+	if err := c.call(ctx, http.MethodGet, "/actions", nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ActionEvents calls fn for every template change until ctx is done or fn
+// returns an error (GET /actions/events). The returned error is nil if the
+// server closed the stream.
+func (c *Client) ActionEvents(ctx context.Context, fn func(api.TemplateChange) error) error {
+	r := request{method: http.MethodGet, path: "/actions/events", accept: "text/event-stream"}
+
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	return readEvents(resp.Body, func(ev event) error {
+		var change api.TemplateChange
+		if err2 := ev.decode(&change); err2 != nil {
+			return err2
+		}
+
+		return fn(change)
+	})
+}
+
+// RefreshTemplates reloads the templates from the sandbox backends
+// (POST /admin/templates/refresh), it requires the admin token.
+func (c *Client) RefreshTemplates(ctx context.Context) ([]api.TemplateChange, error) {
+	var res []api.TemplateChange
+	if err := c.call(ctx, http.MethodPost, "/admin/templates/refresh", nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+const maxErrorBodySize = 64 * 1024
+
+// Error is an error response of the playground.
+type Error struct {
+	// StatusCode of the response.
+	StatusCode int
+	// Problem details of the response. Responses without problem details,
+	// like the ones of a proxy, get the code matching the status code and
+	// the body as detail.
+	Problem api.Problem
+	// RetryAfter is the wait requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Problem.Detail != nil && *e.Problem.Detail != "" {
+		return fmt.Sprintf("%s: %s", e.Problem.Code, *e.Problem.Detail)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Problem.Code, e.Problem.Title)
+}
+
+// Code returns the problem code of err, or an empty code if err isn't an *Error.
+func Code(err error) api.ProblemCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Problem.Code
+	}
+
+	return ""
+}
+
+// readError reads the error response, the body is left open.
+func readError(resp *http.Response) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+	if isJSON(resp.Header.Get("Content-Type")) {
+		var p api.Problem
+		if err := json.Unmarshal(body, &p); err == nil && p.Code != "" {
+			if p.Status == 0 {
+				p.Status = resp.StatusCode
+			}
+			e.Problem = p
+			return e
+		}
+	}
+
+	e.Problem = api.Problem{
+		Type:   "urn:codenire:problem:" + string(statusCode(resp.StatusCode)),
+		Title:  http.StatusText(resp.StatusCode),
+		Status: resp.StatusCode,
+		Code:   statusCode(resp.StatusCode),
+	}
+	if detail := strings.TrimSpace(string(body)); detail != "" {
+		e.Problem.Detail = &detail
+	}
+
+	return e
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	return mediaType == "application/json" || mediaType == "application/problem+json"
+}
+
+// statusCode returns the problem code the playground uses for the status.
+func statusCode(status int) api.ProblemCode {
+	switch status {
+	case http.StatusBadRequest:
+		return api.InvalidRequest
+	case http.StatusUnauthorized:
+		return api.Unauthorized
+	case http.StatusForbidden:
+		return api.Forbidden
+	case http.StatusNotFound:
+		return api.NotFound
+	case http.StatusMethodNotAllowed:
+		return api.MethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return api.RequestTooLarge
+	case http.StatusTooManyRequests:
+		return api.RateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return api.SandboxUnavailable
+	default:
+		return api.InternalError
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The done event of a run carries the artifacts.
+const maxEventSize = 16 * 1024 * 1024
+
+// errStopEvents stops readEvents without an error.
+var errStopEvents = errors.New("stop events")
+
+// event is a server-sent event.
+type event struct {
+	name string
+	data string
+}
+
+func (e event) decode(v interface{}) error {
+	if err := json.Unmarshal([]byte(e.data), v); err != nil {
+		return fmt.Errorf("decode %s event: %w", e.name, err)
+	}
+
+	return nil
+}
+
+// readEvents calls fn for every server-sent event read from r until the end
+// of the stream or fn returns an error. Comments like keep-alives are skipped.
+func readEvents(r io.Reader, fn func(event) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+
+	var (
+		ev   event
+		data []string
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if len(data) == 0 {
+				continue
+			}
+
+			ev.data = strings.Join(data, "\n")
+			if err := fn(ev); err != nil {
+				if errors.Is(err, errStopEvents) {
+					return nil
+				}
+				return err
+			}
+
+			ev, data = event{}, nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			ev.name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read events: %w", err)
+	}
+
+	return nil
+}
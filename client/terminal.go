@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/gorilla/websocket"
+)
+
+// CreateTerminal creates a terminal session for a files submission (POST /run/terminal).
+// It has to be connected with ConnectTerminal before it expires.
+func (c *Client) CreateTerminal(ctx context.Context, req api.SubmissionRequest) (*api.TerminalSession, error) {
+	var res api.TerminalSession
+	if err := c.call(ctx, http.MethodPost, "/run/terminal", req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ConnectTerminal connects the WebSocket of a terminal session, which starts
+// the run (GET /run/terminal/{id}). Binary messages carry the input and the
+// output of the program. The client sends api.TerminalControl text messages
+// and receives api.TerminalEvent text messages, the last one is the done event.
+func (c *Client) ConnectTerminal(ctx context.Context, id string) (*websocket.Conn, error) {
+	wsURL := "ws" + strings.TrimPrefix(c.baseURL, "http") + "/run/terminal/" + url.PathEscape(id)
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, c.header.Clone())
+	if err != nil {
+		if resp != nil {
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			return nil, readError(resp)
+		}
+
+		return nil, fmt.Errorf("connect terminal: %w", err)
+	}
+
+	return conn, nil
+}
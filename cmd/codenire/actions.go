@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func actionsCmd(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("actions", flag.ContinueOnError)
+
+	var sf serverFlags
+	sf.register(fs)
+	template := fs.String("t", "", "list only the actions of the template")
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	c, err := sf.client()
+	if err != nil {
+		return fail(err)
+	}
+
+	actions, err := c.Actions(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *template != "" {
+		filtered := actions[:0]
+		for _, a := range actions {
+			if a.Template == *template {
+				filtered = append(filtered, a)
+			}
+		}
+		actions = filtered
+	}
+
+	if err = printActions(os.Stdout, actions); err != nil {
+		return fail(err)
+	}
+
+	return 0
+}
+
+// printActions prints the actions as table sorted by template,
+// the default action of a template goes first.
+func printActions(w io.Writer, actions api.ActionListResponse) error {
+	sort.SliceStable(actions, func(i, j int) bool {
+		if actions[i].Template != actions[j].Template {
+			return actions[i].Template < actions[j].Template
+		}
+		return actions[i].IsDefault && !actions[j].IsDefault
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEMPLATE\tACTION\tNAME\tVERSION\tDEFAULT\tGROUPS")
+
+	for _, a := range actions {
+		isDefault := ""
+		if a.IsDefault {
+			isDefault = "*"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			a.Template, a.Id, a.Name, a.Version, isDefault, strings.Join(a.Groups, ","))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// collectFiles reads the files to submit. A directory adds the files of its
+// subdirectories, relative to it, "dir/..." is the same as "dir". A single
+// file is added by its base name. Hidden files and directories are skipped
+// when walking directories.
+//
+// The files are encoded like the playground stores extracted archives:
+// UTF-8 files as they are, other files as base64 and executables with their mode.
+func collectFiles(paths []string) (map[string]string, map[string]api.FileMeta, error) {
+	files := make(map[string]string)
+	meta := make(map[string]api.FileMeta)
+
+	add := func(name, src string, mode os.FileMode) error {
+		name = path.Clean(filepath.ToSlash(name))
+		if _, ok := files[name]; ok {
+			return fmt.Errorf("file %s is given twice", name)
+		}
+
+		data, err := os.ReadFile(src) //nolint:gosec
+		if err != nil {
+			return err
+		}
+
+		var m api.FileMeta
+		if utf8.Valid(data) {
+			files[name] = string(data)
+		} else {
+			encoding := api.Base64
+			m.Encoding = &encoding
+			files[name] = base64.StdEncoding.EncodeToString(data)
+		}
+
+		if mode&0o111 != 0 {
+			perm := int(mode.Perm())
+			m.Mode = &perm
+		}
+
+		if m.Encoding != nil || m.Mode != nil {
+			meta[name] = m
+		}
+
+		return nil
+	}
+
+	for _, p := range paths {
+		p = strings.TrimSuffix(p, "...")
+		if p == "" {
+			p = "."
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !info.IsDir() {
+			if err = add(filepath.Base(p), p, info.Mode()); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		root := filepath.Clean(p)
+		err = filepath.WalkDir(root, func(src string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if src != root && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			name, err := filepath.Rel(root, src)
+			if err != nil {
+				return err
+			}
+
+			return add(name, src, fi.Mode())
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no files found in %s", strings.Join(paths, " "))
+	}
+
+	return files, meta, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name string, data []byte, mode os.FileMode) {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("main.go", []byte("package main\n"), 0o644)
+	write("pkg/util.go", []byte("package pkg\n"), 0o644)
+	write("run.sh", []byte("#!/bin/sh\n"), 0o755)
+	write("data.bin", []byte{0xff, 0xfe}, 0o644)
+	write(".git/config", []byte("[core]\n"), 0o644)
+	write(".env", []byte("SECRET=1\n"), 0o644)
+
+	files, meta, err := collectFiles([]string{dir + "/..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"main.go":     "package main\n",
+		"pkg/util.go": "package pkg\n",
+		"run.sh":      "#!/bin/sh\n",
+		"data.bin":    "//4=",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("expected %d files, got %v", len(want), files)
+	}
+	for name, content := range want {
+		if files[name] != content {
+			t.Errorf("file %s: expected %q, got %q", name, content, files[name])
+		}
+	}
+
+	if m := meta["run.sh"]; m.Mode == nil || *m.Mode != 0o755 {
+		t.Errorf("expected mode of run.sh, got %+v", m)
+	}
+	if m := meta["data.bin"]; m.Encoding == nil || *m.Encoding != api.Base64 {
+		t.Errorf("expected base64 encoding of data.bin, got %+v", m)
+	}
+	if _, ok := meta["main.go"]; ok {
+		t.Errorf("unexpected meta of main.go")
+	}
+
+	files, _, err = collectFiles([]string{filepath.Join(dir, "pkg", "util.go"), filepath.Join(dir, "main.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files["util.go"] == "" || files["main.go"] == "" {
+		t.Errorf("expected files by base name, got %v", files)
+	}
+
+	if _, _, err = collectFiles([]string{filepath.Join(dir, "main.go"), dir}); err == nil {
+		t.Errorf("expected error for a file given twice")
+	}
+}
@@ -0,0 +1,102 @@
+// Command codenire runs local files on a Codenire playground and lists its actions.
+//
+// Usage:
+//
+//	codenire run -t <template> [-a <action>] [paths] [-- args]
+//	codenire actions
+//
+// The server is set with -server or CODENIRE_URL, the token with -token or CODENIRE_TOKEN.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/codiewio/codenire/client"
+)
+
+const defaultServer = "http://localhost:8081"
+
+// Exit codes of the tool itself, the run command exits with the code of the program.
+const (
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Usage:
+
+	codenire run -t <template> [-a <action>] [paths] [-- args]
+		runs the files on the server, paths are files, directories or dir/...
+		for a directory with its subdirectories, "." by default
+
+	codenire actions
+		lists the actions the server runs
+
+Run "codenire <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	var code int
+	switch os.Args[1] {
+	case "run":
+		code = runCmd(ctx, os.Args[2:])
+	case "actions":
+		code = actionsCmd(ctx, os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "codenire: unknown command %q\n\n%s", os.Args[1], usage)
+		code = exitUsage
+	}
+
+	stop()
+	os.Exit(code)
+}
+
+// serverFlags are the flags of every command selecting the server.
+type serverFlags struct {
+	server string
+	token  string
+}
+
+func (s *serverFlags) register(fs *flag.FlagSet) {
+	server := os.Getenv("CODENIRE_URL")
+	if server == "" {
+		server = defaultServer
+	}
+
+	fs.StringVar(&s.server, "server", server, "URL of the playground, CODENIRE_URL by default")
+	fs.StringVar(&s.token, "token", os.Getenv("CODENIRE_TOKEN"), "JWT of the user, CODENIRE_TOKEN by default")
+}
+
+func (s *serverFlags) client() (*client.Client, error) {
+	var opts []client.Option
+	if s.token != "" {
+		opts = append(opts, client.WithToken(s.token))
+	}
+
+	return client.New(s.server, opts...)
+}
+
+// fail prints the error and returns the exit code of the tool.
+func fail(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return exitError
+	}
+
+	fmt.Fprintf(os.Stderr, "codenire: %v\n", err)
+
+	return exitError
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// Exit codes of runs which didn't exit on their own, like timeout(1) and a SIGKILL.
+const (
+	exitTimeout = 124
+	exitKilled  = 137
+)
+
+func runCmd(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+
+	var sf serverFlags
+	sf.register(fs)
+	template := fs.String("t", "", "template to run the files with, like golang_1_24 (required)")
+	action := fs.String("a", "", "action of the template, the default one if empty")
+	verbose := fs.Bool("v", false, "print the phases of the run and its environment to stderr")
+
+	paths, progArgs := splitArgs(args)
+	if err := fs.Parse(paths); err != nil {
+		return exitUsage
+	}
+	paths = fs.Args()
+
+	if *template == "" {
+		fmt.Fprintln(os.Stderr, "codenire: -t template is required")
+		fs.Usage()
+		return exitUsage
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, meta, err := collectFiles(paths)
+	if err != nil {
+		return fail(err)
+	}
+
+	stdin, err := readStdin()
+	if err != nil {
+		return fail(err)
+	}
+
+	req := api.SubmissionRequest{
+		TemplateId: *template,
+		Files:      files,
+		Args:       shellJoin(progArgs),
+		Stdin:      stdin,
+	}
+	if *action != "" {
+		req.ActionId = action
+	}
+	if len(meta) > 0 {
+		req.FilesMeta = &meta
+	}
+
+	c, err := sf.client()
+	if err != nil {
+		return fail(err)
+	}
+
+	done, err := c.RunStream(ctx, req, func(ev api.SubmissionResponseEvents) error {
+		switch ev.Kind {
+		case "stdout":
+			_, err2 := io.WriteString(os.Stdout, ev.Message)
+			return err2
+		case "stderr":
+			_, err2 := io.WriteString(os.Stderr, ev.Message)
+			return err2
+		case "error":
+			fmt.Fprintf(os.Stderr, "codenire: %s\n", ev.Message)
+		default:
+			if *verbose {
+				fmt.Fprintf(os.Stderr, "codenire: %s %s\n", ev.Kind, ev.Message)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	if *verbose {
+		fmt.Fprintf(os.Stderr, "codenire: %s, compile %.2fs, run %.2fs\n", done.ActionName, done.CompileTime, done.RunTime)
+	}
+
+	return exitCode(done)
+}
+
+// splitArgs splits the arguments at "--" into the ones of the tool and the
+// ones passed to the program.
+func splitArgs(args []string) ([]string, []string) {
+	for i, a := range args {
+		if a == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+
+	return args, nil
+}
+
+// shellJoin quotes the arguments for the shell which runs the command of the
+// action, so they reach the program as they were given.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes a for sh unless it only has characters without a special meaning.
+func shellQuote(a string) string {
+	if a != "" && strings.Trim(a, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,+@%") == "" {
+		return a
+	}
+
+	return "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+}
+
+// exitCode mirrors the exit code of the program. Runs which were stopped
+// exit like the shell reports them.
+func exitCode(done *api.StreamDoneEvent) int {
+	switch done.Status {
+	case api.ExecutionStatusOk:
+		return 0
+	case api.ExecutionStatusTimeout:
+		return exitTimeout
+	case api.ExecutionStatusOomKilled:
+		return exitKilled
+	case api.ExecutionStatusInternalError:
+		fmt.Fprintln(os.Stderr, "codenire: the sandbox failed to run the files")
+		return exitError
+	case api.ExecutionStatusCompileError, api.ExecutionStatusRuntimeError:
+	}
+
+	if done.ExitCode > 0 {
+		return done.ExitCode
+	}
+
+	if done.Signal != nil {
+		fmt.Fprintf(os.Stderr, "codenire: program terminated by %s\n", *done.Signal)
+	}
+
+	return exitError
+}
+
+// readStdin reads the stdin unless it's a terminal.
+func readStdin() (string, error) {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice != 0 {
+		return "", nil //nolint:nilerr
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("read stdin: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellJoin(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"-n", "3", "input.txt"}, "-n 3 input.txt"},
+		{[]string{"hello world"}, "'hello world'"},
+		{[]string{"a;rm -rf /"}, "'a;rm -rf /'"},
+		{[]string{"it's"}, `'it'\''s'`},
+		{[]string{""}, "''"},
+		{[]string{"$HOME", "*.go"}, "'$HOME' '*.go'"},
+	}
+
+	for _, c := range cases {
+		if got := shellJoin(c.args); got != c.want {
+			t.Errorf("shellJoin(%q) = %s, want %s", c.args, got, c.want)
+		}
+	}
+}
+
+func TestShellJoinRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+
+	args := []string{"a b", "c;d", "it's", "", "$HOME", "`id`", "x\ny", `back\slash`}
+
+	// sh prints every argument it got followed by a NUL byte.
+	out, err := exec.Command(sh, "-c", `printf '%s\0' `+shellJoin(args)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("sh got %q, want %q", got, args)
+	}
+}
@@ -0,0 +1,10 @@
+// Package docs embeds the Swagger UI bundle which the playground serves at /docs.
+package docs
+
+import "embed"
+
+// SwaggerUI holds the files of the Swagger UI in api/, without the source maps.
+//
+//go:embed api/index.html api/index.css api/swagger-ui.css api/swagger-ui-bundle.js api/swagger-ui-standalone-preset.js
+//go:embed api/favicon-16x16.png api/favicon-32x32.png api/oauth2-redirect.html
+var SwaggerUI embed.FS
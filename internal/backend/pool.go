@@ -0,0 +1,233 @@
+// Package backend keeps track of the sandbox backends the playground
+// dispatches runs to.
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codiewio/codenire/internal/client"
+	"github.com/codiewio/codenire/internal/images"
+)
+
+var ErrNoBackend = errors.New("no healthy sandbox backend")
+
+const checkTimeout = 5 * time.Second
+
+// Backend is a single sandbox host.
+type Backend struct {
+	URL string
+
+	mu        sync.RWMutex
+	healthy   bool
+	templates map[string]struct{}
+	lastErr   error
+
+	inflight atomic.Int64
+}
+
+func (b *Backend) Healthy() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.healthy
+}
+
+// Serves reports whether the backend advertises the template in /templates.
+func (b *Backend) Serves(template string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	_, ok := b.templates[template]
+	return ok
+}
+
+func (b *Backend) markUnhealthy(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.healthy = false
+	b.lastErr = err
+}
+
+// check calls /health and refreshes the template list of the backend.
+func (b *Backend) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.URL+"/health", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.SandboxBackendClient().Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected health status %d", resp.StatusCode)
+	}
+
+	list, err := images.PullImageConfigList(b.URL)
+	if err != nil {
+		return err
+	}
+
+	templates := make(map[string]struct{}, len(*list))
+	for _, cfg := range *list {
+		templates[cfg.Template] = struct{}{}
+	}
+
+	b.mu.Lock()
+	b.templates = templates
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Pool is a set of sandbox backends which are health-checked continuously.
+// Runs are routed to the healthy backends which serve the requested template.
+type Pool struct {
+	backends []*Backend
+	next     atomic.Uint64
+}
+
+func NewPool(urls []string) *Pool {
+	p := &Pool{}
+	for _, u := range urls {
+		p.backends = append(p.backends, &Backend{URL: u})
+	}
+
+	return p
+}
+
+func (p *Pool) Backends() []*Backend {
+	return p.backends
+}
+
+// URLs returns the URLs of the healthy backends.
+func (p *Pool) URLs() []string {
+	var urls []string
+	for _, b := range p.backends {
+		if b.Healthy() {
+			urls = append(urls, b.URL)
+		}
+	}
+
+	return urls
+}
+
+// Check health-checks all backends once.
+func (p *Pool) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, b := range p.backends {
+		wg.Add(1)
+		go func(b *Backend) {
+			defer wg.Done()
+
+			err := b.check(ctx)
+			wasHealthy := b.Healthy()
+
+			b.mu.Lock()
+			b.healthy = err == nil
+			b.lastErr = err
+			b.mu.Unlock()
+
+			if err != nil && wasHealthy {
+				log.Printf("sandbox backend %s is unhealthy: %v", b.URL, err)
+			}
+			if err == nil && !wasHealthy {
+				log.Printf("sandbox backend %s is healthy", b.URL)
+			}
+		}(b)
+	}
+	wg.Wait()
+}
+
+// Run health-checks the backends every interval until ctx is done.
+func (p *Pool) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Check(ctx)
+		}
+	}
+}
+
+// Candidates returns the healthy backends serving template, the least busy first.
+func (p *Pool) Candidates(template string) []*Backend {
+	var res []*Backend
+	for _, b := range p.backends {
+		if b.Healthy() && b.Serves(template) {
+			res = append(res, b)
+		}
+	}
+
+	if len(res) < 2 {
+		return res
+	}
+
+	// Rotate before sorting, so backends with equal load take turns.
+	//nolint:gosec
+	offset := int(p.next.Add(1) % uint64(len(res)))
+	res = append(res[offset:], res[:offset]...)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].inflight.Load() < res[j].inflight.Load()
+	})
+
+	return res
+}
+
+// Do calls fn with a backend serving template. When the connection to the
+// backend can't be established, the backend is marked unhealthy and fn is
+// retried with the next candidate.
+func (p *Pool) Do(ctx context.Context, template string, fn func(b *Backend) error) error {
+	candidates := p.Candidates(template)
+	if len(candidates) == 0 {
+		return fmt.Errorf("%w for template `%s`", ErrNoBackend, template)
+	}
+
+	var err error
+	for _, b := range candidates {
+		b.inflight.Add(1)
+		err = fn(b)
+		b.inflight.Add(-1)
+
+		if err == nil || !IsConnError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		log.Printf("sandbox backend %s failed, trying next: %v", b.URL, err)
+		b.markUnhealthy(err)
+	}
+
+	return err
+}
+
+// IsConnError reports whether err means that no connection to the backend
+// could be established, so the request certainly wasn't processed.
+func IsConnError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func newSandbox(t *testing.T, templates ...string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, _ *http.Request) {
+		var list []api.ImageConfig
+		for _, tmpl := range templates {
+			list = append(list, api.ImageConfig{Template: tmpl})
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestPool_RoutesByTemplateAndFailsOver(t *testing.T) {
+	goOnly := newSandbox(t, "golang_1_24")
+	defer goOnly.Close()
+	both := newSandbox(t, "golang_1_24", "python_3")
+	defer both.Close()
+
+	pool := NewPool([]string{goOnly.URL, both.URL})
+	pool.Check(context.Background())
+
+	if got := pool.Candidates("python_3"); len(got) != 1 || got[0].URL != both.URL {
+		t.Fatalf("python_3 must be routed to %s only, got %v", both.URL, got)
+	}
+	if got := pool.Candidates("golang_1_24"); len(got) != 2 {
+		t.Fatalf("golang_1_24 must have 2 candidates, got %d", len(got))
+	}
+
+	// The first backend goes away, requests must fail over to the second one.
+	goOnly.Close()
+
+	for i := 0; i < 2; i++ {
+		var used []string
+		err := pool.Do(context.Background(), "golang_1_24", func(b *Backend) error {
+			used = append(used, b.URL)
+
+			resp, err := http.Get(b.URL + "/health") //nolint:noctx
+			if err != nil {
+				return err
+			}
+			return resp.Body.Close()
+		})
+		if err != nil {
+			t.Fatalf("do: %v", err)
+		}
+		if used[len(used)-1] != both.URL {
+			t.Fatalf("expected the request to end on %s, used %v", both.URL, used)
+		}
+	}
+
+	if pool.Backends()[0].Healthy() {
+		t.Errorf("unreachable backend must be marked unhealthy")
+	}
+}
@@ -0,0 +1,128 @@
+// Package cache keeps the results of deterministic submissions, so identical
+// submissions don't start a container each time.
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// Cache is an LRU cache of submission responses whose entries expire after a TTL.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	ll      *list.List
+	entries map[string]*list.Element
+
+	now func() time.Time
+}
+
+type entry struct {
+	key     string
+	res     api.SubmissionResponse
+	expires time.Time
+}
+
+// New returns a cache holding at most size responses for ttl each.
+func New(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		size:    size,
+		ttl:     ttl,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Key identifies a submission by everything which affects its output. The
+// template version is included, so a rebuilt template doesn't serve stale results.
+func Key(template api.ImageConfig, action string, req api.SubmissionRequest) string {
+	// Maps are encoded with sorted keys, so the encoding is deterministic.
+	b, _ := json.Marshal(struct {
+		Template        string
+		Version         string
+		Action          string
+		Files           map[string]string
+		FilesMeta       *map[string]api.FileMeta
+		Args            string
+		Stdin           string
+		ExternalOptions *map[string]string
+	}{
+		Template:        template.Template,
+		Version:         template.Version,
+		Action:          action,
+		Files:           req.Files,
+		FilesMeta:       req.FilesMeta,
+		Args:            req.Args,
+		Stdin:           req.Stdin,
+		ExternalOptions: req.ExternalOptions,
+	})
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) Get(key string) (api.SubmissionResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return api.SubmissionResponse{}, false
+	}
+
+	e, _ := el.Value.(*entry)
+	if c.now().After(e.expires) {
+		c.remove(el)
+		return api.SubmissionResponse{}, false
+	}
+
+	c.ll.MoveToFront(el)
+	return e.res, true
+}
+
+// Add stores the response, evicting the least recently used one if the cache is full.
+func (c *Cache) Add(key string, res api.SubmissionResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Annotations belong to the request which ran the program.
+	res.Annotations = nil
+
+	if el, ok := c.entries[key]; ok {
+		e, _ := el.Value.(*entry)
+		e.res = res
+		e.expires = c.now().Add(c.ttl)
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.ll.PushFront(&entry{
+		key:     key,
+		res:     res,
+		expires: c.now().Add(c.ttl),
+	})
+
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e, _ := c.ll.Remove(el).(*entry)
+	delete(c.entries, e.key)
+}
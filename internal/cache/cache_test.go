@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func response(out string) api.SubmissionResponse {
+	return api.SubmissionResponse{Events: []api.SubmissionResponseEvents{{Kind: "stdout", Message: out}}}
+}
+
+func TestCacheLRU(t *testing.T) {
+	c := New(2, time.Minute)
+
+	c.Add("a", response("a"))
+	c.Add("b", response("b"))
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a missing")
+	}
+
+	// b is the least recently used now.
+	c.Add("c", response("c"))
+	if _, ok := c.Get("b"); ok {
+		t.Error("b not evicted")
+	}
+	if res, ok := c.Get("a"); !ok || res.Events[0].Message != "a" {
+		t.Errorf("a = %+v, %v", res, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("len = %d, want 2", c.Len())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Now()
+	c := New(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", response("a"))
+	now = now.Add(2 * time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired entry returned")
+	}
+	if c.Len() != 0 {
+		t.Errorf("len = %d, want 0", c.Len())
+	}
+}
+
+func TestKey(t *testing.T) {
+	template := api.ImageConfig{Template: "golang", Version: "1.0"}
+	req := api.SubmissionRequest{TemplateId: "golang", Files: map[string]string{"main.go": "package main", "go.mod": "module play"}}
+
+	key := Key(template, "default", req)
+	if key != Key(template, "default", req) {
+		t.Error("key isn't deterministic")
+	}
+
+	req.Stdin = "1"
+	if key == Key(template, "default", req) {
+		t.Error("stdin doesn't change the key")
+	}
+
+	req.Stdin = ""
+	template.Version = "1.1"
+	if key == Key(template, "default", req) {
+		t.Error("template version doesn't change the key")
+	}
+}
@@ -0,0 +1,70 @@
+// Package diagnostics parses the output of compilers into diagnostics which
+// an editor can show at their position in the source files.
+package diagnostics
+
+import (
+	"strings"
+	"sync"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// Parser turns the output of a compiler into diagnostics. Lines which aren't
+// diagnostics, like source excerpts and summaries, are skipped.
+type Parser interface {
+	Parse(output string) []api.Diagnostic
+}
+
+// ParserFunc adapts a function to the Parser interface.
+type ParserFunc func(output string) []api.Diagnostic
+
+func (f ParserFunc) Parse(output string) []api.Diagnostic {
+	return f(output)
+}
+
+var (
+	mu      sync.RWMutex
+	parsers = map[string]Parser{}
+)
+
+// Register sets the parser of the compiler output of the templates of a
+// group, replacing the one registered before.
+func Register(group string, p Parser) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	parsers[group] = p
+}
+
+// Parse parses the compiler output with the parser of the first of the
+// template groups which has one. The file paths are made relative to the
+// workdir. It returns nil if no group has a parser.
+func Parse(groups []string, workdir, output string) []api.Diagnostic {
+	mu.RLock()
+	var p Parser
+	for _, group := range groups {
+		if p = parsers[group]; p != nil {
+			break
+		}
+	}
+	mu.RUnlock()
+
+	if p == nil {
+		return nil
+	}
+
+	diags := p.Parse(output)
+	for i := range diags {
+		diags[i].File = relativePath(workdir, diags[i].File)
+	}
+
+	return diags
+}
+
+func relativePath(workdir, file string) string {
+	if workdir != "" {
+		file = strings.TrimPrefix(file, strings.TrimSuffix(workdir, "/")+"/")
+	}
+
+	return strings.TrimPrefix(file, "./")
+}
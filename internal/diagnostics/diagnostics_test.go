@@ -0,0 +1,95 @@
+package diagnostics
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name    string
+		groups  []string
+		workdir string
+		output  string
+		want    []api.Diagnostic
+	}{
+		{
+			name:   "go",
+			groups: []string{"go"},
+			output: "# play\n./main.go:5:2: undefined: x\n./main.go:7:1: missing return\n",
+			want: []api.Diagnostic{
+				{File: "main.go", Line: 5, Column: 2, Severity: api.Error, Message: "undefined: x"},
+				{File: "main.go", Line: 7, Column: 1, Severity: api.Error, Message: "missing return"},
+			},
+		},
+		{
+			name:    "gcc",
+			groups:  []string{"cpp"},
+			workdir: "/app",
+			output: "/app/main.cpp: In function 'int main()':\n" +
+				"/app/main.cpp:4:15: warning: unused variable 'y' [-Wunused-variable]\n" +
+				"/app/main.cpp:5:5: error: 'x' was not declared in this scope\n" +
+				"    5 |     x = 1;\n      |     ^\n",
+			want: []api.Diagnostic{
+				{File: "main.cpp", Line: 4, Column: 15, Severity: api.Warning, Message: "unused variable 'y' [-Wunused-variable]"},
+				{File: "main.cpp", Line: 5, Column: 5, Severity: api.Error, Message: "'x' was not declared in this scope"},
+			},
+		},
+		{
+			name:   "javac",
+			groups: []string{"java"},
+			output: "Main.java:3: error: ';' expected\n        int x = 1\n                 ^\n1 error\n",
+			want: []api.Diagnostic{
+				{File: "Main.java", Line: 3, Column: 18, Severity: api.Error, Message: "';' expected"},
+			},
+		},
+		{
+			name:   "rustc",
+			groups: []string{"rust"},
+			output: "error[E0425]: cannot find value `x` in this scope\n --> src/main.rs:2:20\n  |\n" +
+				"warning: unused variable: `y`\n --> src/main.rs:3:9\n" +
+				"error: aborting due to 1 previous error\n",
+			want: []api.Diagnostic{
+				{File: "src/main.rs", Line: 2, Column: 20, Severity: api.Error, Message: "E0425: cannot find value `x` in this scope"},
+				{File: "src/main.rs", Line: 3, Column: 9, Severity: api.Warning, Message: "unused variable: `y`"},
+			},
+		},
+		{
+			name:   "tsc",
+			groups: []string{"typescript"},
+			output: "src/index.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.\n" +
+				"\x1b[96msrc/index.ts\x1b[0m:\x1b[93m4\x1b[0m:\x1b[93m1\x1b[0m - \x1b[91merror\x1b[0m TS2304: Cannot find name 'foo'.\n",
+			want: []api.Diagnostic{
+				{File: "src/index.ts", Line: 3, Column: 7, Severity: api.Error, Message: "TS2322: Type 'string' is not assignable to type 'number'."},
+				{File: "src/index.ts", Line: 4, Column: 1, Severity: api.Error, Message: "TS2304: Cannot find name 'foo'."},
+			},
+		},
+		{
+			name:   "no parser",
+			groups: []string{"bash"},
+			output: "main.sh: line 1: syntax error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Parse(c.groups, c.workdir, c.output)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %+v\nwant %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test", ParserFunc(func(string) []api.Diagnostic {
+		return []api.Diagnostic{{File: "./a.txt", Line: 1, Severity: api.Info, Message: "custom"}}
+	}))
+
+	got := Parse([]string{"unknown", "test"}, "", "")
+	if len(got) != 1 || got[0].File != "a.txt" || got[0].Message != "custom" {
+		t.Errorf("unexpected diagnostics %+v", got)
+	}
+}
@@ -0,0 +1,152 @@
+package diagnostics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func init() {
+	gcc := ParserFunc(parseGCC)
+	for _, group := range []string{"go", "c", "cpp", "zig", "kotlin"} {
+		Register(group, gcc)
+	}
+
+	Register("java", ParserFunc(parseJavac))
+	Register("rust", ParserFunc(parseRustc))
+	Register("typescript", ParserFunc(parseTsc))
+}
+
+var (
+	// file:line:col: [severity: ]message, printed by gcc, clang, go, zig and kotlinc.
+	gccRe = regexp.MustCompile(`^([^\s:][^:]*):(\d+):(\d+): (?:(fatal error|error|warning|note|info): )?(.+)$`)
+
+	// File.java:line: severity: message, followed by the source line and a caret.
+	javacRe = regexp.MustCompile(`^(.+\.java):(\d+): (error|warning): (.+)$`)
+
+	// severity[code]: message, followed by " --> file:line:col".
+	rustcRe         = regexp.MustCompile(`^(error|warning)(\[\w+\])?: (.+)$`)
+	rustcLocationRe = regexp.MustCompile(`^\s*--> (.+):(\d+):(\d+)$`)
+
+	// file(line,col): severity TSxxxx: message, or file:line:col - severity ... with --pretty.
+	tscRe       = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning) (.+)$`)
+	tscPrettyRe = regexp.MustCompile(`^(.+?):(\d+):(\d+) - (error|warning) (.+)$`)
+
+	ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+func parseGCC(output string) []api.Diagnostic {
+	var diags []api.Diagnostic
+	for _, line := range lines(output) {
+		m := gccRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		diags = append(diags, diagnostic(m[1], m[2], m[3], m[4], m[5]))
+	}
+
+	return diags
+}
+
+func parseJavac(output string) []api.Diagnostic {
+	var diags []api.Diagnostic
+
+	ls := lines(output)
+	for i, line := range ls {
+		m := javacRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		// The column is the position of the caret under the source line.
+		column := ""
+		if i+2 < len(ls) {
+			if caret := strings.TrimRight(ls[i+2], " "); strings.TrimSpace(caret) == "^" {
+				column = strconv.Itoa(len(caret))
+			}
+		}
+
+		diags = append(diags, diagnostic(m[1], m[2], column, m[3], m[4]))
+	}
+
+	return diags
+}
+
+func parseRustc(output string) []api.Diagnostic {
+	var diags []api.Diagnostic
+
+	ls := lines(output)
+	for i, line := range ls {
+		m := rustcRe.FindStringSubmatch(line)
+		if m == nil || i+1 >= len(ls) {
+			continue
+		}
+
+		// Summaries like "error: aborting due to 2 previous errors" have no location.
+		loc := rustcLocationRe.FindStringSubmatch(ls[i+1])
+		if loc == nil {
+			continue
+		}
+
+		message := m[3]
+		if m[2] != "" {
+			message = strings.Trim(m[2], "[]") + ": " + message
+		}
+
+		diags = append(diags, diagnostic(loc[1], loc[2], loc[3], m[1], message))
+	}
+
+	return diags
+}
+
+func parseTsc(output string) []api.Diagnostic {
+	var diags []api.Diagnostic
+	for _, line := range lines(output) {
+		m := tscRe.FindStringSubmatch(line)
+		if m == nil {
+			m = tscPrettyRe.FindStringSubmatch(line)
+		}
+		if m == nil {
+			continue
+		}
+
+		diags = append(diags, diagnostic(m[1], m[2], m[3], m[4], m[5]))
+	}
+
+	return diags
+}
+
+func diagnostic(file, line, column, severity, message string) api.Diagnostic {
+	l, _ := strconv.Atoi(line)
+	c, _ := strconv.Atoi(column)
+
+	return api.Diagnostic{
+		File:     file,
+		Line:     l,
+		Column:   c,
+		Severity: parseSeverity(severity),
+		Message:  strings.TrimSpace(message),
+	}
+}
+
+// parseSeverity maps the severities of the compilers to the API ones. Go
+// prints no severity, its diagnostics are errors.
+func parseSeverity(s string) api.DiagnosticSeverity {
+	switch s {
+	case "warning":
+		return api.Warning
+	case "note", "info":
+		return api.Info
+	default:
+		return api.Error
+	}
+}
+
+// lines splits the output into lines without the terminal colors some
+// compilers print.
+func lines(output string) []string {
+	return strings.Split(ansiRe.ReplaceAllString(strings.ReplaceAll(output, "\r\n", "\n"), ""), "\n")
+}
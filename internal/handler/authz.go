@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// Claims restricting what the token holder may run.
+const (
+	// claimTemplates lists the allowed templates as "<template>" for all of
+	// its actions or "<template>/<action>" for a single action.
+	claimTemplates = "templates"
+	// claimGroups lists the template groups the holder may use.
+	claimGroups = "groups"
+	// claimAllowExternalCommands must be true to override the compile and run
+	// commands with ExternalOptions.
+	claimAllowExternalCommands = "allow_external_commands"
+)
+
+// permissions of the caller. A nil set doesn't restrict, so missing template
+// and group claims allow everything, but external commands must be allowed
+// explicitly once JWT authentication is enabled.
+type permissions struct {
+	templates        map[string]bool
+	groups           map[string]bool
+	externalCommands bool
+}
+
+func (h *Handler) permissions(r *http.Request) permissions {
+	if h.Config.JWTSecretKey == "" {
+		return permissions{externalCommands: true}
+	}
+
+	claims := verifiedClaims(r.Context())
+
+	return permissions{
+		templates:        claimSet(claims[claimTemplates]),
+		groups:           claimSet(claims[claimGroups]),
+		externalCommands: claimBool(claims[claimAllowExternalCommands]),
+	}
+}
+
+func (p permissions) unrestricted() bool {
+	return p.templates == nil && p.groups == nil && p.externalCommands
+}
+
+// allowAction reports whether the action may be run. The action is named by its
+// key in the template actions, the default action also by the ID it was copied from.
+func (p permissions) allowAction(template api.ImageConfig, name string, action api.ImageActionConfig) bool {
+	if p.groups != nil && !containsAny(p.groups, template.Groups) {
+		return false
+	}
+
+	if p.templates == nil {
+		return true
+	}
+
+	return p.templates[template.Template] ||
+		p.templates[template.Template+"/"+name] ||
+		p.templates[template.Template+"/"+action.Id]
+}
+
+// allowExternalOptions reports whether the options may override the commands of the action.
+func (p permissions) allowExternalOptions(action api.ImageActionConfig, options *map[string]string) bool {
+	if p.externalCommands || options == nil ||
+		action.EnableExternalCommands == api.ImageActionConfigEnableExternalCommandsNone {
+		return true
+	}
+
+	for _, v := range *options {
+		if v != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// key identifies the permissions for caching the filtered action list.
+func (p permissions) key() string {
+	hash := sha256.New()
+	for _, set := range []map[string]bool{p.templates, p.groups} {
+		if set == nil {
+			_, _ = fmt.Fprint(hash, "*;")
+			continue
+		}
+
+		names := make([]string, 0, len(set))
+		for name := range set {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		_, _ = fmt.Fprint(hash, strings.Join(names, ","), ";")
+	}
+	_, _ = fmt.Fprint(hash, p.externalCommands)
+
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// authorizeAction writes 403 and returns false if the caller may not run the action as requested.
+func (h *Handler) authorizeAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	template api.ImageConfig,
+	name string,
+	action api.ImageActionConfig,
+	options *map[string]string,
+) bool {
+	perm := h.permissions(r)
+
+	if !perm.allowAction(template, name, action) {
+		writeProblem(w, http.StatusForbidden, api.Forbidden, fmt.Sprintf("action `%s` of template `%s` is not allowed", name, template.Template))
+		return false
+	}
+
+	if !perm.allowExternalOptions(action, options) {
+		writeProblem(w, http.StatusForbidden, api.Forbidden, "external commands are not allowed")
+		return false
+	}
+
+	return true
+}
+
+// claimSet reads a list claim, given as JSON array or comma separated string.
+func claimSet(v interface{}) map[string]bool {
+	var items []string
+
+	switch l := v.(type) {
+	case []interface{}:
+		for _, item := range l {
+			items = append(items, fmt.Sprint(item))
+		}
+	case []string:
+		items = l
+	case string:
+		items = strings.Split(l, ",")
+	default:
+		return nil
+	}
+
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = true
+		}
+	}
+
+	return set
+}
+
+func claimBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+
+	return false
+}
+
+func containsAny(set map[string]bool, items []string) bool {
+	for _, item := range items {
+		if set[item] {
+			return true
+		}
+	}
+
+	return false
+}
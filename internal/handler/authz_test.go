@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestPermissionsAllowAction(t *testing.T) {
+	golang := api.ImageConfig{Template: "golang", Groups: []string{"go"}}
+	python := api.ImageConfig{Template: "python", Groups: []string{"python"}}
+	run := api.ImageActionConfig{Id: "run"}
+	tests := api.ImageActionConfig{Id: "tests"}
+
+	cases := []struct {
+		name     string
+		perm     permissions
+		template api.ImageConfig
+		action   string
+		config   api.ImageActionConfig
+		want     bool
+	}{
+		{"unrestricted", permissions{}, python, "run", run, true},
+		{"template", permissions{templates: claimSet("golang")}, golang, "tests", tests, true},
+		{"other template", permissions{templates: claimSet("golang")}, python, "run", run, false},
+		{"action", permissions{templates: claimSet("golang/run")}, golang, "run", run, true},
+		{"other action", permissions{templates: claimSet("golang/run")}, golang, "tests", tests, false},
+		{"default copy", permissions{templates: claimSet("golang/run")}, golang, defaultAction, run, true},
+		{"group", permissions{groups: claimSet([]interface{}{"go"})}, golang, "run", run, true},
+		{"other group", permissions{groups: claimSet([]interface{}{"go"})}, python, "run", run, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.perm.allowAction(c.template, c.action, c.config); got != c.want {
+				t.Errorf("allowAction = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPermissionsAllowExternalOptions(t *testing.T) {
+	all := api.ImageActionConfig{EnableExternalCommands: api.ImageActionConfigEnableExternalCommandsAll}
+	options := &map[string]string{"RunCmd": "rm -rf /"}
+
+	if (permissions{}).allowExternalOptions(all, options) {
+		t.Error("external options allowed without the claim")
+	}
+	if !(permissions{}).allowExternalOptions(all, &map[string]string{"RunCmd": ""}) {
+		t.Error("empty external options rejected")
+	}
+	if !(permissions{externalCommands: true}).allowExternalOptions(all, options) {
+		t.Error("external options rejected with the claim")
+	}
+}
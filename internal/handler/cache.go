@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/cache"
+)
+
+// cacheHeader reports whether the response was served from the result cache.
+const cacheHeader = "X-Codenire-Cache"
+
+// dispatchCached serves the result of a submission to a cacheable template
+// from the cache, or runs it and caches the result.
+func (h *Handler) dispatchCached(ctx context.Context, w http.ResponseWriter, req api.SubmissionRequest) (*api.SubmissionResponse, error) {
+	return h.runCached(ctx, w.Header(), req, h.dispatch)
+}
+
+// runCached is dispatchCached with the run of the submission done by run.
+// The cache header is set in header unless it's nil.
+func (h *Handler) runCached(
+	ctx context.Context,
+	header http.Header,
+	req api.SubmissionRequest,
+	run func(ctx context.Context, req api.SubmissionRequest) (*api.SubmissionResponse, error),
+) (*api.SubmissionResponse, error) {
+	template := h.Templates.Get(req.TemplateId)
+	if h.Cache == nil || template == nil || !template.Cacheable {
+		return run(ctx, req)
+	}
+
+	setHeader := func(value string) {
+		if header != nil {
+			header.Set(cacheHeader, value)
+		}
+	}
+
+	key := cache.Key(*template, actionName(req.ActionId), req)
+	if res, ok := h.Cache.Get(key); ok {
+		setHeader("HIT")
+		return &res, nil
+	}
+
+	setHeader("MISS")
+
+	res, err := run(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheableStatus(res.Status) {
+		h.Cache.Add(key, *res)
+	}
+
+	return res, nil
+}
+
+// cacheableStatus reports whether a run with the status would end
+// the same way again. Timeouts and failures of the sandbox may not.
+func cacheableStatus(status api.ExecutionStatus) bool {
+	switch status {
+	case api.ExecutionStatusOk, api.ExecutionStatusCompileError, api.ExecutionStatusRuntimeError:
+		return true
+	case api.ExecutionStatusTimeout, api.ExecutionStatusOomKilled, api.ExecutionStatusInternalError:
+	}
+
+	return false
+}
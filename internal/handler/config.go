@@ -15,24 +15,50 @@ type CorsConfig struct {
 
 // DefaultCorsConfig is the configuration that will be used in none is provided.
 var DefaultCorsConfig = CorsConfig{
-	AllowOrigins:     []string{"*"},
-	AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-	AllowHeaders:     []string{"Authorization", "Origin", "Content-Type", "Content-Length"},
-	ExposeHeaders:    []string{"Content-Length", "Authorization", "Content-Type"},
+	AllowOrigins: []string{"*"},
+	AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+	AllowHeaders: []string{"Authorization", "Origin", "Content-Type", "Content-Length"},
+	ExposeHeaders: []string{
+		"Content-Length", "Authorization", "Content-Type", "Retry-After",
+		"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+		"X-Quota-Runs-Limit", "X-Quota-Runs-Remaining", "X-Quota-CPU-Limit", "X-Quota-CPU-Remaining", "X-Quota-Reset",
+		"X-Codenire-Cache",
+	},
 	AllowCredentials: true,
 	MaxAge:           300,
 }
 
 type Config struct {
-	BackendURL string
-	Port       string
+	BackendURLs              []string
+	HealthCheckInterval      time.Duration
+	TemplatesRefreshInterval time.Duration
+	Port                     string
+	AdminToken               string
 
 	FileHooksDir                     string
 	PluginHookPath                   string
 	GracefulRequestCompletionTimeout time.Duration
 	ShutdownTimeout                  time.Duration
 	ThrottleLimit                    int
+	JobsLimit                        int
+	JobTTL                           time.Duration
 	JWTSecretKey                     string
+	RateLimitClaim                   string
+	RateLimitRequests                int
+	RateLimitWindow                  time.Duration
+	QuotaRunsPerDay                  int
+	QuotaCPUSecondsPerDay            float64
+	QuotaFile                        string
+	SnippetStorage                   string
+	SnippetDir                       string
+	SnippetS3Endpoint                string
+	SnippetS3Region                  string
+	SnippetS3Bucket                  string
+	SnippetS3Prefix                  string
+	SnippetPostgresDSN               string
+	CacheSize                        int
+	CacheTTL                         time.Duration
+	ValidateRequests                 bool
 	Dev                              bool
 	Cors                             *CorsConfig
 }
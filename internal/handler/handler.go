@@ -2,6 +2,8 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,33 +11,137 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+	"github.com/codiewio/codenire/internal/cache"
+	"github.com/codiewio/codenire/internal/hooks"
+	"github.com/codiewio/codenire/internal/images"
+	"github.com/codiewio/codenire/internal/jobs"
+	"github.com/codiewio/codenire/internal/quota"
+	"github.com/codiewio/codenire/internal/snippets"
 )
 
 type Handler struct {
-	Config *Config
+	Config    *Config
+	Backends  *backend.Pool
+	Templates *images.Registry
+	Jobs      jobs.Store
+	Hooks     []hooks.HookHandler
+	Quotas    *quota.Tracker
+	Snippets  snippets.Store
+	Cache     *cache.Cache
+
+	jobsMu     sync.Mutex
+	jobCancels map[string]context.CancelFunc
+	jobSem     chan struct{}
+
+	terminals *terminalSessions
 }
 
-func copyFilesToTmpDir(tmpDir string, files map[string]string) error {
+// copyFilesToTmpDir writes the submission files into tmpDir. The content of
+// files marked as base64 in meta is decoded, and the file modes are set
+// exactly, regardless of the umask, so they survive the tar archive.
+func copyFilesToTmpDir(tmpDir string, files map[string]string, meta *map[string]api.FileMeta) error {
 	cleanTmpDir, err := filepath.Abs(tmpDir)
 	if err != nil {
 		return err
 	}
 
 	for relPath, content := range files {
-		targetPath := filepath.Join(cleanTmpDir, relPath)
+		cleanPath, err := cleanFilePath(relPath)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(cleanTmpDir, cleanPath)
 
-		rel, err := filepath.Rel(cleanTmpDir, targetPath)
-		if err != nil || strings.HasPrefix(rel, "..") {
-			return fmt.Errorf("invalid file path %q: path traversal detected", relPath)
+		data, mode, err := fileContent(relPath, content, meta)
+		if err != nil {
+			return err
 		}
 
 		if err = os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
 			return err
 		}
 
-		if err = os.WriteFile(targetPath, []byte(content), 0644); err != nil {
+		if err = os.WriteFile(targetPath, data, mode); err != nil {
 			return fmt.Errorf("error creating temp file %q: %w", targetPath, err)
 		}
+
+		if err = os.Chmod(targetPath, mode); err != nil {
+			return fmt.Errorf("error setting mode of %q: %w", targetPath, err)
+		}
+	}
+
+	return nil
+}
+
+// cleanFilePath returns the cleaned path of a submission file relative to
+// the directory of the submission. Paths leaving the directory are rejected.
+func cleanFilePath(name string) (string, error) {
+	sep := string(filepath.Separator)
+
+	clean := filepath.Clean(strings.TrimLeft(filepath.FromSlash(name), sep))
+	if clean == "." {
+		return "", fmt.Errorf("invalid file path %q", name)
+	}
+	if clean == ".." || strings.HasPrefix(clean, ".."+sep) {
+		return "", fmt.Errorf("invalid file path %q: path traversal detected", name)
+	}
+
+	return clean, nil
+}
+
+// fileContent returns the bytes and the mode of a submission file.
+func fileContent(name, content string, meta *map[string]api.FileMeta) ([]byte, os.FileMode, error) {
+	var m api.FileMeta
+	if meta != nil {
+		m = (*meta)[name]
+	}
+
+	mode := os.FileMode(0644)
+	if m.Mode != nil {
+		//nolint:gosec
+		mode = os.FileMode(*m.Mode) & os.ModePerm
+	}
+
+	if m.Encoding == nil || *m.Encoding == api.Text {
+		return []byte(content), mode, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid base64 content of %q: %w", name, err)
+	}
+
+	return data, mode, nil
+}
+
+// checkFiles enforces the limits of the number of files and of their size
+// and checks that the binary files are valid base64.
+func checkFiles(files map[string]string, meta *map[string]api.FileMeta) error {
+	if len(files) > MaxFiles {
+		return newProblem(http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("too many files (max %d)", MaxFiles))
+	}
+
+	for name, content := range files {
+		data, _, err := fileContent(name, content, meta)
+		if err != nil {
+			return newProblem(http.StatusBadRequest, api.InvalidRequest, err.Error())
+		}
+
+		if int64(len(data)) > MaxFileSize {
+			return newProblem(http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("file %q too large (max %d bytes)", name, MaxFileSize))
+		}
+	}
+
+	if meta != nil {
+		for name := range *meta {
+			if _, ok := files[name]; !ok {
+				return newProblem(http.StatusBadRequest, api.InvalidRequest, fmt.Sprintf("FilesMeta of unknown file %q", name))
+			}
+		}
 	}
 
 	return nil
@@ -47,7 +153,7 @@ func writeJSONResponse(w http.ResponseWriter, resp interface{}, status int) {
 
 	if err := json.NewEncoder(&buf).Encode(resp); err != nil {
 		log.Errorf("error encoding response: %v", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeProblem(w, http.StatusInternalServerError, api.InternalError, "")
 		return
 	}
 
@@ -2,26 +2,96 @@ package handler
 
 import (
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	api "github.com/codiewio/codenire/api/gen"
-	"github.com/codiewio/codenire/internal/images"
 )
 
 const defaultAction = "default"
 
-func (h *Handler) ActionListHandler(w http.ResponseWriter, _ *http.Request) {
-	list, err := images.PullImageConfigList(h.Config.BackendURL)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+const actionEventsKeepAlive = 30 * time.Second
+
+// ActionListHandler serves the actions of the cached templates which the caller
+// may use. The list is identified by the registry ETag and the caller permissions,
+// so clients can revalidate it with If-None-Match.
+func (h *Handler) ActionListHandler(w http.ResponseWriter, r *http.Request) {
+	perm := h.permissions(r)
+
+	etag := h.Templates.ETag()
+	if !perm.unrestricted() {
+		etag = strings.TrimSuffix(etag, `"`) + "-" + perm.key() + `"`
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Vary", "Authorization")
+
+	if noneMatch(r.Header.Values("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	var res api.ActionListResponse
-	for _, template := range *list {
+	writeJSONResponse(w, actionList(h.Templates.List(), perm), http.StatusOK)
+}
+
+// noneMatch reports whether the If-None-Match header values match the ETag,
+// so the list isn't modified (RFC 9110, section 13.1.2). The header is "*" or
+// a list of entity tags, which are compared weakly: a W/ prefix is ignored.
+func noneMatch(values []string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, v := range values {
+		for v = strings.TrimSpace(v); v != ""; v = strings.TrimLeft(v, " \t,") {
+			if v == "*" {
+				return true
+			}
+
+			v = strings.TrimPrefix(v, "W/")
+			if !strings.HasPrefix(v, `"`) {
+				// Not an entity tag, skip to the next list element.
+				_, v, _ = strings.Cut(v, ",")
+				continue
+			}
+
+			end := strings.IndexByte(v[1:], '"')
+			if end < 0 {
+				break
+			}
+			if v[:end+2] == etag {
+				return true
+			}
+			v = v[end+2:]
+		}
+	}
+
+	return false
+}
+
+func actionList(templates []api.ImageConfig, perm permissions) api.ActionListResponse {
+	res := api.ActionListResponse{}
+	for _, template := range templates {
 		var defaultCfg *api.ActionItemResponse
 		defaultWrote := false
 
-		for name, config := range template.Actions {
+		names := make([]string, 0, len(template.Actions))
+		for name := range template.Actions {
+			names = append(names, name)
+		}
+		// The default action goes first, so the action it was copied from is marked as default.
+		sort.Slice(names, func(i, j int) bool {
+			if names[i] == defaultAction || names[j] == defaultAction {
+				return names[i] == defaultAction
+			}
+			return names[i] < names[j]
+		})
+
+		for _, name := range names {
+			config := template.Actions[name]
+			if !perm.allowAction(template, name, config) {
+				continue
+			}
+
 			isDefault := name == defaultAction || config.IsDefault
 
 			action := api.ActionItemResponse{
@@ -33,15 +103,23 @@ func (h *Handler) ActionListHandler(w http.ResponseWriter, _ *http.Request) {
 				Workdir:          template.Workdir,
 				Groups:           template.Groups,
 				Provider:         template.Provider,
+				Cacheable:        template.Cacheable,
 
 				CompileCmd:             config.CompileCmd,
 				DefaultFiles:           config.DefaultFiles,
 				IsDefault:              isDefault,
 				RunCmd:                 config.RunCmd,
 				ScriptOptions:          config.ScriptOptions,
+				Artifacts:              config.Artifacts,
+				FormatCmd:              config.FormatCmd,
+				Steps:                  config.Steps,
 				EnableExternalCommands: api.ActionItemResponseEnableExternalCommands(config.EnableExternalCommands),
 			}
 
+			if !perm.externalCommands {
+				action.EnableExternalCommands = api.ActionItemResponseEnableExternalCommandsNone
+			}
+
 			if isDefault {
 				defaultCfg = &action
 				defaultCfg.IsDefault = true
@@ -61,5 +139,58 @@ func (h *Handler) ActionListHandler(w http.ResponseWriter, _ *http.Request) {
 		}
 	}
 
-	writeJSONResponse(w, res, http.StatusOK)
+	return res
+}
+
+// ActionEventsHandler streams template changes as server-sent events.
+func (h *Handler) ActionEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, http.StatusInternalServerError, api.InternalError, "streaming unsupported")
+		return
+	}
+
+	changes, unsubscribe := h.Templates.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(actionEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		case c := <-changes:
+			if err := writeSSE(w, flusher, string(c.Kind), c); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// RefreshTemplatesHandler reloads the templates from the sandbox backends
+// and returns the found changes.
+func (h *Handler) RefreshTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	changes, err := h.Templates.Refresh(r.Context())
+	if err != nil {
+		writeProblem(w, http.StatusBadGateway, api.TemplatesRefreshFailed, "refresh templates failed: "+err.Error())
+		return
+	}
+
+	if changes == nil {
+		changes = []api.TemplateChange{}
+	}
+
+	writeJSONResponse(w, changes, http.StatusOK)
 }
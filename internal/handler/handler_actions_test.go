@@ -0,0 +1,37 @@
+package handler
+
+import "testing"
+
+func TestNoneMatch(t *testing.T) {
+	const etag = `"3f2a9c"`
+
+	cases := []struct {
+		name   string
+		values []string
+		want   bool
+	}{
+		{"none", nil, false},
+		{"exact", []string{`"3f2a9c"`}, true},
+		{"other", []string{`"1b7d04"`}, false},
+		{"any", []string{"*"}, true},
+		{"weak", []string{`W/"3f2a9c"`}, true},
+		{"list", []string{`"1b7d04", W/"3f2a9c"`}, true},
+		{"list without match", []string{`"1b7d04" , "77aa01"`}, false},
+		{"several headers", []string{`"1b7d04"`, `"3f2a9c"`}, true},
+		{"comma in tag", []string{`"1b,7d", "3f2a9c"`}, true},
+		{"unquoted", []string{`3f2a9c`}, false},
+		{"unterminated", []string{`"3f2a9c`}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := noneMatch(c.values, etag); got != c.want {
+				t.Errorf("noneMatch(%q, %s) = %v, want %v", c.values, etag, got, c.want)
+			}
+		})
+	}
+
+	if !noneMatch([]string{`"3f2a9c"`}, `W/"3f2a9c"`) {
+		t.Error("weak ETag doesn't match its strong tag")
+	}
+}
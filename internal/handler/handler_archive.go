@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/hooks"
+)
+
+// archiveFormOverhead is the room left in the request body for the form
+// fields next to the archive.
+const archiveFormOverhead = 64 * 1024
+
+var (
+	zipMagic  = []byte("PK")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// RunArchiveHandler runs a project uploaded as .zip or .tar.gz archive. The
+// files of the archive become the files of a regular submission.
+func (h *Handler) RunArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, api.MethodNotAllowed, "")
+		return
+	}
+
+	req, ok := h.decodeArchiveRequest(w, r)
+	if !ok {
+		return
+	}
+
+	annotations, ok := h.preRun(w, r, req)
+	if !ok {
+		return
+	}
+
+	apiRes, err := h.dispatchCached(r.Context(), w, *req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.postRun(r.Context(), hooks.NewHTTPRequest(r), *req, apiRes, annotations)
+
+	writeJSONResponse(w, apiRes, http.StatusOK)
+}
+
+// decodeArchiveRequest reads the multipart form of an archive submission and
+// extracts the archive into a files submission. On failure the error is
+// written to w and false is returned.
+func (h *Handler) decodeArchiveRequest(w http.ResponseWriter, r *http.Request) (*api.SubmissionRequest, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxArchiveSize+archiveFormOverhead)
+
+	if err := r.ParseMultipartForm(MaxArchiveSize); err != nil {
+		maxBytesErr := new(http.MaxBytesError)
+		if errors.As(err, &maxBytesErr) {
+			writeProblem(w, http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("archive too large (max %d bytes)", MaxArchiveSize))
+			return nil, false
+		}
+
+		writeProblem(w, http.StatusBadRequest, api.InvalidRequest, "invalid request: "+err.Error())
+		return nil, false
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	file, header, err := r.FormFile("Archive")
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, api.InvalidRequest, "invalid request: Archive: "+err.Error())
+		return nil, false
+	}
+	defer file.Close()
+
+	files, meta, err := extractArchive(file, header.Size)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+
+	req := api.SubmissionRequest{
+		TemplateId: r.FormValue("TemplateId"),
+		Args:       r.FormValue("Args"),
+		Stdin:      r.FormValue("Stdin"),
+		Files:      files,
+		FilesMeta:  meta,
+	}
+	if action := r.FormValue("ActionId"); action != "" {
+		req.ActionId = &action
+	}
+
+	if !h.completeSubmissionRequest(w, r, &req) {
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// extractArchive reads the files of a zip or gzipped tar archive. Files which
+// aren't valid UTF-8 are returned base64 encoded, executable files keep their
+// mode. Directories, links and other special files are skipped.
+func extractArchive(ra io.ReaderAt, size int64) (map[string]string, *map[string]api.FileMeta, error) {
+	magic := make([]byte, 2)
+	if _, err := ra.ReadAt(magic, 0); err != nil {
+		return nil, nil, newProblem(http.StatusBadRequest, api.InvalidRequest, "archive is empty")
+	}
+
+	x := archiveExtractor{
+		files: make(map[string]string),
+		meta:  make(map[string]api.FileMeta),
+	}
+
+	var err error
+	switch {
+	case bytes.Equal(magic, zipMagic):
+		err = x.extractZip(ra, size)
+	case bytes.Equal(magic, gzipMagic):
+		err = x.extractTarGz(io.NewSectionReader(ra, 0, size))
+	default:
+		return nil, nil, newProblem(http.StatusBadRequest, api.InvalidRequest, "archive must be a .zip or .tar.gz file")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(x.meta) == 0 {
+		return x.files, nil, nil
+	}
+
+	return x.files, &x.meta, nil
+}
+
+type archiveExtractor struct {
+	files map[string]string
+	meta  map[string]api.FileMeta
+	size  int64
+}
+
+func (x *archiveExtractor) extractZip(ra io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, api.InvalidRequest, "invalid zip archive: "+err.Error())
+	}
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return newProblem(http.StatusBadRequest, api.InvalidRequest, fmt.Sprintf("invalid zip entry %q: %s", f.Name, err))
+		}
+
+		err = x.add(f.Name, f.Mode(), rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *archiveExtractor) extractTarGz(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, api.InvalidRequest, "invalid gzip archive: "+err.Error())
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return newProblem(http.StatusBadRequest, api.InvalidRequest, "invalid tar archive: "+err.Error())
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err = x.add(hdr.Name, hdr.FileInfo().Mode(), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// add reads a file of the archive. The limits are checked while reading so
+// that a compressed archive can't expand beyond them.
+func (x *archiveExtractor) add(name string, mode os.FileMode, r io.Reader) error {
+	cleanPath, err := cleanFilePath(name)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, api.InvalidRequest, err.Error())
+	}
+	cleanPath = filepath.ToSlash(cleanPath)
+
+	if len(x.files) >= MaxFiles {
+		return newProblem(http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("too many files (max %d)", MaxFiles))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxArchiveContentSize-x.size+1))
+	if err != nil {
+		return newProblem(http.StatusBadRequest, api.InvalidRequest, fmt.Sprintf("read archive entry %q: %s", name, err))
+	}
+
+	x.size += int64(len(data))
+	if x.size > MaxArchiveContentSize {
+		return newProblem(http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("archive content too large (max %d bytes)", MaxArchiveContentSize))
+	}
+
+	var m api.FileMeta
+	if utf8.Valid(data) {
+		x.files[cleanPath] = string(data)
+	} else {
+		encoding := api.Base64
+		m.Encoding = &encoding
+		x.files[cleanPath] = base64.StdEncoding.EncodeToString(data)
+	}
+
+	if mode&0o111 != 0 {
+		perm := int(mode.Perm())
+		m.Mode = &perm
+	}
+
+	if m.Encoding != nil || m.Mode != nil {
+		x.meta[cleanPath] = m
+	}
+
+	return nil
+}
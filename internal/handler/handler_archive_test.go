@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+type archiveEntry struct {
+	name    string
+	mode    int64
+	content string
+}
+
+func zipArchive(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		hdr.SetMode(0644)
+		if e.mode != 0 {
+			hdr.SetMode(0755)
+		}
+
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func tarGzArchive(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		mode := e.mode
+		if mode == 0 {
+			mode = 0644
+		}
+
+		hdr := &tar.Header{Name: e.name, Mode: mode, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestExtractArchive(t *testing.T) {
+	entries := []archiveEntry{
+		{name: "go.mod", content: "module example\n"},
+		{name: "./cmd/app/main.go", content: "package main\n"},
+		{name: "run.sh", mode: 0755, content: "#!/bin/sh\n"},
+		{name: "data.bin", content: "\xff\xfe\x00"},
+	}
+
+	archives := map[string][]byte{
+		"zip":    zipArchive(t, entries),
+		"tar.gz": tarGzArchive(t, entries),
+	}
+
+	for name, data := range archives {
+		t.Run(name, func(t *testing.T) {
+			files, meta, err := extractArchive(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if files["go.mod"] != "module example\n" || files["cmd/app/main.go"] != "package main\n" {
+				t.Errorf("unexpected files: %v", files)
+			}
+			if files["data.bin"] != "//4A" {
+				t.Errorf("data.bin = %q, want base64", files["data.bin"])
+			}
+
+			if meta == nil {
+				t.Fatal("missing files meta")
+			}
+			if m := (*meta)["data.bin"]; m.Encoding == nil || *m.Encoding != api.Base64 {
+				t.Errorf("data.bin not marked as base64: %+v", m)
+			}
+			if m := (*meta)["run.sh"]; m.Mode == nil || *m.Mode != 0755 {
+				t.Errorf("run.sh mode not kept: %+v", m)
+			}
+			if _, ok := (*meta)["go.mod"]; ok {
+				t.Error("unexpected meta of a text file")
+			}
+
+			// The extracted submission passes the checks of a regular one.
+			if err = checkFiles(files, meta); err != nil {
+				t.Errorf("checkFiles: %v", err)
+			}
+		})
+	}
+}
+
+func TestExtractArchive_Rejected(t *testing.T) {
+	oldMaxFiles, oldMaxContent := MaxFiles, MaxArchiveContentSize
+	defer func() {
+		MaxFiles, MaxArchiveContentSize = oldMaxFiles, oldMaxContent
+	}()
+	MaxFiles, MaxArchiveContentSize = 2, 16
+
+	cases := []struct {
+		name     string
+		data     []byte
+		wantCode api.ProblemCode
+	}{
+		{"path traversal zip", zipArchive(t, []archiveEntry{{name: "../escape.txt"}}), api.InvalidRequest},
+		{"path traversal tar", tarGzArchive(t, []archiveEntry{{name: "a/../../escape.txt"}}), api.InvalidRequest},
+		{"too many files", zipArchive(t, []archiveEntry{{name: "a"}, {name: "b"}, {name: "c"}}), api.RequestTooLarge},
+		{"content too large", tarGzArchive(t, []archiveEntry{{name: "a", content: "0123456789"}, {name: "b", content: "0123456789"}}), api.RequestTooLarge},
+		{"unknown format", []byte("plain text"), api.InvalidRequest},
+		{"broken zip", []byte("PK broken"), api.InvalidRequest},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := extractArchive(bytes.NewReader(c.data), int64(len(c.data)))
+
+			var p *problemError
+			if !errors.As(err, &p) {
+				t.Fatalf("expected problem, got %v", err)
+			}
+			if p.problem.Code != c.wantCode {
+				t.Errorf("code = %s, want %s", p.problem.Code, c.wantCode)
+			}
+		})
+	}
+}
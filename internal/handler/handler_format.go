@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+	"github.com/codiewio/codenire/internal/client"
+)
+
+// FormatHandler formats the files of a submission with the format command of
+// the requested action, like the /fmt endpoint of the Go playground.
+func (h *Handler) FormatHandler(w http.ResponseWriter, r *http.Request) {
+	var freq api.FormatRequest
+	if !decodeFilesRequest(w, r, &freq) {
+		return
+	}
+
+	if err := checkFiles(freq.Files, freq.FilesMeta); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	cfg := h.Templates.Get(freq.TemplateId)
+	if cfg == nil {
+		writeProblem(w, http.StatusBadRequest, api.TemplateNotFound, fmt.Sprintf("template `%s` not found", freq.TemplateId))
+		return
+	}
+
+	action, err := getAction(freq.ActionId, cfg)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, api.ActionNotFound, err.Error())
+		return
+	}
+
+	if action.FormatCmd == nil || *action.FormatCmd == "" {
+		writeProblem(w, http.StatusBadRequest, api.FormatNotSupported, fmt.Sprintf("action `%s` of template `%s` has no format command", actionName(freq.ActionId), freq.TemplateId))
+		return
+	}
+
+	if !h.authorizeAction(w, r, *cfg, actionName(freq.ActionId), *action, nil) {
+		return
+	}
+
+	res, err := h.dispatchFormat(r.Context(), api.SubmissionRequest{
+		TemplateId: freq.TemplateId,
+		ActionId:   freq.ActionId,
+		Files:      freq.Files,
+		FilesMeta:  freq.FilesMeta,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, res, http.StatusOK)
+}
+
+// dispatchFormat formats the files on one of the sandbox backends serving the template.
+func (h *Handler) dispatchFormat(ctx context.Context, req api.SubmissionRequest) (*api.FormatResponse, error) {
+	body, err := sandboxRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *api.FormatResponse
+	err = h.Backends.Do(ctx, req.TemplateId, func(b *backend.Backend) (err error) {
+		res, err = formatCode(ctx, body, b.URL+"/fmt")
+		return err
+	})
+
+	return res, err
+}
+
+func formatCode(ctx context.Context, body []byte, backendURL string) (*api.FormatResponse, error) {
+	sreq, err := http.NewRequestWithContext(ctx, http.MethodPost, backendURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("request marshal error: %w", err)
+	}
+
+	resp, err := client.SandboxBackendClient().Do(sreq)
+	if err != nil {
+		sandboxErr := fmt.Errorf("sandbox client request error: %w", err)
+		log.Printf("got error from sandbox: %s", sandboxErr.Error())
+
+		return nil, sandboxErr
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, sandboxProblem(resp)
+	}
+
+	var res api.FormatResponse
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("jSON decode error from backend: %w", err)
+	}
+
+	if res.Error != nil {
+		output := string(sanitize([]byte(*res.Error)))
+		res.Error = &output
+	}
+
+	return &res, nil
+}
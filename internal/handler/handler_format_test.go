@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestFormatCode(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		body      string
+		wantFiles map[string]string
+		wantError string
+		wantCode  api.ProblemCode
+	}{
+		{
+			name:      "formatted",
+			status:    http.StatusOK,
+			body:      `{"Files":{"main.go":"package main\n"}}`,
+			wantFiles: map[string]string{"main.go": "package main\n"},
+		},
+		{
+			name:      "syntax error",
+			status:    http.StatusOK,
+			body:      `{"Files":{},"Error":"main.go:1:1: expected 'package'"}`,
+			wantFiles: map[string]string{},
+			wantError: "main.go:1:1: expected 'package'",
+		},
+		{
+			name:     "not supported",
+			status:   http.StatusBadRequest,
+			body:     `{"type":"urn:codenire:problem:format_not_supported","title":"Bad Request","status":400,"code":"format_not_supported"}`,
+			wantCode: api.FormatNotSupported,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if c.status == http.StatusOK {
+					w.Header().Set("Content-Type", "application/json")
+				} else {
+					w.Header().Set("Content-Type", problemContentType)
+				}
+				w.WriteHeader(c.status)
+				_, _ = w.Write([]byte(c.body))
+			}))
+			defer srv.Close()
+
+			res, err := formatCode(context.Background(), []byte(`{}`), srv.URL+"/fmt")
+			if c.wantCode != "" {
+				var p *problemError
+				if !errors.As(err, &p) || p.problem.Code != c.wantCode {
+					t.Fatalf("expected %s problem, got %v", c.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(res.Files) != len(c.wantFiles) || res.Files["main.go"] != c.wantFiles["main.go"] {
+				t.Errorf("files = %v, want %v", res.Files, c.wantFiles)
+			}
+			got := ""
+			if res.Error != nil {
+				got = *res.Error
+			}
+			if got != c.wantError {
+				t.Errorf("error = %q, want %q", got, c.wantError)
+			}
+		})
+	}
+}
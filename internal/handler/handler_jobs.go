@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/hooks"
+	"github.com/codiewio/codenire/internal/jobs"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateJobHandler queues a files submission and returns the job
+// without waiting for the run to finish.
+func (h *Handler) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeSubmissionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	annotations, ok := h.preRun(w, r, req)
+	if !ok {
+		return
+	}
+
+	job := jobs.New()
+	if err := h.Jobs.Create(r.Context(), job); err != nil {
+		writeProblem(w, http.StatusInternalServerError, api.InternalError, "create job failed: "+err.Error())
+		return
+	}
+
+	// The job outlives the request, but keeps its values for the quota accounting.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	h.jobsMu.Lock()
+	h.jobCancels[job.Id] = cancel
+	h.jobsMu.Unlock()
+
+	go h.runJob(ctx, job.Id, *req, hooks.NewHTTPRequest(r), annotations)
+
+	writeJSONResponse(w, job.JobResponse, http.StatusAccepted)
+}
+
+func (h *Handler) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := h.Jobs.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, job.JobResponse, http.StatusOK)
+}
+
+// CancelJobHandler cancels a queued or running job. Finished jobs are removed
+// from the store.
+func (h *Handler) CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	job, err := h.Jobs.Get(r.Context(), id)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	if job.Finished() {
+		if err = h.Jobs.Delete(r.Context(), id); err != nil {
+			writeJobError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.cancelJob(id)
+
+	job, err = h.Jobs.Update(r.Context(), id, func(job *jobs.Job) {
+		if !job.Finished() {
+			job.Status = api.Canceled
+		}
+	})
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, job.JobResponse, http.StatusOK)
+}
+
+// runJob waits for a free slot and runs the job like /run, the job is
+// queued until the sandbox reports the first phase of the run. Results of
+// cacheable templates are served from the cache.
+func (h *Handler) runJob(
+	ctx context.Context,
+	id string,
+	req api.SubmissionRequest,
+	httpReq hooks.HTTPRequest,
+	annotations map[string]string,
+) {
+	defer h.cancelJob(id)
+
+	select {
+	case h.jobSem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-h.jobSem }()
+
+	res, err := h.runCached(ctx, nil, req, func(ctx context.Context, req api.SubmissionRequest) (*api.SubmissionResponse, error) {
+		return h.dispatchJob(ctx, id, req)
+	})
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	if err == nil {
+		h.postRun(ctx, httpReq, req, res, annotations)
+	}
+
+	_, err = h.Jobs.Update(context.Background(), id, func(job *jobs.Job) {
+		if job.Finished() {
+			return
+		}
+
+		if err != nil {
+			msg := err.Error()
+			job.Status = api.Failed
+			job.Error = &msg
+			job.Problem = &toProblem(err).problem
+			return
+		}
+
+		job.Status = api.Done
+		job.Response = res
+	})
+	if err != nil {
+		log.Errorf("update job %s: %v", id, err)
+	}
+}
+
+// dispatchJob runs the submission like runCode, but through the streaming
+// endpoint of the sandbox, so that the job status follows the run phases.
+func (h *Handler) dispatchJob(ctx context.Context, id string, req api.SubmissionRequest) (*api.SubmissionResponse, error) {
+	execRes := api.SandboxResponse{
+		Status:   api.ExecutionStatusInternalError,
+		ExitCode: -1,
+	}
+
+	err := h.dispatchStream(ctx, req, func(ev api.SandboxStreamEvent) error {
+		switch ev.Kind {
+		case streamKindPhase:
+			if string(ev.Data) == phaseCompile {
+				h.setJobStatus(id, api.Compiling)
+			} else {
+				h.setJobStatus(id, api.Running)
+			}
+		case streamKindStdout:
+			execRes.Stdout = append(execRes.Stdout, ev.Data...)
+		case streamKindStderr:
+			execRes.Stderr = append(execRes.Stderr, ev.Data...)
+		case streamKindError:
+			// Like /run, a run the sandbox couldn't complete reports the error as output.
+			execRes.Stderr = append(execRes.Stderr, ev.Data...)
+		case streamKindDone:
+			if ev.RunEnvironment != nil {
+				execRes.RunEnvironment = *ev.RunEnvironment
+			}
+			execRes.Artifacts = ev.Artifacts
+			if ev.Result != nil {
+				execRes.Status = ev.Result.Status
+				execRes.ExitCode = ev.Result.ExitCode
+				execRes.Signal = ev.Result.Signal
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := submissionResponse(execRes)
+	if err != nil {
+		return nil, err
+	}
+	h.addDiagnostics(req.TemplateId, res)
+
+	return res, nil
+}
+
+func (h *Handler) setJobStatus(id string, status api.JobStatus) {
+	_, err := h.Jobs.Update(context.Background(), id, func(job *jobs.Job) {
+		if !job.Finished() {
+			job.Status = status
+		}
+	})
+	if err != nil {
+		log.Errorf("update job %s: %v", id, err)
+	}
+}
+
+func (h *Handler) cancelJob(id string) {
+	h.jobsMu.Lock()
+	defer h.jobsMu.Unlock()
+
+	if cancel, ok := h.jobCancels[id]; ok {
+		cancel()
+		delete(h.jobCancels, id)
+	}
+}
+
+func writeJobError(w http.ResponseWriter, err error) {
+	if errors.Is(err, jobs.ErrNotFound) {
+		writeProblem(w, http.StatusNotFound, api.JobNotFound, err.Error())
+		return
+	}
+
+	writeError(w, err)
+}
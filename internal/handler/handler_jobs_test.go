@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+	"github.com/codiewio/codenire/internal/cache"
+	"github.com/codiewio/codenire/internal/images"
+	"github.com/codiewio/codenire/internal/jobs"
+	"github.com/go-chi/chi/v5"
+)
+
+// newJobsServer serves the job routes of a handler in front of a fake sandbox
+// serving the cacheable python_3 template, stream answers its /run/stream requests.
+func newJobsServer(t *testing.T, stream http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	templates := []api.ImageConfig{{
+		Template:  "python_3",
+		Cacheable: true,
+		Actions: map[string]api.ImageActionConfig{
+			defaultAction: {Id: defaultAction, Name: defaultAction, IsDefault: true, RunCmd: "python3 main.py"},
+		},
+	}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(templates)
+	})
+	mux.HandleFunc("/run/stream", stream)
+
+	sandbox := httptest.NewServer(mux)
+	t.Cleanup(sandbox.Close)
+
+	pool := backend.NewPool([]string{sandbox.URL})
+	pool.Check(context.Background())
+
+	registry := images.NewRegistry(func(context.Context) (*[]api.ImageConfig, error) {
+		return &templates, nil
+	})
+	if _, err := registry.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Handler{
+		Config:     &Config{},
+		Backends:   pool,
+		Templates:  registry,
+		Jobs:       jobs.NewMemoryStore(time.Hour),
+		Cache:      cache.New(10, time.Hour),
+		jobCancels: make(map[string]context.CancelFunc),
+		jobSem:     make(chan struct{}, 1),
+	}
+
+	r := chi.NewRouter()
+	r.Post("/jobs", h.CreateJobHandler)
+	r.Get("/jobs/{id}", h.GetJobHandler)
+	r.Delete("/jobs/{id}", h.CancelJobHandler)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// sendEvents writes the events like the streaming endpoint of the sandbox.
+func sendEvents(w http.ResponseWriter, events ...api.SandboxStreamEvent) {
+	for _, ev := range events {
+		body, _ := json.Marshal(ev)
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", body)
+	}
+	w.(http.Flusher).Flush()
+}
+
+func doJob(t *testing.T, method, url string) (int, api.JobResponse) {
+	t.Helper()
+
+	var body *strings.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(`{"TemplateId":"python_3","Files":{"main.py":"print(1)"},"Args":"","Stdin":""}`)
+	} else {
+		body = strings.NewReader("")
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var job api.JobResponse
+	_ = json.NewDecoder(resp.Body).Decode(&job)
+
+	return resp.StatusCode, job
+}
+
+// waitJob polls the job until it has the status.
+func waitJob(t *testing.T, url string, status api.JobStatus) api.JobResponse {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, job := doJob(t, http.MethodGet, url)
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job status = %s, want %s", job.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobs_Run(t *testing.T) {
+	phases := make(chan struct{})
+
+	srv := newJobsServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		<-phases
+		sendEvents(w, api.SandboxStreamEvent{Kind: streamKindPhase, Data: []byte(phaseCompile)})
+		<-phases
+		sendEvents(w,
+			api.SandboxStreamEvent{Kind: streamKindPhase, Data: []byte("run")},
+			api.SandboxStreamEvent{Kind: streamKindStdout, Data: []byte("1\n")},
+			api.SandboxStreamEvent{
+				Kind:           streamKindDone,
+				RunEnvironment: &api.RunEnvironment{RunCmd: "python3 main.py"},
+				Result:         &api.ExecutionResult{Status: api.ExecutionStatusOk},
+			},
+		)
+	})
+
+	status, job := doJob(t, http.MethodPost, srv.URL+"/jobs")
+	if status != http.StatusAccepted || job.Id == "" {
+		t.Fatalf("create = %d %+v", status, job)
+	}
+	url := srv.URL + "/jobs/" + job.Id
+
+	// The job is queued until the sandbox reports the first phase.
+	time.Sleep(50 * time.Millisecond)
+	if _, job = doJob(t, http.MethodGet, url); job.Status != api.Queued {
+		t.Errorf("status before the first phase = %s, want queued", job.Status)
+	}
+
+	phases <- struct{}{}
+	waitJob(t, url, api.Compiling)
+
+	phases <- struct{}{}
+	job = waitJob(t, url, api.Done)
+	if job.Response == nil || job.Response.Status != api.ExecutionStatusOk || job.Response.RunEnvironment.RunCmd != "python3 main.py" {
+		t.Fatalf("response = %+v", job.Response)
+	}
+	if events := job.Response.Events; len(events) != 1 || events[0].Message != "1\n" {
+		t.Errorf("events = %+v", events)
+	}
+
+	// Finished jobs are removed by DELETE.
+	if status, _ = doJob(t, http.MethodDelete, url); status != http.StatusNoContent {
+		t.Errorf("delete = %d, want 204", status)
+	}
+	if status, _ = doJob(t, http.MethodGet, url); status != http.StatusNotFound {
+		t.Errorf("get after delete = %d, want 404", status)
+	}
+}
+
+func TestJobs_Cancel(t *testing.T) {
+	stopped := make(chan struct{})
+
+	srv := newJobsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		sendEvents(w, api.SandboxStreamEvent{Kind: streamKindPhase, Data: []byte("run")})
+		<-r.Context().Done()
+		close(stopped)
+	})
+
+	_, job := doJob(t, http.MethodPost, srv.URL+"/jobs")
+	url := srv.URL + "/jobs/" + job.Id
+	waitJob(t, url, api.Running)
+
+	status, job := doJob(t, http.MethodDelete, url)
+	if status != http.StatusOK || job.Status != api.Canceled {
+		t.Fatalf("cancel = %d %+v", status, job)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run wasn't stopped in the sandbox")
+	}
+	if _, job = doJob(t, http.MethodGet, url); job.Status != api.Canceled || job.Response != nil {
+		t.Errorf("job after the run stopped = %+v", job)
+	}
+}
+
+func TestJobs_TimedOut(t *testing.T) {
+	srv := newJobsServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		sendEvents(w,
+			api.SandboxStreamEvent{Kind: streamKindPhase, Data: []byte("run")},
+			api.SandboxStreamEvent{Kind: streamKindError, Data: []byte("timeout execute")},
+			api.SandboxStreamEvent{
+				Kind:           streamKindDone,
+				RunEnvironment: &api.RunEnvironment{RunCmd: "python3 main.py"},
+				Result:         &api.ExecutionResult{Status: api.ExecutionStatusTimeout, ExitCode: -1},
+			},
+		)
+	})
+
+	// Like /run, the job is done and reports the timeout as its status and output.
+	_, job := doJob(t, http.MethodPost, srv.URL+"/jobs")
+	job = waitJob(t, srv.URL+"/jobs/"+job.Id, api.Done)
+	if job.Response == nil || job.Response.Status != api.ExecutionStatusTimeout {
+		t.Fatalf("response = %+v, want the timeout status", job.Response)
+	}
+	if !slices.Contains(job.Response.Events, api.SubmissionResponseEvents{Kind: "stderr", Message: "timeout execute"}) {
+		t.Errorf("events = %+v, want the timeout in stderr", job.Response.Events)
+	}
+}
+
+func TestJobs_Cached(t *testing.T) {
+	var runs atomic.Int32
+
+	srv := newJobsServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		runs.Add(1)
+		sendEvents(w,
+			api.SandboxStreamEvent{Kind: streamKindPhase, Data: []byte("run")},
+			api.SandboxStreamEvent{Kind: streamKindStdout, Data: []byte("1\n")},
+			api.SandboxStreamEvent{Kind: streamKindDone, Result: &api.ExecutionResult{Status: api.ExecutionStatusOk}},
+		)
+	})
+
+	for i := 0; i < 2; i++ {
+		_, job := doJob(t, http.MethodPost, srv.URL+"/jobs")
+		job = waitJob(t, srv.URL+"/jobs/"+job.Id, api.Done)
+		if job.Response == nil || len(job.Response.Events) != 1 || job.Response.Events[0].Message != "1\n" {
+			t.Fatalf("job %d response = %+v", i, job.Response)
+		}
+	}
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("sandbox runs = %d, want 1 with the second job served from the cache", n)
+	}
+}
+
+func TestJobs_Failed(t *testing.T) {
+	srv := newJobsServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, job := doJob(t, http.MethodPost, srv.URL+"/jobs")
+	job = waitJob(t, srv.URL+"/jobs/"+job.Id, api.Failed)
+	if job.Error == nil || job.Problem == nil || job.Response != nil {
+		t.Errorf("failed job = %+v", job)
+	}
+}
+
+func TestJobs_NotFound(t *testing.T) {
+	srv := newJobsServer(t, func(http.ResponseWriter, *http.Request) {})
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if status, _ := doJob(t, method, srv.URL+"/jobs/unknown"); status != http.StatusNotFound {
+			t.Errorf("%s unknown job = %d, want 404", method, status)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+	"github.com/codiewio/codenire/internal/client"
+	"github.com/codiewio/codenire/internal/judge"
+)
+
+// JudgeHandler compiles a files submission once, runs it for every test case
+// and returns a verdict per case.
+func (h *Handler) JudgeHandler(w http.ResponseWriter, r *http.Request) {
+	reader := http.MaxBytesReader(nil, r.Body, MaxJudgeRequestSize)
+	defer reader.Close()
+
+	var jreq api.JudgeRequest
+	if err := json.NewDecoder(reader).Decode(&jreq); err != nil {
+		maxBytesErr := new(http.MaxBytesError)
+		if errors.As(err, &maxBytesErr) {
+			writeProblem(w, http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("judge request too large (max %d bytes)", MaxJudgeRequestSize))
+			return
+		}
+
+		writeProblem(w, http.StatusBadRequest, api.InvalidRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	if len(jreq.Cases) == 0 || len(jreq.Cases) > MaxJudgeCases {
+		writeProblem(w, http.StatusBadRequest, api.TooManyTestCases, fmt.Sprintf("between 1 and %d test cases are required", MaxJudgeCases))
+		return
+	}
+
+	req := api.SubmissionRequest{
+		TemplateId:      jreq.TemplateId,
+		ActionId:        jreq.ActionId,
+		Files:           jreq.Files,
+		FilesMeta:       jreq.FilesMeta,
+		Args:            jreq.Args,
+		Stdin:           jreq.Stdin,
+		ExternalOptions: jreq.ExternalOptions,
+	}
+	if !h.completeSubmissionRequest(w, r, &req) {
+		return
+	}
+
+	if _, ok := h.preRun(w, r, &req); !ok {
+		return
+	}
+
+	res, err := h.dispatchJudge(r.Context(), req, jreq)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, res, http.StatusOK)
+}
+
+// dispatchJudge runs the test cases on one of the sandbox backends serving the template.
+func (h *Handler) dispatchJudge(ctx context.Context, req api.SubmissionRequest, jreq api.JudgeRequest) (*api.JudgeResponse, error) {
+	sreq, err := sandboxRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cases := make([]api.SandboxJudgeCase, len(jreq.Cases))
+	for i, c := range jreq.Cases {
+		cases[i] = api.SandboxJudgeCase{Stdin: c.Stdin, Args: c.Args}
+	}
+
+	body, err := json.Marshal(api.SandboxJudgeRequest{
+		Action:          sreq.Action,
+		Args:            sreq.Args,
+		Binary:          sreq.Binary,
+		ExtendedOptions: sreq.ExtendedOptions,
+		SandId:          sreq.SandId,
+		Stdin:           sreq.Stdin,
+		Cases:           cases,
+		TimeLimit:       jreq.TimeLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sres *api.SandboxJudgeResponse
+	err = h.Backends.Do(ctx, req.TemplateId, func(b *backend.Backend) (err error) {
+		sres, err = judgeCode(ctx, body, b.URL+"/judge")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.chargeQuota(ctx, sres.RunEnvironment)
+
+	return judgeResponse(jreq.Cases, sres)
+}
+
+func judgeCode(ctx context.Context, body []byte, backendURL string) (*api.SandboxJudgeResponse, error) {
+	sreq, err := http.NewRequestWithContext(ctx, http.MethodPost, backendURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("request marshal error: %w", err)
+	}
+
+	resp, err := client.SandboxBackendClient().Do(sreq)
+	if err != nil {
+		sandboxErr := fmt.Errorf("sandbox client request error: %w", err)
+		log.Printf("got error from sandbox: %s", sandboxErr.Error())
+
+		return nil, sandboxErr
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, sandboxProblem(resp)
+	}
+
+	var res api.SandboxJudgeResponse
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("jSON decode error from backend: %w", err)
+	}
+
+	if res.Error != nil {
+		return nil, errors.New(*res.Error)
+	}
+
+	return &res, nil
+}
+
+// judgeResponse assigns the verdicts to the case results of the sandbox.
+func judgeResponse(cases []api.JudgeCase, sres *api.SandboxJudgeResponse) (*api.JudgeResponse, error) {
+	res := &api.JudgeResponse{
+		Cases:          []api.JudgeCaseResult{},
+		RunEnvironment: sres.RunEnvironment,
+	}
+
+	if sres.CompileFailed {
+		res.Verdict = api.JudgeVerdictCompilationError
+		if sres.CompileOutput != nil {
+			output := string(sanitize(*sres.CompileOutput))
+			res.CompileOutput = &output
+		}
+
+		return res, nil
+	}
+
+	if len(sres.Cases) != len(cases) {
+		return nil, fmt.Errorf("sandbox returned %d results for %d test cases", len(sres.Cases), len(cases))
+	}
+
+	for i, c := range sres.Cases {
+		res.Cases = append(res.Cases, api.JudgeCaseResult{
+			Verdict: judge.Verdict(cases[i], judge.Run{
+				Stdout:    string(c.Stdout),
+				ExitCode:  c.ExitCode,
+				TimedOut:  c.TimedOut,
+				OOMKilled: c.OomKilled,
+				NotRun:    c.NotRun,
+			}),
+			Time:     c.Time,
+			ExitCode: c.ExitCode,
+			Stdout:   string(sanitize(c.Stdout)),
+			Stderr:   string(sanitize(c.Stderr)),
+		})
+	}
+
+	res.Verdict = judge.Overall(res.Cases)
+
+	return res, nil
+}
@@ -3,70 +3,153 @@ package handler
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
 	"github.com/codiewio/codenire/internal/client"
-	"github.com/codiewio/codenire/internal/images"
+	"github.com/codiewio/codenire/internal/diagnostics"
+	"github.com/codiewio/codenire/internal/hooks"
 )
 
 var (
-	MaxFilesSnippetSize  int64 = 60 * 1024
+	// MaxFilesSnippetSize limits the body of a files submission. When it's 0
+	// the limit is derived from MaxFileSize, see filesRequestSize.
+	MaxFilesSnippetSize  int64 = 0
 	MaxScriptSnippetSize int64 = 1 * 1024 * 1024
+	MaxJudgeRequestSize  int64 = 1 * 1024 * 1024
+	MaxJudgeCases              = 100
+
+	// MaxFiles and MaxFileSize limit the files of a submission,
+	// the size is the one of the decoded content of binary files.
+	MaxFiles          = 100
+	MaxFileSize int64 = 1 * 1024 * 1024
+
+	// MaxArchiveSize limits an uploaded project archive and
+	// MaxArchiveContentSize the total size of the files extracted from it.
+	MaxArchiveSize        int64 = 5 * 1024 * 1024
+	MaxArchiveContentSize int64 = 10 * 1024 * 1024
 )
 
+// filesRequestOverhead is the room left in the body of a files submission for
+// the JSON around its largest file.
+const filesRequestOverhead = 64 * 1024
+
+// filesRequestSize returns the limit of the body of a files submission. Unless
+// MaxFilesSnippetSize is set, a file of MaxFileSize fits in it as base64.
+func filesRequestSize() int64 {
+	if MaxFilesSnippetSize > 0 {
+		return MaxFilesSnippetSize
+	}
+
+	return int64(base64.StdEncoding.EncodedLen(int(MaxFileSize))) + filesRequestOverhead
+}
+
 func (h *Handler) RunFilesHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, http.StatusMethodNotAllowed, api.MethodNotAllowed, "")
 		return
 	}
 
-	reader := http.MaxBytesReader(nil, r.Body, MaxFilesSnippetSize)
-	defer reader.Close()
+	req, ok := h.decodeSubmissionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	annotations, ok := h.preRun(w, r, req)
+	if !ok {
+		return
+	}
+
+	apiRes, err := h.dispatchCached(r.Context(), w, *req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.postRun(r.Context(), hooks.NewHTTPRequest(r), *req, apiRes, annotations)
+
+	writeJSONResponse(w, apiRes, http.StatusOK)
+}
 
+// decodeSubmissionRequest reads a files submission from the request body and
+// completes it with the default files of the requested action. On failure the
+// error is written to w and false is returned.
+func (h *Handler) decodeSubmissionRequest(w http.ResponseWriter, r *http.Request) (*api.SubmissionRequest, bool) {
 	var req api.SubmissionRequest
-	if err := json.NewDecoder(reader).Decode(&req); err != nil {
+	if !decodeFilesRequest(w, r, &req) {
+		return nil, false
+	}
+
+	if !h.completeSubmissionRequest(w, r, &req) {
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// decodeFilesRequest reads the JSON body of a files submission into v, limited
+// by filesRequestSize. On failure the error is written to w and false is returned.
+func decodeFilesRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	limit := filesRequestSize()
+
+	reader := http.MaxBytesReader(nil, r.Body, limit)
+	defer reader.Close()
+
+	if err := json.NewDecoder(reader).Decode(v); err != nil {
 		maxBytesErr := new(http.MaxBytesError)
 		if errors.As(err, &maxBytesErr) {
-			http.Error(w, fmt.Sprintf("code snippet too large (max %d bytes): ", MaxFilesSnippetSize)+err.Error(), http.StatusBadRequest)
-			return
+			writeProblem(w, http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("code snippet too large (max %d bytes)", limit))
+			return false
 		}
 
-		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
-		return
+		writeProblem(w, http.StatusBadRequest, api.InvalidRequest, "invalid request: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+// completeSubmissionRequest checks that the caller may run the requested action
+// and adds its default files to req. On failure the error is written to w and
+// false is returned.
+func (h *Handler) completeSubmissionRequest(w http.ResponseWriter, r *http.Request, req *api.SubmissionRequest) bool {
+	if err := checkFiles(req.Files, req.FilesMeta); err != nil {
+		writeError(w, err)
+		return false
 	}
 
-	cfg := images.GetImageConfig(req.TemplateId)
+	cfg := h.Templates.Get(req.TemplateId)
 	if cfg == nil {
-		http.Error(w, fmt.Sprintf("template `%s` not found", req.TemplateId), http.StatusBadRequest)
-		return
+		writeProblem(w, http.StatusBadRequest, api.TemplateNotFound, fmt.Sprintf("template `%s` not found", req.TemplateId))
+		return false
 	}
 
 	action, err := getAction(req.ActionId, cfg)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		writeProblem(w, http.StatusBadRequest, api.ActionNotFound, err.Error())
+		return false
 	}
 
-	req.Files = addDefaultFiles(req.Files, action.DefaultFiles)
-
-	apiRes, err := runCode(r.Context(), req, h.Config.BackendURL+"/run")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if !h.authorizeAction(w, r, *cfg, actionName(req.ActionId), *action, req.ExternalOptions) {
+		return false
 	}
 
-	writeJSONResponse(w, apiRes, http.StatusOK)
+	req.Files = addDefaultFiles(req.Files, action.DefaultFiles)
+
+	return true
 }
 
 func (h *Handler) RunScriptHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, http.StatusMethodNotAllowed, api.MethodNotAllowed, "")
 		return
 	}
 
@@ -77,24 +160,27 @@ func (h *Handler) RunScriptHandler(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(reader).Decode(&preReq); err != nil {
 		maxBytesErr := new(http.MaxBytesError)
 		if errors.As(err, &maxBytesErr) {
-			err = fmt.Errorf("code snippet too large (max %d bytes): %w", MaxScriptSnippetSize, err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeProblem(w, http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("code snippet too large (max %d bytes)", MaxScriptSnippetSize))
 			return
 		}
 
-		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, api.InvalidRequest, "invalid request: "+err.Error())
 		return
 	}
 
-	cfg := images.GetImageConfig(preReq.TemplateId)
+	cfg := h.Templates.Get(preReq.TemplateId)
 	if cfg == nil {
-		http.Error(w, fmt.Sprintf("template `%s` not found", preReq.TemplateId), http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, api.TemplateNotFound, fmt.Sprintf("template `%s` not found", preReq.TemplateId))
 		return
 	}
 
 	action, err := getAction(preReq.ActionId, cfg)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, api.ActionNotFound, err.Error())
+		return
+	}
+
+	if !h.authorizeAction(w, r, *cfg, actionName(preReq.ActionId), *action, preReq.ExternalOptions) {
 		return
 	}
 
@@ -111,46 +197,40 @@ func (h *Handler) RunScriptHandler(w http.ResponseWriter, r *http.Request) {
 	req.Files[sourceFile] = preReq.Code
 	req.Files = addDefaultFiles(req.Files, action.DefaultFiles)
 
-	apiRes, err := runCode(r.Context(), req, h.Config.BackendURL+"/run")
+	annotations, ok := h.preRun(w, r, &req)
+	if !ok {
+		return
+	}
+
+	apiRes, err := h.dispatchCached(r.Context(), w, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
+	h.postRun(r.Context(), hooks.NewHTTPRequest(r), req, apiRes, annotations)
+
 	writeJSONResponse(w, apiRes, http.StatusOK)
 }
 
-func runCode(ctx context.Context, req api.SubmissionRequest, backendURL string) (*api.SubmissionResponse, error) {
-	tmpDir, err := os.MkdirTemp("", "box")
-	if err != nil {
-		return nil, fmt.Errorf("create tmp dir error: %w", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	err = copyFilesToTmpDir(tmpDir, req.Files)
-	if err != nil {
-		return nil, fmt.Errorf("copying files into tmp dir failed: %w", err)
+// dispatch runs the submission on one of the sandbox backends serving its template.
+func (h *Handler) dispatch(ctx context.Context, req api.SubmissionRequest) (*api.SubmissionResponse, error) {
+	var res *api.SubmissionResponse
+
+	err := h.Backends.Do(ctx, req.TemplateId, func(b *backend.Backend) (err error) {
+		res, err = runCode(ctx, req, b.URL+"/run")
+		return err
+	})
+	if err == nil {
+		h.chargeQuota(ctx, res.RunEnvironment)
+		h.addDiagnostics(req.TemplateId, res)
 	}
 
-	b, err := tarToBase64(tmpDir)
-	if err != nil {
-		return nil, fmt.Errorf("fail on create tar files: %w", err)
-	}
+	return res, err
+}
 
-	action := "default"
-	if req.ActionId != nil {
-		action = *req.ActionId
-	}
-	jsonData, err := json.Marshal(
-		api.SandboxRequest{
-			Args:            req.Args,
-			SandId:          req.TemplateId,
-			Binary:          b,
-			Stdin:           req.Stdin,
-			Action:          action,
-			ExtendedOptions: req.ExternalOptions,
-		},
-	)
+func runCode(ctx context.Context, req api.SubmissionRequest, backendURL string) (*api.SubmissionResponse, error) {
+	jsonData, err := sandboxRequestBody(req)
 	if err != nil {
 		return nil, err
 	}
@@ -180,7 +260,7 @@ func runCode(ctx context.Context, req api.SubmissionRequest, backendURL string)
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected http status from backend: %d", resp.StatusCode)
+		return nil, sandboxProblem(resp)
 	}
 
 	var execRes api.SandboxResponse
@@ -188,6 +268,12 @@ func runCode(ctx context.Context, req api.SubmissionRequest, backendURL string)
 		return nil, fmt.Errorf("jSON decode error from backend: %w", err)
 	}
 
+	return submissionResponse(execRes)
+}
+
+// submissionResponse converts the program output received from the sandbox
+// into the events of the API response.
+func submissionResponse(execRes api.SandboxResponse) (*api.SubmissionResponse, error) {
 	rec := new(Recorder)
 	_, _ = rec.Stdout().Write(execRes.Stdout)
 	_, _ = rec.Stderr().Write(execRes.Stderr)
@@ -196,30 +282,108 @@ func runCode(ctx context.Context, req api.SubmissionRequest, backendURL string)
 		return nil, fmt.Errorf("error decoding events: %w", err)
 	}
 
+	env := execRes.RunEnvironment
 	apiRes := &api.SubmissionResponse{
 		Events: events,
 		RunEnvironment: api.RunEnvironment{
-			RunCmd:      execRes.RunEnvironment.RunCmd,
-			CompileCmd:  execRes.RunEnvironment.CompileCmd,
-			RunTime:     execRes.RunEnvironment.RunTime,
-			CompileTime: execRes.RunEnvironment.CompileTime,
-			ActionName:  execRes.RunEnvironment.ActionName,
+			RunCmd:       env.RunCmd,
+			CompileCmd:   env.CompileCmd,
+			RunTime:      env.RunTime,
+			CompileTime:  env.CompileTime,
+			ActionName:   env.ActionName,
+			CompileUsage: env.CompileUsage,
+			RunUsage:     env.RunUsage,
+			Steps:        env.Steps,
 		},
+		Status:    execRes.Status,
+		ExitCode:  execRes.ExitCode,
+		Signal:    execRes.Signal,
+		Artifacts: execRes.Artifacts,
 	}
 
 	return apiRes, nil
 }
 
-func getAction(reqAction *string, cfg *api.ImageConfig) (*api.ImageActionConfig, error) {
-	actionID := "default"
-	if reqAction != nil {
-		actionID = *reqAction
+// addDiagnostics parses the compiler output of a failed compilation into the
+// diagnostics of the response, with the parser of the template groups.
+func (h *Handler) addDiagnostics(templateID string, res *api.SubmissionResponse) {
+	if res.Status != api.ExecutionStatusCompileError {
+		return
+	}
+
+	cfg := h.Templates.Get(templateID)
+	if cfg == nil {
+		return
+	}
+
+	var output strings.Builder
+	for _, ev := range res.Events {
+		output.WriteString(ev.Message)
+	}
+
+	if diags := diagnostics.Parse(cfg.Groups, cfg.Workdir, output.String()); len(diags) > 0 {
+		res.Diagnostics = &diags
+	}
+}
+
+// sandboxRequestBody packs the submission files into a tar archive and
+// returns the JSON encoded SandboxRequest for the sandbox backend.
+func sandboxRequestBody(req api.SubmissionRequest) ([]byte, error) {
+	sreq, err := sandboxRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(sreq)
+}
+
+// sandboxRequest packs the submission files into a tar archive.
+func sandboxRequest(req api.SubmissionRequest) (*api.SandboxRequest, error) {
+	tmpDir, err := os.MkdirTemp("", "box")
+	if err != nil {
+		return nil, fmt.Errorf("create tmp dir error: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	err = copyFilesToTmpDir(tmpDir, req.Files, req.FilesMeta)
+	if err != nil {
+		return nil, fmt.Errorf("copying files into tmp dir failed: %w", err)
+	}
+
+	b, err := tarToBase64(tmpDir)
+	if err != nil {
+		return nil, fmt.Errorf("fail on create tar files: %w", err)
+	}
+
+	action := "default"
+	if req.ActionId != nil {
+		action = *req.ActionId
 	}
 
-	action, ok := cfg.Actions[actionID]
+	return &api.SandboxRequest{
+		Args:            req.Args,
+		SandId:          req.TemplateId,
+		Binary:          b,
+		Stdin:           req.Stdin,
+		Action:          action,
+		ExtendedOptions: req.ExternalOptions,
+	}, nil
+}
+
+func getAction(reqAction *string, cfg *api.ImageConfig) (*api.ImageActionConfig, error) {
+	action, ok := cfg.Actions[actionName(reqAction)]
 	if !ok {
 		return nil, fmt.Errorf("action `%s` not found", *reqAction)
 	}
 
 	return &action, nil
 }
+
+// actionName returns the requested action, which is the default one if not set.
+func actionName(reqAction *string) string {
+	if reqAction == nil {
+		return defaultAction
+	}
+
+	return *reqAction
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/snippets"
+	"github.com/go-chi/chi/v5"
+)
+
+// ShareHandler stores a submission as it was sent, without the default files
+// of the action, and returns the ID under which it's served by GetSnippetHandler.
+func (h *Handler) ShareHandler(w http.ResponseWriter, r *http.Request) {
+	var req api.SubmissionRequest
+	if !decodeFilesRequest(w, r, &req) {
+		return
+	}
+
+	if err := checkFiles(req.Files, req.FilesMeta); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if h.Templates.Get(req.TemplateId) == nil {
+		writeProblem(w, http.StatusBadRequest, api.TemplateNotFound, fmt.Sprintf("template `%s` not found", req.TemplateId))
+		return
+	}
+
+	// The fields are encoded in a fixed order and the files sorted by name,
+	// so the same submission always gets the same ID.
+	data, err := json.Marshal(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id := snippets.ID(data)
+	if err = h.Snippets.Put(r.Context(), id, data); err != nil {
+		log.Errorf("share snippet: %v", err)
+		writeProblem(w, http.StatusInternalServerError, api.InternalError, "store snippet failed")
+		return
+	}
+
+	writeJSONResponse(w, api.ShareResponse{Id: id}, http.StatusOK)
+}
+
+func (h *Handler) GetSnippetHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := h.Snippets.Get(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, snippets.ErrNotFound) {
+		writeProblem(w, http.StatusNotFound, api.SnippetNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Errorf("get snippet: %v", err)
+		writeProblem(w, http.StatusInternalServerError, api.InternalError, "load snippet failed")
+		return
+	}
+
+	// Snippets never change, their ID is the hash of the content.
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	_, _ = w.Write(data)
+}
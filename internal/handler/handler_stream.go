@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+	"github.com/codiewio/codenire/internal/client"
+)
+
+const (
+	streamKindStdout = "stdout"
+	streamKindStderr = "stderr"
+	streamKindPhase  = "phase"
+	streamKindDone   = "done"
+	streamKindError  = "error"
+
+	phaseCompile = "compile"
+
+	// The done event carries the artifacts of the run.
+	maxStreamEventSize = 16 * 1024 * 1024
+)
+
+// RunStreamHandler runs a files submission and sends its output to the client
+// as server-sent events while the program is still running in the sandbox.
+func (h *Handler) RunStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, api.MethodNotAllowed, "")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, http.StatusInternalServerError, api.InternalError, "streaming unsupported")
+		return
+	}
+
+	req, ok := h.decodeSubmissionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Only pre-run hooks are invoked, the output has already
+	// been sent when a post-run hook could annotate it.
+	if _, ok = h.preRun(w, r, req); !ok {
+		return
+	}
+
+	// The stream starts with the first event, so failures
+	// before the run are still answered with problem details.
+	started := false
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	stream := newEventStream(w, flusher)
+	done := false
+
+	err := h.dispatchStream(r.Context(), *req, func(ev api.SandboxStreamEvent) error {
+		if !started {
+			start()
+		}
+
+		if ev.Kind == streamKindDone {
+			done = true
+			return stream.done(ev)
+		}
+
+		return stream.output(ev.Kind, ev.Data)
+	})
+	if err != nil && !started {
+		writeError(w, err)
+		return
+	}
+	if !started {
+		start()
+	}
+	if err != nil {
+		log.Errorf("stream run: %v", err)
+		_ = stream.fail(err)
+	}
+
+	if !done {
+		_ = stream.done(api.SandboxStreamEvent{})
+	}
+}
+
+// eventStream sends the events of a run to the client as server-sent events.
+type eventStream struct {
+	w       io.Writer
+	flusher http.Flusher
+	// Output chunks may end in the middle of a multibyte character,
+	// so the incomplete tail is kept until the next chunk of the same kind.
+	pending map[string][]byte
+}
+
+func newEventStream(w io.Writer, flusher http.Flusher) *eventStream {
+	return &eventStream{w: w, flusher: flusher, pending: make(map[string][]byte)}
+}
+
+// output sends the data of an output event up to its last complete character.
+func (s *eventStream) output(kind string, data []byte) error {
+	data = append(s.pending[kind], data...)
+	data, s.pending[kind] = splitIncompleteRune(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	return writeSSE(s.w, s.flusher, kind, api.SubmissionResponseEvents{
+		Kind:    kind,
+		Message: string(sanitize(data)),
+	})
+}
+
+// flush sends the incomplete tails which are still kept, the stream ends
+// without the rest of their characters.
+func (s *eventStream) flush() error {
+	for _, kind := range slices.Sorted(maps.Keys(s.pending)) {
+		data := s.pending[kind]
+		delete(s.pending, kind)
+		if len(data) == 0 {
+			continue
+		}
+
+		err := writeSSE(s.w, s.flusher, kind, api.SubmissionResponseEvents{
+			Kind:    kind,
+			Message: string(sanitize(data)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fail sends the error which ended the run after the kept output.
+func (s *eventStream) fail(err error) error {
+	if fErr := s.flush(); fErr != nil {
+		return fErr
+	}
+
+	return writeSSE(s.w, s.flusher, streamKindError, api.SubmissionResponseEvents{
+		Kind:    streamKindError,
+		Message: err.Error(),
+	})
+}
+
+// done sends the done event of the run after the kept output.
+func (s *eventStream) done(ev api.SandboxStreamEvent) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	return writeSSE(s.w, s.flusher, streamKindDone, streamDoneEvent(ev))
+}
+
+// streamDoneEvent returns the payload of the done event sent to the client.
+// Without a result from the sandbox the run is reported as internal error.
+func streamDoneEvent(ev api.SandboxStreamEvent) api.StreamDoneEvent {
+	done := api.StreamDoneEvent{
+		Status:   api.ExecutionStatusInternalError,
+		ExitCode: -1,
+	}
+
+	if env := ev.RunEnvironment; env != nil {
+		done.ActionName = env.ActionName
+		done.CompileCmd = env.CompileCmd
+		done.CompileTime = env.CompileTime
+		done.RunCmd = env.RunCmd
+		done.RunTime = env.RunTime
+		done.CompileUsage = env.CompileUsage
+		done.RunUsage = env.RunUsage
+		done.Steps = env.Steps
+	}
+
+	done.Artifacts = ev.Artifacts
+
+	if res := ev.Result; res != nil {
+		done.Status = res.Status
+		done.ExitCode = res.ExitCode
+		done.Signal = res.Signal
+	}
+
+	return done
+}
+
+// dispatchStream streams the submission from one of the sandbox backends
+// serving its template. Only connection failures are retried on another
+// backend, so no event is emitted twice.
+func (h *Handler) dispatchStream(ctx context.Context, req api.SubmissionRequest, emit func(api.SandboxStreamEvent) error) error {
+	return h.Backends.Do(ctx, req.TemplateId, func(b *backend.Backend) error {
+		return streamCode(ctx, req, b.URL+"/run/stream", func(ev api.SandboxStreamEvent) error {
+			if ev.Kind == streamKindDone && ev.RunEnvironment != nil {
+				h.chargeQuota(ctx, *ev.RunEnvironment)
+			}
+
+			return emit(ev)
+		})
+	})
+}
+
+// streamCode sends the submission to the streaming endpoint of the sandbox
+// and calls emit for every event received from it.
+func streamCode(ctx context.Context, req api.SubmissionRequest, backendURL string, emit func(api.SandboxStreamEvent) error) error {
+	jsonData, err := sandboxRequestBody(req)
+	if err != nil {
+		return err
+	}
+
+	sreq, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		backendURL,
+		bytes.NewBuffer(jsonData),
+	)
+	if err != nil {
+		return fmt.Errorf("request marshal error: %w", err)
+	}
+
+	sreq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := client.SandboxBackendClient().Do(sreq)
+	if err != nil {
+		sandboxErr := fmt.Errorf("sandbox client request error: %w", err)
+		log.Printf("got error from sandbox: %s", sandboxErr.Error())
+
+		return sandboxErr
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return sandboxProblem(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamEventSize)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var ev api.SandboxStreamEvent
+		if err = json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("jSON decode error from backend: %w", err)
+		}
+
+		if err = emit(ev); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func writeSSE(w io.Writer, flusher http.Flusher, kind string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", kind, body); err != nil {
+		return err
+	}
+	flusher.Flush()
+
+	return nil
+}
+
+// splitIncompleteRune splits b into the part which doesn't end in the middle
+// of a UTF-8 sequence and the incomplete trailing sequence, if any.
+func splitIncompleteRune(b []byte) ([]byte, []byte) {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		start := len(b) - i
+		if !utf8.RuneStart(b[start]) {
+			continue
+		}
+		if utf8.FullRune(b[start:]) {
+			return b, nil
+		}
+
+		return b[:start], append([]byte(nil), b[start:]...)
+	}
+
+	return b, nil
+}
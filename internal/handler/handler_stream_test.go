@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestSplitIncompleteRune(t *testing.T) {
+	world := []byte("世界")
+
+	testCases := []struct {
+		name     string
+		input    []byte
+		wantData string
+		wantRest []byte
+	}{
+		{"ascii", []byte("hello"), "hello", nil},
+		{"complete-multibyte", world, "世界", nil},
+		{"cut-after-first-byte", world[:4], "世", world[3:4]},
+		{"cut-after-second-byte", world[:5], "世", world[3:5]},
+		{"only-incomplete", world[:1], "", world[:1]},
+		{"empty", nil, "", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, rest := splitIncompleteRune(tc.input)
+			if string(data) != tc.wantData {
+				t.Errorf("data: got %q, want %q", data, tc.wantData)
+			}
+			if string(rest) != string(tc.wantRest) {
+				t.Errorf("rest: got %q, want %q", rest, tc.wantRest)
+			}
+		})
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	world := []byte("世界")
+
+	testCases := []struct {
+		name string
+		end  func(s *eventStream) error
+		want string
+	}{
+		{"done", func(s *eventStream) error {
+			return s.done(api.SandboxStreamEvent{Result: &api.ExecutionResult{Status: api.ExecutionStatusOk}})
+		}, "stdout:世|stdout:界|stderr:\ufffd\ufffd|stdout:\ufffd|done"},
+		{"error", func(s *eventStream) error {
+			return s.fail(errors.New("sandbox gone"))
+		}, "stdout:世|stdout:界|stderr:\ufffd\ufffd|stdout:\ufffd|error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s := newEventStream(rec, rec)
+
+			// The first character is completed by the next chunk, the tails
+			// of the last chunks are kept until the stream ends.
+			for _, out := range []struct {
+				kind string
+				data []byte
+			}{
+				{streamKindStdout, world[:4]},
+				{streamKindStdout, world[4:]},
+				{streamKindStdout, world[:1]},
+				{streamKindStderr, world[:2]},
+			} {
+				if err := s.output(out.kind, out.data); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tc.end(s); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, event := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+				kind, data, _ := strings.Cut(strings.TrimPrefix(event, "event: "), "\ndata: ")
+				if kind == streamKindStdout || kind == streamKindStderr {
+					_, message, _ := strings.Cut(data, `"Message":"`)
+					message, _, _ = strings.Cut(message, `"`)
+					kind += ":" + message
+				}
+				got = append(got, kind)
+			}
+
+			if strings.Join(got, "|") != tc.want {
+				t.Errorf("events = %s, want %s", strings.Join(got, "|"), tc.want)
+			}
+		})
+	}
+}
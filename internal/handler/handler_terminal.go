@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// terminalSessionTTL is how long a created terminal session waits for its WebSocket.
+	terminalSessionTTL = time.Minute
+
+	maxTerminalMessageSize = 64 * 1024
+	terminalWriteTimeout   = 10 * time.Second
+)
+
+// terminalSession is a checked submission waiting for the WebSocket which runs it.
+type terminalSession struct {
+	req api.SubmissionRequest
+	// ctx keeps the values of the creating request for the quota accounting.
+	ctx       context.Context
+	expiresAt time.Time
+}
+
+// terminalSessions holds the created sessions until they're connected or expire.
+type terminalSessions struct {
+	mu       sync.Mutex
+	sessions map[string]terminalSession
+}
+
+func newTerminalSessions() *terminalSessions {
+	return &terminalSessions{sessions: make(map[string]terminalSession)}
+}
+
+// add stores the session and returns its ID. Expired sessions are dropped.
+func (s *terminalSessions) add(sess terminalSession) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	id := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, v := range s.sessions {
+		if now.After(v.expiresAt) {
+			delete(s.sessions, k)
+		}
+	}
+	s.sessions[id] = sess
+
+	return id
+}
+
+// take removes the session, it's returned if it hasn't expired.
+func (s *terminalSessions) take(id string) (terminalSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	delete(s.sessions, id)
+
+	return sess, ok && time.Now().Before(sess.expiresAt)
+}
+
+// CreateTerminalHandler checks a files submission like /run and creates a
+// terminal session for it, which is started by connecting its WebSocket.
+func (h *Handler) CreateTerminalHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeSubmissionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Only pre-run hooks are invoked, like for streamed runs.
+	if _, ok = h.preRun(w, r, req); !ok {
+		return
+	}
+
+	expiresAt := time.Now().Add(terminalSessionTTL).UTC()
+	id := h.terminals.add(terminalSession{
+		req:       *req,
+		ctx:       context.WithoutCancel(r.Context()),
+		expiresAt: expiresAt,
+	})
+
+	writeJSONResponse(w, api.TerminalSession{Id: id, ExpiresAt: expiresAt}, http.StatusCreated)
+}
+
+// ConnectTerminalHandler connects the WebSocket of a terminal session to the
+// terminal of a sandbox backend serving its template and passes the messages
+// through. Failures before the upgrade are answered with problem details.
+func (h *Handler) ConnectTerminalHandler(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.terminals.take(chi.URLParam(r, "id"))
+	if !ok {
+		writeProblem(w, http.StatusNotFound, api.TerminalSessionNotFound, "terminal session not found or expired")
+		return
+	}
+
+	sreq, err := sandboxRequest(sess.req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return originAllowed(h.Config.Cors.AllowOrigins, r.Header.Get("Origin"))
+		},
+	}
+
+	err = h.Backends.Do(r.Context(), sess.req.TemplateId, func(b *backend.Backend) error {
+		sandbox, dErr := dialTerminal(r.Context(), b.URL, *sreq)
+		if dErr != nil {
+			return dErr
+		}
+		defer sandbox.Close()
+
+		conn, uErr := upgrader.Upgrade(w, r, nil)
+		if uErr != nil {
+			// The upgrader has already answered the client.
+			log.Errorf("upgrade terminal: %v", uErr)
+			return nil
+		}
+		defer conn.Close()
+
+		h.proxyTerminal(sess.ctx, conn, sandbox)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+// dialTerminal connects to the terminal of the sandbox backend and sends it
+// the request. A rejected handshake is returned as the problem of the sandbox.
+func dialTerminal(ctx context.Context, backendURL string, sreq api.SandboxRequest) (*websocket.Conn, error) {
+	u, err := url.Parse(backendURL + "/run/terminal")
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend url: %w", err)
+	}
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return nil, sandboxProblem(resp)
+		}
+
+		return nil, err
+	}
+
+	if err = conn.WriteJSON(sreq); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("send terminal request: %w", err)
+	}
+
+	return conn, nil
+}
+
+// proxyTerminal passes the messages between the client and the sandbox until
+// one of them closes the connection. The input and control messages of the
+// client are passed on as they are, the events of the sandbox are translated
+// to TerminalEvent messages. The output is binary and passed on as it is.
+func (h *Handler) proxyTerminal(ctx context.Context, conn, sandbox *websocket.Conn) {
+	conn.SetReadLimit(maxTerminalMessageSize)
+
+	// Closing the connection to the sandbox stops the run when the client leaves.
+	go func() {
+		defer sandbox.Close()
+
+		for {
+			kind, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err = sandbox.WriteMessage(kind, data); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(kind int, data []byte) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(terminalWriteTimeout))
+		return conn.WriteMessage(kind, data)
+	}
+	sendEvent := func(ev api.TerminalEvent) error {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+
+		return send(websocket.TextMessage, body)
+	}
+
+	// The session ends with the done event or with the problem which kept
+	// the sandbox from running the submission.
+	ended := false
+
+	for {
+		kind, data, err := sandbox.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		if kind != websocket.TextMessage {
+			if err = send(kind, data); err != nil {
+				return
+			}
+			continue
+		}
+
+		var ev api.SandboxStreamEvent
+		if err = json.Unmarshal(data, &ev); err != nil {
+			log.Errorf("terminal event from backend: %v", err)
+			continue
+		}
+
+		if ev.Kind == streamKindDone && ev.RunEnvironment != nil {
+			h.chargeQuota(ctx, *ev.RunEnvironment)
+		}
+		ended = ended || ev.Kind == streamKindDone || ev.Problem != nil
+
+		if err = sendEvent(terminalEvent(ev)); err != nil {
+			return
+		}
+	}
+
+	if !ended {
+		_ = sendEvent(terminalEvent(api.SandboxStreamEvent{
+			Kind: streamKindError,
+			Data: []byte("terminal closed by the sandbox"),
+		}))
+		_ = sendEvent(terminalEvent(api.SandboxStreamEvent{Kind: streamKindDone}))
+	}
+
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(terminalWriteTimeout))
+}
+
+// terminalEvent returns the message sent to the client for an event of the sandbox.
+func terminalEvent(ev api.SandboxStreamEvent) api.TerminalEvent {
+	res := api.TerminalEvent{
+		Kind:    ev.Kind,
+		Problem: ev.Problem,
+	}
+
+	if ev.Kind == streamKindDone {
+		done := streamDoneEvent(ev)
+		res.Result = &done
+		return res
+	}
+
+	msg := string(sanitize(ev.Data))
+	res.Message = &msg
+
+	return res
+}
+
+// originAllowed reports whether a browser on the origin may open a terminal,
+// like the CORS configuration allows its requests. The patterns may contain
+// one wildcard. Requests without an origin don't come from a browser.
+func originAllowed(patterns []string, origin string) bool {
+	if origin == "" {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		if !wildcard && pattern == origin {
+			return true
+		}
+		if wildcard && len(origin) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
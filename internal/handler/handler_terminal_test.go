@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/gorilla/websocket"
+)
+
+func TestTerminalSessions(t *testing.T) {
+	sessions := newTerminalSessions()
+
+	id := sessions.add(terminalSession{
+		req:       api.SubmissionRequest{TemplateId: "golang_1_23"},
+		expiresAt: time.Now().Add(time.Minute),
+	})
+
+	sess, ok := sessions.take(id)
+	if !ok || sess.req.TemplateId != "golang_1_23" {
+		t.Fatalf("take = %v, %v, want the session", sess, ok)
+	}
+	if _, ok = sessions.take(id); ok {
+		t.Error("session taken twice")
+	}
+
+	expired := sessions.add(terminalSession{expiresAt: time.Now().Add(-time.Second)})
+	if _, ok = sessions.take(expired); ok {
+		t.Error("expired session taken")
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		patterns []string
+		origin   string
+		want     bool
+	}{
+		{"no-origin", []string{"https://play.example.com"}, "", true},
+		{"any", []string{"*"}, "https://evil.example.org", true},
+		{"exact", []string{"https://play.example.com"}, "https://play.example.com", true},
+		{"case", []string{"https://Play.example.com"}, "https://play.EXAMPLE.com", true},
+		{"other", []string{"https://play.example.com"}, "https://evil.example.org", false},
+		{"wildcard", []string{"https://*.example.com"}, "https://play.example.com", true},
+		{"wildcard-other", []string{"https://*.example.com"}, "https://example.org", false},
+		{"none", nil, "https://play.example.com", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := originAllowed(tc.patterns, tc.origin); got != tc.want {
+				t.Errorf("originAllowed(%v, %q) = %v, want %v", tc.patterns, tc.origin, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProxyTerminal(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	// The sandbox echoes the input and ends the session on "exit".
+	sandbox := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req api.SandboxRequest
+		if err = conn.ReadJSON(&req); err != nil || req.SandId != "python_3" {
+			t.Errorf("sandbox request = %+v, %v", req, err)
+			return
+		}
+
+		_ = conn.WriteJSON(api.SandboxStreamEvent{Kind: streamKindPhase, Data: []byte("run")})
+		for {
+			kind, data, rErr := conn.ReadMessage()
+			if rErr != nil {
+				return
+			}
+			if kind == websocket.TextMessage {
+				continue
+			}
+			if string(data) == "exit" {
+				break
+			}
+			_ = conn.WriteMessage(websocket.BinaryMessage, data)
+		}
+
+		_ = conn.WriteJSON(api.SandboxStreamEvent{
+			Kind:           streamKindDone,
+			RunEnvironment: &api.RunEnvironment{RunCmd: "python3 main.py"},
+			Result:         &api.ExecutionResult{Status: api.ExecutionStatusOk},
+		})
+	}))
+	defer sandbox.Close()
+
+	h := &Handler{}
+	playground := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendConn, err := dialTerminal(r.Context(), sandbox.URL, api.SandboxRequest{SandId: "python_3"})
+		if err != nil {
+			t.Errorf("dial sandbox: %v", err)
+			return
+		}
+		defer backendConn.Close()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		h.proxyTerminal(context.Background(), conn, backendConn)
+	}))
+	defer playground.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(playground.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial playground: %v", err)
+	}
+	defer conn.Close()
+
+	readEvent := func() api.TerminalEvent {
+		var ev api.TerminalEvent
+		if err = conn.ReadJSON(&ev); err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		return ev
+	}
+
+	if ev := readEvent(); ev.Kind != streamKindPhase || ev.Message == nil || *ev.Message != "run" {
+		t.Errorf("phase event = %+v", ev)
+	}
+
+	if err = conn.WriteMessage(websocket.BinaryMessage, []byte("hello\r")); err != nil {
+		t.Fatal(err)
+	}
+	kind, data, err := conn.ReadMessage()
+	if err != nil || kind != websocket.BinaryMessage || string(data) != "hello\r" {
+		t.Errorf("output = %d %q %v, want the echoed input", kind, data, err)
+	}
+
+	if err = conn.WriteMessage(websocket.BinaryMessage, []byte("exit")); err != nil {
+		t.Fatal(err)
+	}
+
+	done := readEvent()
+	if done.Kind != streamKindDone || done.Result == nil {
+		t.Fatalf("done event = %+v", done)
+	}
+	if done.Result.Status != api.ExecutionStatusOk || done.Result.RunCmd != "python3 main.py" {
+		t.Errorf("result = %+v", *done.Result)
+	}
+}
+
+func TestDialTerminalProblem(t *testing.T) {
+	sandbox := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", problemContentType)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"type":"urn:codenire:problem:sandbox_saturated","title":"Service Unavailable","status":503,"code":"sandbox_saturated"}`))
+	}))
+	defer sandbox.Close()
+
+	_, err := dialTerminal(context.Background(), sandbox.URL, api.SandboxRequest{})
+	if p := toProblem(err); p.problem.Code != api.SandboxSaturated {
+		t.Errorf("problem = %+v, want sandbox_saturated", p.problem)
+	}
+}
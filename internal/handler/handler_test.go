@@ -1,10 +1,19 @@
 package handler
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
 )
 
 func TestCopyFilesToTmpDir_PathTraversal_ShouldBeBlocked(t *testing.T) {
@@ -30,7 +39,7 @@ func TestCopyFilesToTmpDir_PathTraversal_ShouldBeBlocked(t *testing.T) {
 				tc.filename: "escaped content",
 			}
 
-			err = copyFilesToTmpDir(subTmpDir, files)
+			err = copyFilesToTmpDir(subTmpDir, files, nil)
 
 			if err == nil {
 				t.Errorf("expected error for path traversal attempt %q, got nil", tc.filename)
@@ -60,7 +69,7 @@ func TestCopyFilesToTmpDir_ValidPaths(t *testing.T) {
 		"src/lib/helper.go": "package lib",
 	}
 
-	err = copyFilesToTmpDir(tmpDir, validFiles)
+	err = copyFilesToTmpDir(tmpDir, validFiles, nil)
 	if err != nil {
 		t.Fatalf("copyFilesToTmpDir failed for valid paths: %v", err)
 	}
@@ -77,3 +86,110 @@ func TestCopyFilesToTmpDir_ValidPaths(t *testing.T) {
 		}
 	}
 }
+
+func TestCopyFilesToTmpDir_BinaryAndMode(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, '\r', '\n'}
+	encoding := api.Base64
+	mode := 0755
+	files := map[string]string{
+		"img.png": base64.StdEncoding.EncodeToString(binary),
+		"run.sh":  "#!/bin/sh\necho hi\n",
+	}
+	meta := map[string]api.FileMeta{
+		"img.png": {Encoding: &encoding},
+		"run.sh":  {Mode: &mode},
+	}
+
+	if err := copyFilesToTmpDir(tmpDir, files, &meta); err != nil {
+		t.Fatalf("copyFilesToTmpDir: %v", err)
+	}
+
+	encoded, err := tarToBase64(tmpDir)
+	if err != nil {
+		t.Fatalf("tarToBase64: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("decode tar: %v", err)
+	}
+
+	got := make(map[string]*tar.Header)
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		got[hdr.Name] = hdr
+		contents[hdr.Name], _ = io.ReadAll(tr)
+	}
+
+	if !bytes.Equal(contents["img.png"], binary) {
+		t.Errorf("img.png = %v, want %v", contents["img.png"], binary)
+	}
+	if hdr := got["run.sh"]; hdr == nil || hdr.Mode&0777 != 0755 {
+		t.Errorf("run.sh header = %+v, want mode 0755", hdr)
+	}
+	if hdr := got["img.png"]; hdr == nil || hdr.Mode&0777 != 0644 {
+		t.Errorf("img.png header = %+v, want mode 0644", hdr)
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	encoding := api.Base64
+	meta := map[string]api.FileMeta{"a.bin": {Encoding: &encoding}}
+
+	if err := checkFiles(map[string]string{"a.bin": "AAEC"}, &meta); err != nil {
+		t.Errorf("valid files: %v", err)
+	}
+	if err := checkFiles(map[string]string{"a.bin": "not base64!"}, &meta); err == nil {
+		t.Errorf("invalid base64 must be rejected")
+	}
+	if err := checkFiles(map[string]string{"b.txt": ""}, &meta); err == nil {
+		t.Errorf("meta of a missing file must be rejected")
+	}
+
+	large := strings.Repeat("x", int(MaxFileSize)+1)
+	if err := checkFiles(map[string]string{"a.txt": large}, nil); err == nil {
+		t.Errorf("too large file must be rejected")
+	}
+}
+
+func TestDecodeFilesRequest(t *testing.T) {
+	encoding := api.Base64
+	binary := bytes.Repeat([]byte{0xff, 0x00, 0x7f}, int(MaxFileSize)/3)
+
+	body, err := json.Marshal(api.SubmissionRequest{
+		TemplateId: "python_3",
+		Files:      map[string]string{"data.bin": base64.StdEncoding.EncodeToString(binary), "main.py": "print(1)"},
+		FilesMeta:  &map[string]api.FileMeta{"data.bin": {Encoding: &encoding}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req api.SubmissionRequest
+	w := httptest.NewRecorder()
+	if !decodeFilesRequest(w, httptest.NewRequest(http.MethodPost, "/run", bytes.NewReader(body)), &req) {
+		t.Fatalf("file of %d bytes rejected: %s", len(binary), w.Body)
+	}
+	if err = checkFiles(req.Files, req.FilesMeta); err != nil {
+		t.Errorf("checkFiles: %v", err)
+	}
+
+	large := strings.Repeat("x", int(filesRequestSize()))
+	body = []byte(`{"TemplateId":"python_3","Files":{"main.py":"` + large + `"}}`)
+	w = httptest.NewRecorder()
+	if decodeFilesRequest(w, httptest.NewRequest(http.MethodPost, "/run", bytes.NewReader(body)), &req) {
+		t.Fatal("too large request accepted")
+	}
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), string(api.RequestTooLarge)) {
+		t.Errorf("response = %d %s, want request_too_large", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/hooks"
+)
+
+// setupHooks returns the hook handlers enabled by the config.
+func setupHooks(config *Config) ([]hooks.HookHandler, error) {
+	var handlers []hooks.HookHandler
+
+	if config.FileHooksDir != "" {
+		handlers = append(handlers, hooks.FileHook{Directory: config.FileHooksDir})
+	}
+
+	if config.PluginHookPath != "" {
+		handlers = append(handlers, &hooks.PluginHook{Endpoint: config.PluginHookPath})
+	}
+
+	for _, hh := range handlers {
+		if err := hh.Setup(); err != nil {
+			return nil, err
+		}
+	}
+
+	return handlers, nil
+}
+
+// preRun passes the submission to the pre-run hooks, which may rewrite req.
+// It returns the annotations collected from the hooks. If a hook rejects the
+// run or fails, the response is written to w and false is returned.
+func (h *Handler) preRun(w http.ResponseWriter, r *http.Request, req *api.SubmissionRequest) (map[string]string, bool) {
+	annotations := make(map[string]string)
+
+	for _, hh := range h.Hooks {
+		res, err := hh.InvokeHook(r.Context(), hooks.HookEvent{
+			Type:        hooks.HookPreRun,
+			Request:     *req,
+			HTTPRequest: hooks.NewHTTPRequest(r),
+		})
+		if err != nil {
+			log.Errorf("pre-run hook: %v", err)
+			writeProblem(w, http.StatusInternalServerError, api.HookFailed, "pre-run hook failed")
+			return nil, false
+		}
+
+		if res.RejectRun {
+			status := res.HTTPResponse.StatusCode
+			if status == 0 {
+				status = http.StatusForbidden
+			}
+
+			body := res.HTTPResponse.Body
+			if body == "" {
+				body = "run rejected by hook"
+			}
+
+			writeProblem(w, status, api.RejectedByHook, body)
+			return nil, false
+		}
+
+		res.ChangeRequest.Apply(req)
+
+		for k, v := range res.Annotations {
+			annotations[k] = v
+		}
+	}
+
+	return annotations, true
+}
+
+// postRun passes the result to the post-run hooks and adds the annotations of
+// the pre-run and post-run hooks to res. Hook failures are only logged, the
+// program has already been run at this point.
+func (h *Handler) postRun(
+	ctx context.Context,
+	httpReq hooks.HTTPRequest,
+	req api.SubmissionRequest,
+	res *api.SubmissionResponse,
+	annotations map[string]string,
+) {
+	for _, hh := range h.Hooks {
+		hookRes, err := hh.InvokeHook(ctx, hooks.HookEvent{
+			Type:        hooks.HookPostRun,
+			Request:     req,
+			Response:    res,
+			HTTPRequest: httpReq,
+		})
+		if err != nil {
+			log.Errorf("post-run hook: %v", err)
+			continue
+		}
+
+		for k, v := range hookRes.Annotations {
+			annotations[k] = v
+		}
+	}
+
+	if len(annotations) > 0 {
+		res.Annotations = &annotations
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/quota"
+	"github.com/go-chi/httprate"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// Claims overriding the configured daily quotas of a user.
+const (
+	claimQuotaRuns       = "quota_runs_per_day"
+	claimQuotaCPUSeconds = "quota_cpu_seconds_per_day"
+)
+
+type quotaKeyCtx struct{}
+
+// userKey identifies the user of the request for rate limits and quotas:
+// the value of the configured claim of a verified token, or the client IP
+// for anonymous requests.
+func (h *Handler) userKey(r *http.Request) (string, error) {
+	if claims := verifiedClaims(r.Context()); claims != nil && h.Config.RateLimitClaim != "" {
+		if v, ok := claims[h.Config.RateLimitClaim]; ok && v != nil {
+			return fmt.Sprint(v), nil
+		}
+	}
+
+	ip, err := httprate.KeyByRealIP(r)
+	if err != nil {
+		return "", err
+	}
+
+	return "ip:" + ip, nil
+}
+
+// verifiedClaims returns the claims of the token verified by jwtauth.Verifier,
+// or nil if the request has no valid token.
+func verifiedClaims(ctx context.Context) map[string]interface{} {
+	token, claims, err := jwtauth.FromContext(ctx)
+	if token == nil || err != nil {
+		return nil
+	}
+
+	return claims
+}
+
+// enforceQuota counts the request as a run of the user and rejects it with
+// 429 when the daily quota is used up. The run is refunded when the request
+// is rejected with a client error, like an invalid body or an unknown template.
+func (h *Handler) enforceQuota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := h.userKey(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		limits := h.Quotas.Limits(key, claimLimits(verifiedClaims(r.Context())))
+		if limits.Unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		usage, ok := h.Quotas.TakeRun(key, limits)
+		reset := h.Quotas.Reset()
+		setQuotaHeaders(w.Header(), usage, limits, reset)
+
+		if !ok {
+			retryAfter := int(math.Ceil(time.Until(reset).Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeProblem(w, http.StatusTooManyRequests, api.QuotaExceeded, "daily quota exceeded")
+			return
+		}
+
+		qw := &quotaResponseWriter{ResponseWriter: w, refund: func() {
+			setQuotaHeaders(w.Header(), h.Quotas.RefundRun(key), limits, reset)
+		}}
+
+		next.ServeHTTP(qw, r.WithContext(context.WithValue(r.Context(), quotaKeyCtx{}, key)))
+	})
+}
+
+// quotaResponseWriter calls refund before a client error status is written.
+type quotaResponseWriter struct {
+	http.ResponseWriter
+	refund      func()
+	wroteHeader bool
+}
+
+func (w *quotaResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status >= 400 && status < 500 {
+			w.refund()
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *quotaResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush is needed by the streamed runs.
+func (w *quotaResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *quotaResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// chargeQuota adds the CPU time of a finished run to the quota usage
+// of the user who submitted it.
+func (h *Handler) chargeQuota(ctx context.Context, env api.RunEnvironment) {
+	key, ok := ctx.Value(quotaKeyCtx{}).(string)
+	if !ok || h.Quotas == nil {
+		return
+	}
+
+	h.Quotas.AddCPU(key, cpuSeconds(env))
+}
+
+// cpuSeconds returns the CPU time of the compile and run commands. The
+// wall-clock time is charged for a command whose usage the sandbox couldn't measure.
+func cpuSeconds(env api.RunEnvironment) float64 {
+	phase := func(usage *api.ResourceUsage, wall float32) float64 {
+		if usage == nil {
+			return float64(wall)
+		}
+
+		return float64(usage.CPUUserTime + usage.CPUSystemTime)
+	}
+
+	return phase(env.CompileUsage, env.CompileTime) + phase(env.RunUsage, env.RunTime)
+}
+
+func setQuotaHeaders(header http.Header, usage quota.Usage, limits quota.Limits, reset time.Time) {
+	runs, cpuSeconds := usage.Remaining(limits)
+
+	if limits.RunsPerDay > 0 {
+		header.Set("X-Quota-Runs-Limit", strconv.Itoa(limits.RunsPerDay))
+		header.Set("X-Quota-Runs-Remaining", strconv.Itoa(runs))
+	}
+
+	if limits.CPUSecondsPerDay > 0 {
+		header.Set("X-Quota-CPU-Limit", strconv.FormatFloat(limits.CPUSecondsPerDay, 'f', -1, 64))
+		header.Set("X-Quota-CPU-Remaining", strconv.FormatFloat(cpuSeconds, 'f', 3, 64))
+	}
+
+	header.Set("X-Quota-Reset", strconv.FormatInt(reset.Unix(), 10))
+}
+
+// claimLimits reads the quota overrides from the token claims.
+func claimLimits(claims map[string]interface{}) quota.Limits {
+	return quota.Limits{
+		RunsPerDay:       int(claimNumber(claims[claimQuotaRuns])),
+		CPUSecondsPerDay: claimNumber(claims[claimQuotaCPUSeconds]),
+	}
+}
+
+func claimNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+
+	return 0
+}
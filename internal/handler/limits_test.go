@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/quota"
+)
+
+func TestCPUSeconds(t *testing.T) {
+	env := api.RunEnvironment{
+		CompileTime: 2,
+		RunTime:     3,
+		RunUsage:    &api.ResourceUsage{CPUUserTime: 0.5, CPUSystemTime: 0.25},
+	}
+
+	// The compile usage is missing, so its wall-clock time is charged.
+	if got := cpuSeconds(env); got != 2.75 {
+		t.Errorf("cpuSeconds = %v, want 2.75", got)
+	}
+}
+
+func TestEnforceQuota(t *testing.T) {
+	h := &Handler{
+		Config: &Config{},
+		Quotas: quota.NewTracker(quota.Limits{RunsPerDay: 1}, nil),
+	}
+
+	status := http.StatusBadRequest
+	next := h.enforceQuota(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if status != http.StatusOK {
+			writeProblem(w, status, api.TemplateNotFound, "")
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	run := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/run", nil))
+		return rec
+	}
+
+	// Rejected requests don't use up the quota.
+	for i := 0; i < 3; i++ {
+		rec := run()
+		if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Quota-Runs-Remaining") != "1" {
+			t.Fatalf("rejected request %d = %d, remaining %s", i, rec.Code, rec.Header().Get("X-Quota-Runs-Remaining"))
+		}
+	}
+
+	status = http.StatusOK
+	if rec := run(); rec.Code != http.StatusOK || rec.Header().Get("X-Quota-Runs-Remaining") != "0" {
+		t.Fatalf("run = %d, remaining %s", rec.Code, rec.Header().Get("X-Quota-Runs-Remaining"))
+	}
+	if rec := run(); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("run over the quota = %d, want 429", rec.Code)
+	}
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/docs"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/go-chi/chi/v5"
+)
+
+// apiServer implements the server interface generated from api.yaml with the
+// handlers of the playground, so every operation of the spec has a handler.
+// The handlers read the path parameters from the route context themselves.
+type apiServer struct {
+	h *Handler
+}
+
+var _ api.ServerInterface = apiServer{}
+
+func (s apiServer) RefreshActionList(w http.ResponseWriter, r *http.Request) {
+	s.h.ActionListHandler(w, r)
+}
+
+func (s apiServer) GetActionList(w http.ResponseWriter, r *http.Request) {
+	s.h.ActionListHandler(w, r)
+}
+
+func (s apiServer) ActionEvents(w http.ResponseWriter, r *http.Request) {
+	s.h.ActionEventsHandler(w, r)
+}
+
+func (s apiServer) RefreshTemplates(w http.ResponseWriter, r *http.Request) {
+	s.h.RefreshTemplatesHandler(w, r)
+}
+
+func (s apiServer) FormatCode(w http.ResponseWriter, r *http.Request) {
+	s.h.FormatHandler(w, r)
+}
+
+func (s apiServer) CreateSubmissionJob(w http.ResponseWriter, r *http.Request) {
+	s.h.CreateJobHandler(w, r)
+}
+
+func (s apiServer) CancelSubmissionJob(w http.ResponseWriter, r *http.Request, _ string) {
+	s.h.CancelJobHandler(w, r)
+}
+
+func (s apiServer) GetSubmissionJob(w http.ResponseWriter, r *http.Request, _ string) {
+	s.h.GetJobHandler(w, r)
+}
+
+func (s apiServer) JudgeSubmission(w http.ResponseWriter, r *http.Request) {
+	s.h.JudgeHandler(w, r)
+}
+
+func (s apiServer) GetSharedSubmission(w http.ResponseWriter, r *http.Request, _ string) {
+	s.h.GetSnippetHandler(w, r)
+}
+
+func (s apiServer) RunFilesSubmission(w http.ResponseWriter, r *http.Request) {
+	s.h.RunFilesHandler(w, r)
+}
+
+func (s apiServer) RunArchiveSubmission(w http.ResponseWriter, r *http.Request) {
+	s.h.RunArchiveHandler(w, r)
+}
+
+func (s apiServer) RunScriptSubmission(w http.ResponseWriter, r *http.Request) {
+	s.h.RunScriptHandler(w, r)
+}
+
+func (s apiServer) RunFilesSubmissionStream(w http.ResponseWriter, r *http.Request) {
+	s.h.RunStreamHandler(w, r)
+}
+
+func (s apiServer) CreateTerminalSession(w http.ResponseWriter, r *http.Request) {
+	s.h.CreateTerminalHandler(w, r)
+}
+
+func (s apiServer) ConnectTerminalSession(w http.ResponseWriter, r *http.Request, _ string) {
+	s.h.ConnectTerminalHandler(w, r)
+}
+
+func (s apiServer) ShareSubmission(w http.ResponseWriter, r *http.Request) {
+	s.h.ShareHandler(w, r)
+}
+
+// invalidParam reports path parameters which don't match the spec.
+func invalidParam(w http.ResponseWriter, _ *http.Request, err error) {
+	writeProblem(w, http.StatusBadRequest, api.InvalidRequest, "invalid request: "+err.Error())
+}
+
+// loadSpec returns the spec embedded in the generated code. Its server is
+// replaced by the playground itself, which is where requests are validated
+// and where the Swagger UI sends its requests.
+func loadSpec() (*openapi3.T, error) {
+	spec, err := api.GetSwagger()
+	if err != nil {
+		return nil, fmt.Errorf("load embedded spec: %w", err)
+	}
+	spec.Servers = openapi3.Servers{{URL: "/"}}
+
+	return spec, nil
+}
+
+// validateRequests checks the parameters and bodies of the requests of the
+// operations of the spec and rejects invalid ones as invalid_request. The
+// requests of routes which aren't in the spec are passed on.
+func validateRequests(spec *openapi3.T) (func(http.Handler) http.Handler, error) {
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("create spec router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		// Tokens are checked by the middlewares of the routes.
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		// The handlers apply the defaults, the body is passed on as it was sent.
+		SkipSettingDefaults: true,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The body is read for the validation, bound it by the largest
+			// request limit. The handlers check their own limit afterwards.
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize())
+
+			err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				maxBytesErr := new(http.MaxBytesError)
+				if errors.As(err, &maxBytesErr) {
+					writeProblem(w, http.StatusBadRequest, api.RequestTooLarge, fmt.Sprintf("request too large (max %d bytes)", maxBytesErr.Limit))
+					return
+				}
+
+				writeProblem(w, http.StatusBadRequest, api.InvalidRequest, validationDetail(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func maxRequestSize() int64 {
+	return max(filesRequestSize(), MaxScriptSnippetSize, MaxJudgeRequestSize, MaxArchiveSize+archiveFormOverhead)
+}
+
+// validationDetail describes a validation error without the schema dump of
+// its text.
+func validationDetail(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		// Errors of allOf and nested schemas are wrapped, report the innermost one.
+		for inner := new(openapi3.SchemaError); errors.As(schemaErr.Origin, &inner); {
+			schemaErr = inner
+		}
+
+		if field := strings.Join(schemaErr.JSONPointer(), "."); field != "" {
+			return fmt.Sprintf("invalid request: %s: %s", field, schemaErr.Reason)
+		}
+
+		return "invalid request: " + schemaErr.Reason
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.Parameter != nil {
+			return fmt.Sprintf("invalid request: parameter %s: %s", reqErr.Parameter.Name, reqErr.Reason)
+		}
+		if reqErr.Reason != "" {
+			return "invalid request: " + reqErr.Reason
+		}
+	}
+
+	return "invalid request: " + err.Error()
+}
+
+// serveSpec serves the spec as /openapi.json and the embedded Swagger UI
+// showing it at /docs.
+func serveSpec(router chi.Router, spec *openapi3.T) error {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("encode spec: %w", err)
+	}
+
+	ui, err := fs.Sub(docs.SwaggerUI, "api")
+	if err != nil {
+		return err
+	}
+
+	router.Get("/openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(specJSON)
+	})
+
+	router.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/", http.StatusMovedPermanently)
+	})
+	// The bundled initializer loads the published spec, this one the served spec.
+	router.Get("/docs/swagger-initializer.js", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		_, _ = w.Write([]byte(swaggerInitializer))
+	})
+	router.Handle("/docs/*", http.StripPrefix("/docs/", http.FileServer(http.FS(ui))))
+
+	return nil
+}
+
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
+`
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestValidateRequests(t *testing.T) {
+	spec, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validate, err := validateRequests(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler still gets the whole body after the validation.
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	})
+	h := validate(next)
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantDetail string
+	}{
+		{
+			name:       "valid",
+			method:     http.MethodPost,
+			path:       "/run",
+			body:       `{"TemplateId":"golang_1_23","Files":{"main.go":"package main"},"Args":"","Stdin":""}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing property",
+			method:     http.MethodPost,
+			path:       "/run",
+			body:       `{"TemplateId":"golang_1_23","Args":"","Stdin":""}`,
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "Files",
+		},
+		{
+			name:       "wrong type",
+			method:     http.MethodPost,
+			path:       "/judge",
+			body:       `{"TemplateId":"golang_1_23","Files":{},"Args":"","Stdin":"","Cases":"none"}`,
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "Cases",
+		},
+		{
+			name:       "not in spec",
+			method:     http.MethodGet,
+			path:       "/health",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body = ""
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != c.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, c.wantStatus, rec.Body.String())
+			}
+			if c.wantStatus == http.StatusOK && body != c.body {
+				t.Errorf("handler got body %q, want %q", body, c.body)
+			}
+			if c.wantDetail != "" {
+				got := rec.Body.String()
+				if !strings.Contains(got, `"code":"`+string(api.InvalidRequest)+`"`) || !strings.Contains(got, c.wantDetail) {
+					t.Errorf("body = %s, want invalid_request about %s", got, c.wantDetail)
+				}
+			}
+		})
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	spec, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := chi.NewRouter()
+	if err = serveSpec(router, spec); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path         string
+		wantStatus   int
+		wantContains string
+	}{
+		{"/openapi.json", http.StatusOK, `"operationId":"RunFilesSubmission"`},
+		{"/docs", http.StatusMovedPermanently, ""},
+		{"/docs/", http.StatusOK, "swagger-ui"},
+		{"/docs/swagger-initializer.js", http.StatusOK, `url: "/openapi.json"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+			if rec.Code != c.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, c.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), c.wantContains) {
+				t.Errorf("body doesn't contain %s", c.wantContains)
+			}
+		})
+	}
+}
@@ -88,6 +88,7 @@ func (r *Recorder) Events() ([]api.SubmissionResponseEvents, error) {
 		out = append(out, api.SubmissionResponseEvents{
 			Message: string(sanitize(e.msg)),
 			Kind:    e.kind,
+			Delay:   int64(delay),
 		})
 		if delay > 0 {
 			now = e.time
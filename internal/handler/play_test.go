@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// playback returns a write of a faketime program at epoch+at.
+func playback(at time.Duration, msg string) []byte {
+	header := make([]byte, 16)
+	copy(header, "\x00\x00PB")
+	//nolint:gosec
+	binary.BigEndian.PutUint64(header[4:], uint64(epoch.Add(at).UnixNano()))
+	//nolint:gosec
+	binary.BigEndian.PutUint32(header[12:], uint32(len(msg)))
+
+	return append(header, msg...)
+}
+
+func TestRecorderEventsDelay(t *testing.T) {
+	rec := new(Recorder)
+	_, _ = rec.Stdout().Write(playback(0, "3\n"))
+	_, _ = rec.Stdout().Write(playback(time.Second, "2\n"))
+	_, _ = rec.Stderr().Write(playback(1500*time.Millisecond, "warning\n"))
+	_, _ = rec.Stdout().Write(playback(2*time.Second, "1\n"))
+
+	events, err := rec.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		kind, msg string
+		delay     time.Duration
+	}{
+		{"stdout", "3\n", 0},
+		{"stdout", "2\n", time.Second},
+		{"stderr", "warning\n", 500 * time.Millisecond},
+		{"stdout", "1\n", 500 * time.Millisecond},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+
+	for i, w := range want {
+		e := events[i]
+		if e.Kind != w.kind || e.Message != w.msg || time.Duration(e.Delay) != w.delay {
+			t.Errorf("event %d = %s %q %v, want %s %q %v", i, e.Kind, e.Message, time.Duration(e.Delay), w.kind, w.msg, w.delay)
+		}
+	}
+}
+
+func TestRecorderEventsWithoutHeaders(t *testing.T) {
+	rec := new(Recorder)
+	_, _ = rec.Stdout().Write([]byte("hello\n"))
+
+	events, err := rec.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Message != "hello\n" || events[0].Delay != 0 {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
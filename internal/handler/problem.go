@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemError is an error which is reported to the client as RFC 7807
+// problem details with a stable code.
+type problemError struct {
+	problem    api.Problem
+	retryAfter string
+}
+
+func newProblem(status int, code api.ProblemCode, detail string) *problemError {
+	p := &problemError{problem: api.Problem{
+		Type:   "urn:codenire:problem:" + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+	}}
+	if detail != "" {
+		p.problem.Detail = &detail
+	}
+
+	return p
+}
+
+func (e *problemError) Error() string {
+	if e.problem.Detail != nil {
+		return *e.problem.Detail
+	}
+
+	return e.problem.Title
+}
+
+// writeProblem writes the problem details with the given status and code.
+func writeProblem(w http.ResponseWriter, status int, code api.ProblemCode, detail string) {
+	newProblem(status, code, detail).write(w)
+}
+
+func (e *problemError) write(w http.ResponseWriter) {
+	if e.retryAfter != "" {
+		w.Header().Set("Retry-After", e.retryAfter)
+	}
+
+	// The problem consists of strings and numbers only, it always encodes.
+	body, _ := json.Marshal(e.problem)
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.problem.Status)
+	_, _ = w.Write(body)
+}
+
+// writeError writes err as problem details. Problems reported by the sandbox
+// are passed on as they are, unreachable sandboxes are reported as
+// sandbox_unavailable and any other error as internal_error.
+func writeError(w http.ResponseWriter, err error) {
+	toProblem(err).write(w)
+}
+
+func toProblem(err error) *problemError {
+	var p *problemError
+	if errors.As(err, &p) {
+		return p
+	}
+
+	if errors.Is(err, backend.ErrNoBackend) || backend.IsConnError(err) {
+		return newProblem(http.StatusServiceUnavailable, api.SandboxUnavailable, err.Error())
+	}
+
+	return newProblem(http.StatusInternalServerError, api.InternalError, err.Error())
+}
+
+// sandboxProblem reads the error response of a sandbox backend. Problem
+// details are passed on to the client, any other response is reported as
+// sandbox_error.
+func sandboxProblem(resp *http.Response) *problemError {
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), problemContentType) {
+		var p api.Problem
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&p); err == nil && p.Code != "" {
+			return &problemError{problem: p, retryAfter: resp.Header.Get("Retry-After")}
+		}
+	}
+
+	return newProblem(
+		http.StatusBadGateway,
+		api.SandboxError,
+		fmt.Sprintf("unexpected http status from backend: %d", resp.StatusCode),
+	)
+}
+
+// methodNotAllowed and notFound replace the plain-text responses of the router.
+func methodNotAllowed(w http.ResponseWriter, _ *http.Request) {
+	writeProblem(w, http.StatusMethodNotAllowed, api.MethodNotAllowed, "")
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, http.StatusNotFound, api.NotFound, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+}
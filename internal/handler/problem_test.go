@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+)
+
+func TestSandboxProblem(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    api.ProblemCode
+	}{
+		{
+			name:        "problem",
+			contentType: problemContentType,
+			body:        `{"type":"urn:codenire:problem:sandbox_saturated","title":"Service Unavailable","status":503,"code":"sandbox_saturated"}`,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCode:    api.SandboxSaturated,
+		},
+		{
+			name:        "plain text",
+			contentType: "text/plain",
+			body:        "Invalid request",
+			wantStatus:  http.StatusBadGateway,
+			wantCode:    api.SandboxError,
+		},
+		{
+			name:        "broken problem",
+			contentType: problemContentType,
+			body:        `{"status":`,
+			wantStatus:  http.StatusBadGateway,
+			wantCode:    api.SandboxError,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Content-Type": {c.contentType}},
+				Body:       io.NopCloser(strings.NewReader(c.body)),
+			}
+
+			p := sandboxProblem(resp)
+			if p.problem.Status != c.wantStatus || p.problem.Code != c.wantCode {
+				t.Errorf("got %d %s, want %d %s", p.problem.Status, p.problem.Code, c.wantStatus, c.wantCode)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   api.ProblemCode
+	}{
+		{"problem", fmt.Errorf("run: %w", newProblem(http.StatusBadRequest, api.TemplateNotFound, "")), http.StatusBadRequest, api.TemplateNotFound},
+		{"no backend", fmt.Errorf("%w for template `go`", backend.ErrNoBackend), http.StatusServiceUnavailable, api.SandboxUnavailable},
+		{"other", io.ErrUnexpectedEOF, http.StatusInternalServerError, api.InternalError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeError(rec, c.err)
+
+			if rec.Code != c.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, c.wantStatus)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("content type = %q", ct)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, `"code":"`+string(c.wantCode)+`"`) {
+				t.Errorf("body = %s, want code %s", body, c.wantCode)
+			}
+		})
+	}
+}
@@ -6,12 +6,20 @@ package handler
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/codiewio/codenire/internal/backend"
+	"github.com/codiewio/codenire/internal/cache"
 	"github.com/codiewio/codenire/internal/client"
+	"github.com/codiewio/codenire/internal/images"
+	"github.com/codiewio/codenire/internal/jobs"
+	"github.com/codiewio/codenire/internal/quota"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -25,13 +33,62 @@ var log = newStdLogger()
 var JWTAuth *jwtauth.JWTAuth
 
 func NewServer(config *Config) (*http.Server, error) {
+	hookHandlers, err := setupHooks(config)
+	if err != nil {
+		return nil, fmt.Errorf("hooks setup failed: %w", err)
+	}
+
+	backends := backend.NewPool(config.BackendURLs)
+	backends.Check(context.Background())
+	go backends.Run(context.Background(), config.HealthCheckInterval)
+
+	templates := images.NewRegistry(func(_ context.Context) (*[]api.ImageConfig, error) {
+		return images.PullImageConfigList(backends.URLs()...)
+	})
+	if _, err = templates.Refresh(context.Background()); err != nil {
+		return nil, fmt.Errorf("load templates failed: %w", err)
+	}
+	go templates.Run(context.Background(), config.TemplatesRefreshInterval)
+
+	var quotaFile *quota.File
+	if config.QuotaFile != "" {
+		if quotaFile, err = quota.LoadFile(config.QuotaFile); err != nil {
+			return nil, err
+		}
+	}
+
+	snippetStore, err := setupSnippets(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("snippets setup failed: %w", err)
+	}
+
+	quotas := quota.NewTracker(quota.Limits{
+		RunsPerDay:       config.QuotaRunsPerDay,
+		CPUSecondsPerDay: config.QuotaCPUSecondsPerDay,
+	}, quotaFile)
+
 	handler := Handler{
-		Config: config,
+		Config:     config,
+		Backends:   backends,
+		Templates:  templates,
+		Hooks:      hookHandlers,
+		Quotas:     quotas,
+		Snippets:   snippetStore,
+		Jobs:       jobs.NewMemoryStore(config.JobTTL),
+		jobCancels: make(map[string]context.CancelFunc),
+		jobSem:     make(chan struct{}, config.JobsLimit),
+		terminals:  newTerminalSessions(),
+	}
+
+	if config.CacheSize > 0 {
+		handler.Cache = cache.New(config.CacheSize, config.CacheTTL)
 	}
 
 	JWTAuth = jwtauth.New("HS256", []byte(config.JWTSecretKey), nil)
 
 	router := chi.NewRouter()
+	router.NotFound(notFound)
+	router.MethodNotAllowed(methodNotAllowed)
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(cors.Handler(cors.Options{
@@ -45,57 +102,172 @@ func NewServer(config *Config) (*http.Server, error) {
 
 	router.Get("/", rootHandler)
 
+	spec, err := loadSpec()
+	if err != nil {
+		return nil, err
+	}
+	if err = serveSpec(router, spec); err != nil {
+		return nil, fmt.Errorf("serve spec failed: %w", err)
+	}
+
+	// The operations of the spec are routed through the generated wrapper,
+	// which binds the path parameters and validates the requests if enabled.
+	ops := &api.ServerInterfaceWrapper{
+		Handler:          apiServer{h: &handler},
+		ErrorHandlerFunc: invalidParam,
+	}
+	if config.ValidateRequests {
+		validate, vErr := validateRequests(spec)
+		if vErr != nil {
+			return nil, vErr
+		}
+		ops.HandlerMiddlewares = []api.MiddlewareFunc{validate}
+	}
+
+	if config.JWTSecretKey != "" {
+		// For debugging/example purposes, we generate and print in `--dev` mode
+		// a sample jwt token with claims `user_id:123` here:
+		if config.Dev {
+			_, tokenString, _ := JWTAuth.Encode(map[string]interface{}{"user_id": 123})
+			fmt.Printf("DEBUG: a sample jwt is %s\n\n", tokenString)
+		}
+
+		log.Printf("Enabled JWT handling")
+	}
+
+	authenticate := func(r chi.Router) {
+		if config.JWTSecretKey == "" {
+			return
+		}
+
+		r.Use(jwtauth.Verifier(JWTAuth))
+		r.Use(requireToken)
+	}
+
+	rateLimit := func() func(http.Handler) http.Handler {
+		return httprate.Limit(
+			config.RateLimitRequests,
+			config.RateLimitWindow,
+			httprate.WithKeyFuncs(handler.userKey),
+			httprate.WithLimitHandler(func(w http.ResponseWriter, _ *http.Request) {
+				writeProblem(w, http.StatusTooManyRequests, api.RateLimited, "rate limit exceeded")
+			}),
+			httprate.WithErrorHandler(func(w http.ResponseWriter, _ *http.Request, err error) {
+				writeError(w, err)
+			}),
+		)
+	}
+
+	// Runs and terminals share the slots of the throttle.
+	throttle := throttleBacklog(
+		config.ThrottleLimit,
+		config.ThrottleLimit+config.ThrottleLimit,
+		time.Second*60,
+	)
+
 	router.Group(func(r chi.Router) {
-		r.Use(httprate.LimitByRealIP(1, 3*time.Second))
-		r.Use(middleware.ThrottleBacklog(
-			config.ThrottleLimit,
-			config.ThrottleLimit+config.ThrottleLimit,
-			time.Second*60,
-		))
+		// The token is verified before the rate limit to key it on the user,
+		// rejecting unauthenticated requests is left to the inner group.
+		if config.JWTSecretKey != "" {
+			r.Use(jwtauth.Verifier(JWTAuth))
+		}
+		r.Use(rateLimit())
+		r.Use(throttle)
 
 		r.Group(func(in chi.Router) {
-			if config.JWTSecretKey != "" {
-				// For debugging/example purposes, we generate and print in `--dev` mode
-				// a sample jwt token with claims `user_id:123` here:
-				if config.Dev {
-					_, tokenString, _ := JWTAuth.Encode(map[string]interface{}{"user_id": 123})
-					fmt.Printf("DEBUG: a sample jwt is %s\n\n", tokenString)
-				}
-
-				in.Use(jwtauth.Verifier(JWTAuth))
-				in.Use(jwtauth.Authenticator(JWTAuth))
-
-				log.Printf("Enabled JWT handling")
-			}
+			authenticate(in)
+			in.Use(handler.enforceQuota)
 
 			in.Get("/run", handler.RunFilesHandler) // To avoid file-server handling
-			in.Post("/run", handler.RunFilesHandler)
+			in.Post("/run", ops.RunFilesSubmission)
+			in.Post("/run/stream", ops.RunFilesSubmissionStream)
+			in.Post("/run-archive", ops.RunArchiveSubmission)
+			in.Post("/run/terminal", ops.CreateTerminalSession)
 
 			in.Get("/run-script", handler.RunScriptHandler) // To avoid file-server handling
-			in.Post("/run-script", handler.RunScriptHandler)
+			in.Post("/run-script", ops.RunScriptSubmission)
+
+			in.Post("/jobs", ops.CreateSubmissionJob)
+			in.Post("/judge", ops.JudgeSubmission)
+		})
+
+		// Formatting doesn't run the submission, it isn't charged to the quota.
+		r.Group(func(in chi.Router) {
+			authenticate(in)
+
+			in.Post("/fmt", ops.FormatCode)
 		})
 
 		r.Group(func(r chi.Router) {
-			r.Get("/actions", handler.ActionListHandler)
+			r.Get("/actions", ops.RefreshActionList)
+			r.Post("/actions", ops.GetActionList)
 		})
 	})
 
+	// Browsers can't authenticate WebSockets, the session ID returned to the
+	// authenticated creator of the session is the credential. Creating the
+	// session was rate limited already, connecting right after it isn't.
+	// A terminal holds a throttle slot like a run until it ends.
+	router.Group(func(r chi.Router) {
+		r.Use(throttle)
+
+		r.Get("/run/terminal/{id}", ops.ConnectTerminalSession)
+	})
+
+	router.Get("/actions/events", ops.ActionEvents)
+
+	if handler.Snippets != nil {
+		router.Group(func(r chi.Router) {
+			authenticate(r)
+			r.Use(rateLimit())
+
+			r.Post("/share", ops.ShareSubmission)
+		})
+
+		// Shared links are public.
+		router.Get("/p/{id}", ops.GetSharedSubmission)
+	}
+
+	if config.AdminToken != "" {
+		router.Group(func(r chi.Router) {
+			r.Use(requireAdmin(config.AdminToken))
+
+			r.Post("/admin/templates/refresh", ops.RefreshTemplates)
+		})
+	}
+
+	// Job polling isn't throttled like runs, the jobs themselves are limited by JobsLimit.
+	router.Group(func(r chi.Router) {
+		authenticate(r)
+
+		r.Get("/jobs/{id}", ops.GetSubmissionJob)
+		r.Delete("/jobs/{id}", ops.CancelSubmissionJob)
+	})
+
+	// Metrics are served by one sandbox backend at a time,
+	// `?backend=<index>` selects it, by default it's the first healthy one.
 	router.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		backendURL, ok := metricsBackendURL(handler.Backends, r.URL.Query().Get("backend"))
+		if !ok {
+			writeProblem(w, http.StatusServiceUnavailable, api.SandboxUnavailable, "sandbox backend not available")
+			return
+		}
+
 		req, err := http.NewRequestWithContext(
 			context.Background(),
 			http.MethodGet,
-			handler.Config.BackendURL+"/metrics",
+			backendURL+"/metrics",
 			nil,
 		)
 
 		if err != nil {
-			http.Error(w, "sandbox client metrics request error", http.StatusInternalServerError)
+			writeProblem(w, http.StatusInternalServerError, api.InternalError, "sandbox client metrics request error")
 			return
 		}
 
 		resp, err := client.SandboxBackendClient().Do(req)
 		if err != nil {
-			http.Error(w, "Failed to fetch metrics from other service", http.StatusInternalServerError)
+			writeProblem(w, http.StatusBadGateway, api.SandboxUnavailable, "failed to fetch metrics from the sandbox")
 			return
 		}
 		defer func() {
@@ -105,7 +277,7 @@ func NewServer(config *Config) (*http.Server, error) {
 		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
 		_, err = io.Copy(w, resp.Body)
 		if err != nil {
-			http.Error(w, "Failed to write metrics to response", http.StatusInternalServerError)
+			log.Errorf("copy metrics: %v", err)
 			return
 		}
 	})
@@ -117,9 +289,63 @@ func NewServer(config *Config) (*http.Server, error) {
 	}, nil
 }
 
+// requireAdmin only passes requests with the admin token as bearer token.
+func requireAdmin(token string) func(http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := []byte(r.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(got, expected) != 1 {
+				writeProblem(w, http.StatusUnauthorized, api.Unauthorized, "admin token required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireToken replaces jwtauth.Authenticator, it rejects requests
+// without a token verified by jwtauth.Verifier.
+func requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, err := jwtauth.FromContext(r.Context())
+		if err != nil {
+			writeProblem(w, http.StatusUnauthorized, api.Unauthorized, err.Error())
+			return
+		}
+
+		if token == nil {
+			writeProblem(w, http.StatusUnauthorized, api.Unauthorized, "token required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func metricsBackendURL(pool *backend.Pool, index string) (string, bool) {
+	if index != "" {
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= len(pool.Backends()) {
+			return "", false
+		}
+
+		return pool.Backends()[i].URL, true
+	}
+
+	urls := pool.URLs()
+	if len(urls) == 0 {
+		return "", false
+	}
+
+	return urls[0], true
+}
+
 func rootHandler(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+		notFound(w, r)
 		return
 	}
 	_, _ = io.WriteString(w, "Hi from playground\n")
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer starts the playground with the default flags in front of a
+// fake sandbox serving the python_3 template, the routes of the sandbox are
+// added to the mux.
+func newTestServer(t *testing.T, sandbox *http.ServeMux, configure func(*Config)) *httptest.Server {
+	t.Helper()
+
+	sandbox.HandleFunc("GET /health", func(http.ResponseWriter, *http.Request) {})
+	sandbox.HandleFunc("GET /templates", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSONResponse(w, []api.ImageConfig{{
+			Template: "python_3",
+			Enabled:  true,
+			Actions: map[string]api.ImageActionConfig{
+				defaultAction: {Id: defaultAction, Name: defaultAction, IsDefault: true, RunCmd: "python3 main.py"},
+			},
+		}}, http.StatusOK)
+	})
+
+	backend := httptest.NewServer(sandbox)
+	t.Cleanup(backend.Close)
+
+	config := &Config{
+		BackendURLs:              []string{backend.URL},
+		HealthCheckInterval:      time.Hour,
+		TemplatesRefreshInterval: time.Hour,
+		ThrottleLimit:            10,
+		JobsLimit:                1,
+		JobTTL:                   time.Hour,
+		RateLimitRequests:        1,
+		RateLimitWindow:          3 * time.Second,
+		ValidateRequests:         true,
+		Cors:                     &DefaultCorsConfig,
+	}
+	if configure != nil {
+		configure(config)
+	}
+
+	srv, err := NewServer(config)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	playground := httptest.NewServer(srv.Handler)
+	t.Cleanup(playground.Close)
+
+	return playground
+}
+
+func TestServer_ConnectTerminalAfterCreate(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	sandbox := http.NewServeMux()
+	sandbox.HandleFunc("GET /run/terminal", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req api.SandboxRequest
+		if err = conn.ReadJSON(&req); err != nil {
+			return
+		}
+		_ = conn.WriteJSON(api.SandboxStreamEvent{
+			Kind:           streamKindDone,
+			RunEnvironment: &api.RunEnvironment{RunCmd: "python3 main.py"},
+			Result:         &api.ExecutionResult{Status: api.ExecutionStatusOk},
+		})
+	})
+	playground := newTestServer(t, sandbox, nil)
+
+	body := []byte(`{"TemplateId":"python_3","Files":{"main.py":"print(input())"},"Args":"","Stdin":""}`)
+	resp, err := http.Post(playground.URL+"/run/terminal", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var sess api.TerminalSession
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201", resp.StatusCode)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&sess); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rate limit of one request per window is used up by the creation.
+	u := "ws" + strings.TrimPrefix(playground.URL, "http") + "/run/terminal/" + sess.Id
+	conn, connResp, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		status := 0
+		if connResp != nil {
+			status = connResp.StatusCode
+		}
+		t.Fatalf("connect: %v (status %d)", err, status)
+	}
+	defer conn.Close()
+
+	var ev api.TerminalEvent
+	if err = conn.ReadJSON(&ev); err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	if ev.Kind != streamKindDone || ev.Result == nil || ev.Result.Status != api.ExecutionStatusOk {
+		t.Errorf("event = %+v, want the done event", ev)
+	}
+}
+
+func TestServer_ValidatesRequests(t *testing.T) {
+	playground := newTestServer(t, http.NewServeMux(), nil)
+
+	body := []byte(`{"templateId":"python_3","files":{"main.py":"print(1)"}}`)
+	resp, err := http.Post(playground.URL+"/run", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var p api.Problem
+	if err = json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || p.Code != api.InvalidRequest {
+		t.Errorf("response = %d %+v, want invalid_request", resp.StatusCode, p)
+	}
+}
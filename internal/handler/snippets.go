@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codiewio/codenire/internal/snippets"
+)
+
+// Snippet storage backends selectable with Config.SnippetStorage.
+const (
+	SnippetStorageFilesystem = "fs"
+	SnippetStorageS3         = "s3"
+	SnippetStoragePostgres   = "postgres"
+)
+
+// setupSnippets returns the snippet store enabled by the config, or nil if sharing is disabled.
+func setupSnippets(ctx context.Context, config *Config) (snippets.Store, error) {
+	switch config.SnippetStorage {
+	case "":
+		return nil, nil
+	case SnippetStorageFilesystem:
+		if config.SnippetDir == "" {
+			return nil, fmt.Errorf("snippet directory is required for %q storage", config.SnippetStorage)
+		}
+
+		return snippets.FileStore{Dir: config.SnippetDir}, nil
+	case SnippetStorageS3:
+		if config.SnippetS3Bucket == "" {
+			return nil, fmt.Errorf("s3 bucket is required for %q storage", config.SnippetStorage)
+		}
+
+		return snippets.NewS3Store(
+			config.SnippetS3Endpoint,
+			config.SnippetS3Region,
+			config.SnippetS3Bucket,
+			config.SnippetS3Prefix,
+		), nil
+	case SnippetStoragePostgres:
+		return snippets.NewPostgresStore(ctx, config.SnippetPostgresDSN)
+	}
+
+	return nil, fmt.Errorf("unknown snippet storage %q", config.SnippetStorage)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// throttleBacklog works like middleware.ThrottleBacklog of chi: at most limit
+// requests are processed at a time, up to backlogLimit more wait for a slot
+// until backlogTimeout. Rejected requests are answered with problem details.
+func throttleBacklog(limit, backlogLimit int, backlogTimeout time.Duration) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, limit)
+	backlog := make(chan struct{}, limit+backlogLimit)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case backlog <- struct{}{}:
+				defer func() { <-backlog }()
+			default:
+				w.Header().Set("Retry-After", "1")
+				writeProblem(w, http.StatusTooManyRequests, api.CapacityExceeded, "server capacity exceeded")
+				return
+			}
+
+			timer := time.NewTimer(backlogTimeout)
+			defer timer.Stop()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next.ServeHTTP(w, r)
+			case <-timer.C:
+				w.Header().Set("Retry-After", "1")
+				writeProblem(w, http.StatusTooManyRequests, api.CapacityExceeded, "timed out while waiting for a pending request to complete")
+			case <-r.Context().Done():
+				writeProblem(w, http.StatusTooManyRequests, api.CapacityExceeded, "context was canceled")
+			}
+		})
+	}
+}
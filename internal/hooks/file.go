@@ -0,0 +1,71 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// FileHook runs the executable named after the hook type (e.g. `pre-run`)
+// from Directory. The event is passed via stdin and the HookResponse is read
+// from stdout. A missing executable is not an error, the hook is just skipped.
+type FileHook struct {
+	Directory string
+}
+
+func (h FileHook) Setup() error {
+	info, err := os.Stat(h.Directory)
+	if err != nil {
+		return fmt.Errorf("hooks dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("hooks dir %s is not a directory", h.Directory)
+	}
+
+	return nil
+}
+
+func (h FileHook) InvokeHook(ctx context.Context, event HookEvent) (res HookResponse, err error) {
+	hookPath := filepath.Join(h.Directory, string(event.Type))
+	if _, err = os.Stat(hookPath); errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return res, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, hookPath)
+	cmd.Env = append(os.Environ(), "CODENIRE_HOOK_TYPE="+string(event.Type))
+	cmd.Dir = h.Directory
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = os.Stderr
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err = cmd.Run(); err != nil {
+		return res, fmt.Errorf("hook %s failed: %w", hookPath, err)
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+	if len(out) == 0 {
+		return res, nil
+	}
+
+	if err = json.Unmarshal(out, &res); err != nil {
+		return res, fmt.Errorf("hook %s returned invalid response: %w", hookPath, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,60 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestFileHook_InvokeHook(t *testing.T) {
+	dir := t.TempDir()
+
+	script := "#!/bin/sh\n" +
+		"cat > /dev/null\n" +
+		`echo '{"ChangeRequest":{"Files":{"main.go":"package main // patched","old.go":null},"Args":"-v"},"Annotations":{"policy":"ok"}}'` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, string(HookPreRun)), []byte(script), 0755); err != nil {
+		t.Fatalf("write hook: %v", err)
+	}
+
+	hook := FileHook{Directory: dir}
+	if err := hook.Setup(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	req := api.SubmissionRequest{
+		TemplateId: "golang_1_24",
+		Files: map[string]string{
+			"main.go": "package main",
+			"old.go":  "package main",
+		},
+	}
+
+	res, err := hook.InvokeHook(context.Background(), HookEvent{Type: HookPreRun, Request: req})
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+
+	res.ChangeRequest.Apply(&req)
+
+	if req.Files["main.go"] != "package main // patched" {
+		t.Errorf("main.go not rewritten: %q", req.Files["main.go"])
+	}
+	if _, ok := req.Files["old.go"]; ok {
+		t.Errorf("old.go should be removed")
+	}
+	if req.Args != "-v" {
+		t.Errorf("args: got %q, want %q", req.Args, "-v")
+	}
+	if res.Annotations["policy"] != "ok" {
+		t.Errorf("annotations: got %v", res.Annotations)
+	}
+
+	// Hooks without an executable are skipped.
+	res, err = hook.InvokeHook(context.Background(), HookEvent{Type: HookPostRun, Request: req})
+	if err != nil || res.RejectRun || len(res.Annotations) != 0 {
+		t.Errorf("missing hook should be a no-op, got %+v, %v", res, err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package hooks lets external programs inspect and change submissions.
+//
+// Before a submission is sent to the sandbox, the pre-run hooks receive the
+// SubmissionRequest and may reject it or rewrite its files, args and stdin.
+// After the run, the post-run hooks receive the request together with the
+// SubmissionResponse and may annotate the result.
+package hooks
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+type HookType string
+
+const (
+	HookPreRun  HookType = "pre-run"
+	HookPostRun HookType = "post-run"
+)
+
+// DefaultTimeout bounds the execution time of a single hook invocation.
+const DefaultTimeout = 10 * time.Second
+
+// HookEvent is the payload sent to hooks, encoded as JSON.
+type HookEvent struct {
+	Type        HookType
+	Request     api.SubmissionRequest
+	Response    *api.SubmissionResponse `json:",omitempty"`
+	HTTPRequest HTTPRequest
+}
+
+// HTTPRequest contains basic details of the HTTP request which started the run.
+type HTTPRequest struct {
+	Method     string
+	URI        string
+	RemoteAddr string
+	Header     http.Header
+}
+
+func NewHTTPRequest(r *http.Request) HTTPRequest {
+	return HTTPRequest{
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		RemoteAddr: r.RemoteAddr,
+		Header:     r.Header.Clone(),
+	}
+}
+
+// HookResponse is the answer of a hook, decoded from JSON.
+// An empty response lets the run proceed unchanged.
+type HookResponse struct {
+	// RejectRun stops the submission in a pre-run hook.
+	RejectRun bool
+	// HTTPResponse is sent to the client when the run is rejected.
+	HTTPResponse HTTPResponse
+	// ChangeRequest rewrites the submission in a pre-run hook.
+	ChangeRequest ChangeRequest
+	// Annotations are added to the SubmissionResponse.
+	Annotations map[string]string
+}
+
+type HTTPResponse struct {
+	StatusCode int
+	Body       string
+}
+
+// ChangeRequest describes changes of the submission. Files are merged into
+// the submission files, a null value removes the file.
+type ChangeRequest struct {
+	Files map[string]*string
+	Args  *string
+	Stdin *string
+}
+
+// Apply applies the changes to req.
+func (c ChangeRequest) Apply(req *api.SubmissionRequest) {
+	for name, content := range c.Files {
+		if content == nil {
+			delete(req.Files, name)
+			continue
+		}
+
+		if req.Files == nil {
+			req.Files = make(map[string]string)
+		}
+		req.Files[name] = *content
+	}
+
+	if c.Args != nil {
+		req.Args = *c.Args
+	}
+
+	if c.Stdin != nil {
+		req.Stdin = *c.Stdin
+	}
+}
+
+// HookHandler invokes hooks of one kind (scripts, plugin endpoint).
+type HookHandler interface {
+	Setup() error
+	InvokeHook(ctx context.Context, event HookEvent) (HookResponse, error)
+}
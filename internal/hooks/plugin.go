@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PluginHook sends the event as a POST request to Endpoint and reads the
+// HookResponse from the response body. Any status other than 2xx is an error.
+type PluginHook struct {
+	Endpoint string
+
+	client *http.Client
+}
+
+func (h *PluginHook) Setup() error {
+	h.client = &http.Client{Timeout: DefaultTimeout}
+
+	return nil
+}
+
+func (h *PluginHook) InvokeHook(ctx context.Context, event HookEvent) (res HookResponse, err error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return res, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return res, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Hook-Name", string(event.Type))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return res, fmt.Errorf("plugin hook request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return res, err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return res, fmt.Errorf("plugin hook responded with status %d: %s", resp.StatusCode, body)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return res, nil
+	}
+
+	if err = json.Unmarshal(body, &res); err != nil {
+		return res, fmt.Errorf("plugin hook returned invalid response: %w", err)
+	}
+
+	return res, nil
+}
@@ -3,6 +3,7 @@ package images
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,11 +13,48 @@ import (
 )
 
 var ExtendedTemplates []api.ImageConfig
-var ImageTemplateList *[]api.ImageConfig
 
 const templatesPath = "templates"
 
-func PullImageConfigList(url string) (res *[]api.ImageConfig, err error) {
+// PullImageConfigList loads the templates from the sandbox backends and merges
+// them into one list. When several backends serve a template, the config of
+// the first one is used. Unavailable backends are skipped unless none responds.
+func PullImageConfigList(urls ...string) (res *[]api.ImageConfig, err error) {
+	var merged []api.ImageConfig
+	seen := make(map[string]struct{})
+	pulled := false
+
+	for _, url := range urls {
+		list, pullErr := pullImageConfigList(url)
+		if pullErr != nil {
+			log.Printf("pull templates from %s failed: %v", url, pullErr)
+			err = pullErr
+			continue
+		}
+		pulled = true
+
+		for _, cfg := range list {
+			if _, ok := seen[cfg.Template]; ok {
+				continue
+			}
+			seen[cfg.Template] = struct{}{}
+			merged = append(merged, cfg)
+		}
+	}
+
+	if !pulled {
+		if err == nil {
+			err = errors.New("no sandbox backends")
+		}
+		return nil, err
+	}
+
+	merged = append(merged, ExtendedTemplates...)
+
+	return &merged, nil
+}
+
+func pullImageConfigList(url string) ([]api.ImageConfig, error) {
 	req, err := http.NewRequestWithContext(
 		context.Background(),
 		http.MethodGet,
@@ -34,25 +72,10 @@ func PullImageConfigList(url string) (res *[]api.ImageConfig, err error) {
 	}
 	defer resp.Body.Close()
 
-	log.Printf("response status: %v", resp)
-
 	var execRes []api.ImageConfig
 	if err = json.NewDecoder(resp.Body).Decode(&execRes); err != nil {
 		return nil, err
 	}
 
-	execRes = append(execRes, ExtendedTemplates...)
-
-	return &execRes, nil
-}
-
-func GetImageConfig(templateID string) *api.ImageConfig {
-	configs := *ImageTemplateList
-	for _, config := range configs {
-		if config.Template == templateID {
-			return &config
-		}
-	}
-
-	return nil
+	return execRes, nil
 }
@@ -0,0 +1,171 @@
+package images
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// Registry keeps the templates served by the sandbox backends. It's safe for
+// concurrent use, refreshes the list in the background and notifies
+// subscribers when templates appear, disappear or change.
+type Registry struct {
+	pull func(ctx context.Context) (*[]api.ImageConfig, error)
+
+	// refreshMu serializes the refreshes, so a list pulled earlier is never
+	// applied after a newer one.
+	refreshMu sync.Mutex
+
+	mu        sync.RWMutex
+	templates []api.ImageConfig
+	hashes    map[string]string
+	etag      string
+
+	subsMu sync.Mutex
+	subs   map[chan api.TemplateChange]struct{}
+}
+
+func NewRegistry(pull func(ctx context.Context) (*[]api.ImageConfig, error)) *Registry {
+	return &Registry{
+		pull:   pull,
+		hashes: make(map[string]string),
+		subs:   make(map[chan api.TemplateChange]struct{}),
+	}
+}
+
+// Get returns the config of the template or nil if it's unknown.
+func (r *Registry) Get(templateID string) *api.ImageConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, config := range r.templates {
+		if config.Template == templateID {
+			return &config
+		}
+	}
+
+	return nil
+}
+
+// List returns the templates sorted by name.
+func (r *Registry) List() []api.ImageConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]api.ImageConfig(nil), r.templates...)
+}
+
+// ETag identifies the current version of the template list.
+func (r *Registry) ETag() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.etag
+}
+
+// Refresh pulls the templates and returns the changes to the previous list.
+// On error the previous list is kept. Concurrent refreshes run one after another.
+func (r *Registry) Refresh(ctx context.Context) ([]api.TemplateChange, error) {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	list, err := r.pull(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := append([]api.ImageConfig(nil), *list...)
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Template < templates[j].Template
+	})
+
+	hashes := make(map[string]string, len(templates))
+	all := sha256.New()
+	for _, t := range templates {
+		b, _ := json.Marshal(t)
+		sum := sha256.Sum256(b)
+		hashes[t.Template] = hex.EncodeToString(sum[:])
+		all.Write(sum[:])
+	}
+
+	r.mu.Lock()
+	var changes []api.TemplateChange
+	for _, t := range templates {
+		prev, ok := r.hashes[t.Template]
+		switch {
+		case !ok:
+			changes = append(changes, api.TemplateChange{Kind: api.Added, Template: t.Template, Version: t.Version})
+		case prev != hashes[t.Template]:
+			changes = append(changes, api.TemplateChange{Kind: api.Changed, Template: t.Template, Version: t.Version})
+		}
+	}
+	for _, t := range r.templates {
+		if _, ok := hashes[t.Template]; !ok {
+			changes = append(changes, api.TemplateChange{Kind: api.Removed, Template: t.Template, Version: t.Version})
+		}
+	}
+
+	r.templates = templates
+	r.hashes = hashes
+	r.etag = `"` + hex.EncodeToString(all.Sum(nil))[:32] + `"`
+	r.mu.Unlock()
+
+	for _, c := range changes {
+		r.notify(c)
+	}
+
+	return changes, nil
+}
+
+// Run refreshes the templates every interval until ctx is done.
+func (r *Registry) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Refresh(ctx); err != nil {
+				log.Printf("refresh templates failed: %v", err)
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the template changes. The returned
+// function must be called to stop the subscription. Changes are dropped for
+// subscribers which don't keep up.
+func (r *Registry) Subscribe() (<-chan api.TemplateChange, func()) {
+	ch := make(chan api.TemplateChange, 32)
+
+	r.subsMu.Lock()
+	r.subs[ch] = struct{}{}
+	r.subsMu.Unlock()
+
+	return ch, func() {
+		r.subsMu.Lock()
+		delete(r.subs, ch)
+		r.subsMu.Unlock()
+	}
+}
+
+func (r *Registry) notify(c api.TemplateChange) {
+	r.subsMu.Lock()
+	defer r.subsMu.Unlock()
+
+	for ch := range r.subs {
+		select {
+		case ch <- c:
+		default:
+		}
+	}
+}
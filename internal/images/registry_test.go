@@ -0,0 +1,127 @@
+package images
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestRegistry_RefreshReportsChanges(t *testing.T) {
+	list := []api.ImageConfig{
+		{Template: "golang_1_24", Version: "1.0"},
+		{Template: "python_3", Version: "1.0"},
+	}
+
+	reg := NewRegistry(func(_ context.Context) (*[]api.ImageConfig, error) {
+		res := append([]api.ImageConfig(nil), list...)
+		return &res, nil
+	})
+
+	changes, unsubscribe := reg.Subscribe()
+	defer unsubscribe()
+
+	got, err := reg.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if len(got) != 2 || got[0].Kind != api.Added || got[1].Kind != api.Added {
+		t.Fatalf("expected two added templates, got %+v", got)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("subscriber should receive 2 changes, got %d", len(changes))
+	}
+	etag := reg.ETag()
+
+	got, _ = reg.Refresh(context.Background())
+	if len(got) != 0 || reg.ETag() != etag {
+		t.Fatalf("unchanged list must not report changes, got %+v", got)
+	}
+
+	list = []api.ImageConfig{
+		{Template: "golang_1_24", Version: "1.1"},
+		{Template: "rust_1_84", Version: "1.0"},
+	}
+	got, _ = reg.Refresh(context.Background())
+
+	want := map[string]api.TemplateChangeKind{
+		"golang_1_24": api.Changed,
+		"rust_1_84":   api.Added,
+		"python_3":    api.Removed,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), got)
+	}
+	for _, c := range got {
+		if want[c.Template] != c.Kind {
+			t.Errorf("%s: got %s, want %s", c.Template, c.Kind, want[c.Template])
+		}
+	}
+
+	if reg.ETag() == etag {
+		t.Errorf("etag must change with the list")
+	}
+	if reg.Get("python_3") != nil || reg.Get("rust_1_84") == nil {
+		t.Errorf("registry content not updated")
+	}
+}
+
+func TestRegistry_RefreshesAreSerialized(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		calls    int
+		inFlight int
+		overlap  bool
+	)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	reg := NewRegistry(func(_ context.Context) (*[]api.ImageConfig, error) {
+		mu.Lock()
+		calls++
+		call := calls
+		inFlight++
+		overlap = overlap || inFlight > 1
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		// The first pull is slow and returns the older list.
+		if call == 1 {
+			close(started)
+			<-release
+			return &[]api.ImageConfig{{Template: "golang_1_24", Version: "1.0"}}, nil
+		}
+
+		return &[]api.ImageConfig{{Template: "golang_1_24", Version: "1.1"}}, nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = reg.Refresh(context.Background())
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		_, _ = reg.Refresh(context.Background())
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if overlap {
+		t.Error("pulls of concurrent refreshes overlapped")
+	}
+	if got := reg.Get("golang_1_24"); got == nil || got.Version != "1.1" {
+		t.Errorf("template = %+v, want the version of the later refresh", got)
+	}
+}
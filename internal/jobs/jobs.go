@@ -0,0 +1,63 @@
+// Package jobs keeps the state of asynchronous submissions.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+var ErrNotFound = errors.New("job not found")
+
+// Job is a submission which is executed in the background.
+type Job struct {
+	api.JobResponse
+}
+
+// Finished reports whether the job reached a final status.
+func (j *Job) Finished() bool {
+	switch j.Status {
+	case api.Done, api.Failed, api.Canceled:
+		return true
+	case api.Queued, api.Compiling, api.Running:
+		return false
+	}
+
+	return false
+}
+
+// Store persists jobs. Implementations must be safe for concurrent use.
+type Store interface {
+	Create(ctx context.Context, job *Job) error
+	Get(ctx context.Context, id string) (*Job, error)
+	// Update applies fn to the stored job atomically and returns the result.
+	Update(ctx context.Context, id string, fn func(job *Job)) (*Job, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// New returns a queued job with a random ID.
+func New() *Job {
+	now := time.Now().UTC()
+
+	return &Job{
+		JobResponse: api.JobResponse{
+			Id:        newID(),
+			Status:    api.Queued,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
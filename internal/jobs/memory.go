@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps jobs in process memory. Finished jobs are dropped
+// after ttl.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+	ttl  time.Duration
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		jobs: make(map[string]*Job),
+		ttl:  ttl,
+	}
+}
+
+func (s *MemoryStore) Create(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictExpired()
+
+	j := *job
+	s.jobs[job.Id] = &j
+
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || s.expired(job) {
+		return nil, ErrNotFound
+	}
+
+	j := *job
+	return &j, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, id string, fn func(job *Job)) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	fn(job)
+	job.UpdatedAt = time.Now().UTC()
+
+	j := *job
+	return &j, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.jobs, id)
+
+	return nil
+}
+
+func (s *MemoryStore) expired(job *Job) bool {
+	return s.ttl > 0 && job.Finished() && time.Since(job.UpdatedAt) > s.ttl
+}
+
+func (s *MemoryStore) evictExpired() {
+	for id, job := range s.jobs {
+		if s.expired(job) {
+			delete(s.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestMemoryStore_FinishedJobsExpire(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(time.Millisecond)
+
+	job := New()
+	if err := store.Create(ctx, job); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := store.Get(ctx, job.Id); err != nil {
+		t.Fatalf("queued job must not expire: %v", err)
+	}
+
+	updated, err := store.Update(ctx, job.Id, func(j *Job) { j.Status = api.Done })
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Status != api.Done {
+		t.Errorf("status: got %q, want %q", updated.Status, api.Done)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err = store.Get(ctx, job.Id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for expired job, got %v", err)
+	}
+}
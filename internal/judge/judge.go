@@ -0,0 +1,116 @@
+// Package judge compares the output of test case runs with the expected
+// output and assigns the verdicts.
+package judge
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+// DefaultTolerance is the tolerance of the float mode if the case has none.
+const DefaultTolerance = 1e-6
+
+// Run is how a test case run ended in the sandbox.
+type Run struct {
+	Stdout    string
+	ExitCode  int
+	TimedOut  bool
+	OOMKilled bool
+	NotRun    bool
+}
+
+// Verdict judges a single test case run.
+func Verdict(c api.JudgeCase, run Run) api.JudgeVerdict {
+	switch {
+	case run.NotRun:
+		return api.JudgeVerdictNotRun
+	case run.TimedOut:
+		return api.JudgeVerdictTimeLimit
+	case run.OOMKilled:
+		return api.JudgeVerdictMemoryLimit
+	case run.ExitCode != 0:
+		return api.JudgeVerdictRuntimeError
+	}
+
+	mode := api.Exact
+	if c.Compare != nil {
+		mode = *c.Compare
+	}
+
+	tolerance := DefaultTolerance
+	if c.Tolerance != nil {
+		tolerance = *c.Tolerance
+	}
+
+	if !Compare(mode, c.ExpectedStdout, run.Stdout, tolerance) {
+		return api.JudgeVerdictWrongAnswer
+	}
+
+	return api.JudgeVerdictAccepted
+}
+
+// Overall returns the verdict of the first case which wasn't accepted.
+func Overall(cases []api.JudgeCaseResult) api.JudgeVerdict {
+	for _, c := range cases {
+		if c.Verdict != api.JudgeVerdictAccepted {
+			return c.Verdict
+		}
+	}
+
+	return api.JudgeVerdictAccepted
+}
+
+// Compare reports whether got matches expected in the given mode.
+func Compare(mode api.JudgeCompareMode, expected, got string, tolerance float64) bool {
+	switch mode {
+	case api.Trimmed:
+		return trimLines(expected) == trimLines(got)
+	case api.Float:
+		return compareFloats(expected, got, tolerance)
+	case api.Exact:
+	}
+
+	return expected == got
+}
+
+// trimLines removes the trailing whitespace of every line and the trailing empty lines.
+func trimLines(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// compareFloats compares the whitespace separated tokens of both outputs.
+// Numbers match if they differ by at most the absolute or relative tolerance,
+// other tokens must be equal.
+func compareFloats(expected, got string, tolerance float64) bool {
+	want, have := strings.Fields(expected), strings.Fields(got)
+	if len(want) != len(have) {
+		return false
+	}
+
+	for i := range want {
+		if want[i] == have[i] {
+			continue
+		}
+
+		a, errA := strconv.ParseFloat(want[i], 64)
+		b, errB := strconv.ParseFloat(have[i], 64)
+		if errA != nil || errB != nil || math.IsNaN(a) || math.IsNaN(b) {
+			return false
+		}
+
+		diff := math.Abs(a - b)
+		if diff > tolerance && diff > tolerance*math.Abs(a) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package judge
+
+import (
+	"testing"
+
+	api "github.com/codiewio/codenire/api/gen"
+)
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		mode     api.JudgeCompareMode
+		expected string
+		got      string
+		want     bool
+	}{
+		{api.Exact, "1 2\n", "1 2\n", true},
+		{api.Exact, "1 2\n", "1 2", false},
+		{api.Trimmed, "1 2\n", "1 2  \r\n\n", true},
+		{api.Trimmed, "1 2\n3", "1 2 3", false},
+		{api.Float, "0.333333", "0.3333331\n", true},
+		{api.Float, "1e9", "1000000001", true},
+		{api.Float, "0.5 yes", "0.5 no", false},
+		{api.Float, "0.5", "0.6", false},
+		{api.Float, "1 2", "1", false},
+		{api.Float, "NaN", "NaN", true},
+	}
+
+	for _, c := range cases {
+		if got := Compare(c.mode, c.expected, c.got, DefaultTolerance); got != c.want {
+			t.Errorf("Compare(%s, %q, %q) = %v, want %v", c.mode, c.expected, c.got, got, c.want)
+		}
+	}
+}
+
+func TestVerdict(t *testing.T) {
+	trimmed := api.Trimmed
+	c := api.JudgeCase{ExpectedStdout: "42", Compare: &trimmed}
+
+	cases := []struct {
+		run  Run
+		want api.JudgeVerdict
+	}{
+		{Run{Stdout: "42\n"}, api.JudgeVerdictAccepted},
+		{Run{Stdout: "41\n"}, api.JudgeVerdictWrongAnswer},
+		{Run{Stdout: "42\n", ExitCode: 1}, api.JudgeVerdictRuntimeError},
+		{Run{ExitCode: 137, OOMKilled: true}, api.JudgeVerdictMemoryLimit},
+		{Run{ExitCode: 137}, api.JudgeVerdictRuntimeError},
+		{Run{ExitCode: -1, TimedOut: true}, api.JudgeVerdictTimeLimit},
+		{Run{ExitCode: -1, NotRun: true}, api.JudgeVerdictNotRun},
+	}
+
+	for _, tc := range cases {
+		if got := Verdict(c, tc.run); got != tc.want {
+			t.Errorf("Verdict(%+v) = %s, want %s", tc.run, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package quota counts the daily usage of the playground per user and
+// checks it against the configured limits.
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Limits of a user per UTC day. Zero means unlimited.
+type Limits struct {
+	RunsPerDay       int     `json:"RunsPerDay"`
+	CPUSecondsPerDay float64 `json:"CPUSecondsPerDay"`
+}
+
+func (l Limits) Unlimited() bool {
+	return l.RunsPerDay <= 0 && l.CPUSecondsPerDay <= 0
+}
+
+// Merge returns l with the non-zero values of o.
+func (l Limits) Merge(o Limits) Limits {
+	if o.RunsPerDay != 0 {
+		l.RunsPerDay = o.RunsPerDay
+	}
+	if o.CPUSecondsPerDay != 0 {
+		l.CPUSecondsPerDay = o.CPUSecondsPerDay
+	}
+
+	return l
+}
+
+type Usage struct {
+	Runs       int
+	CPUSeconds float64
+}
+
+// Remaining returns the runs and CPU seconds left. Unlimited values are -1.
+func (u Usage) Remaining(l Limits) (runs int, cpuSeconds float64) {
+	runs, cpuSeconds = -1, -1
+
+	if l.RunsPerDay > 0 {
+		runs = max(l.RunsPerDay-u.Runs, 0)
+	}
+	if l.CPUSecondsPerDay > 0 {
+		cpuSeconds = max(l.CPUSecondsPerDay-u.CPUSeconds, 0)
+	}
+
+	return runs, cpuSeconds
+}
+
+// Exceeded reports whether no further run is allowed.
+func (u Usage) Exceeded(l Limits) bool {
+	return (l.RunsPerDay > 0 && u.Runs >= l.RunsPerDay) ||
+		(l.CPUSecondsPerDay > 0 && u.CPUSeconds >= l.CPUSecondsPerDay)
+}
+
+// File is the format of the quota config file: default limits for everybody
+// and limits per user key (the value of the rate limit claim).
+type File struct {
+	Default Limits            `json:"default"`
+	Users   map[string]Limits `json:"users"`
+}
+
+func LoadFile(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read quota file: %w", err)
+	}
+
+	var f File
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parse quota file: %w", err)
+	}
+
+	return &f, nil
+}
+
+// Tracker keeps the usage of the current UTC day in memory.
+type Tracker struct {
+	mu    sync.Mutex
+	day   string
+	usage map[string]*Usage
+
+	defaults Limits
+	users    map[string]Limits
+
+	now func() time.Time
+}
+
+func NewTracker(defaults Limits, file *File) *Tracker {
+	t := &Tracker{
+		usage:    make(map[string]*Usage),
+		defaults: defaults,
+		users:    make(map[string]Limits),
+		now:      time.Now,
+	}
+
+	if file != nil {
+		t.defaults = t.defaults.Merge(file.Default)
+		for user, l := range file.Users {
+			t.users[user] = l
+		}
+	}
+
+	return t
+}
+
+// Limits returns the limits of the user: the defaults, overridden by the
+// quota file and then by the limits from the user's token claims.
+func (t *Tracker) Limits(user string, fromClaims Limits) Limits {
+	l := t.defaults
+	if u, ok := t.users[user]; ok {
+		l = l.Merge(u)
+	}
+
+	return l.Merge(fromClaims)
+}
+
+func (t *Tracker) Usage(key string) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate()
+	if u, ok := t.usage[key]; ok {
+		return *u
+	}
+
+	return Usage{}
+}
+
+// TakeRun counts a run for key if the limits allow it. It returns the usage
+// including the run and whether the run is allowed.
+func (t *Tracker) TakeRun(key string, l Limits) (Usage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate()
+	u, ok := t.usage[key]
+	if !ok {
+		u = &Usage{}
+		t.usage[key] = u
+	}
+
+	if u.Exceeded(l) {
+		return *u, false
+	}
+
+	u.Runs++
+	return *u, true
+}
+
+// RefundRun takes back a run counted by TakeRun which wasn't run after all.
+// It returns the usage without the run.
+func (t *Tracker) RefundRun(key string) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate()
+	u, ok := t.usage[key]
+	if !ok {
+		return Usage{}
+	}
+
+	if u.Runs > 0 {
+		u.Runs--
+	}
+	return *u
+}
+
+// AddCPU charges the CPU time of a finished run to key.
+func (t *Tracker) AddCPU(key string, seconds float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate()
+	if u, ok := t.usage[key]; ok {
+		u.CPUSeconds += seconds
+	}
+}
+
+// Reset returns the time when the usage of the current day is reset.
+func (t *Tracker) Reset() time.Time {
+	y, m, d := t.now().UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+func (t *Tracker) rotate() {
+	day := t.now().UTC().Format(time.DateOnly)
+	if day != t.day {
+		t.day = day
+		t.usage = make(map[string]*Usage)
+	}
+}
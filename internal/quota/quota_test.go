@@ -0,0 +1,73 @@
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerLimits(t *testing.T) {
+	tr := NewTracker(Limits{RunsPerDay: 10}, &File{
+		Default: Limits{CPUSecondsPerDay: 60},
+		Users:   map[string]Limits{"123": {RunsPerDay: 100}},
+	})
+
+	if l := tr.Limits("456", Limits{}); l != (Limits{RunsPerDay: 10, CPUSecondsPerDay: 60}) {
+		t.Errorf("default limits = %+v", l)
+	}
+	if l := tr.Limits("123", Limits{}); l != (Limits{RunsPerDay: 100, CPUSecondsPerDay: 60}) {
+		t.Errorf("user limits = %+v", l)
+	}
+	if l := tr.Limits("123", Limits{CPUSecondsPerDay: 5}); l != (Limits{RunsPerDay: 100, CPUSecondsPerDay: 5}) {
+		t.Errorf("claim limits = %+v", l)
+	}
+}
+
+func TestTrackerTakeRun(t *testing.T) {
+	now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	tr := NewTracker(Limits{}, nil)
+	tr.now = func() time.Time { return now }
+
+	limits := Limits{RunsPerDay: 2, CPUSecondsPerDay: 10}
+
+	for i := 0; i < 2; i++ {
+		if _, ok := tr.TakeRun("u", limits); !ok {
+			t.Fatalf("run %d rejected", i)
+		}
+	}
+	if u, ok := tr.TakeRun("u", limits); ok || u.Runs != 2 {
+		t.Fatalf("third run: usage %+v, allowed %v", u, ok)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := tr.TakeRun("u", limits); !ok {
+		t.Fatal("run rejected on the next day")
+	}
+
+	tr.AddCPU("u", 10)
+	if _, ok := tr.TakeRun("u", limits); ok {
+		t.Fatal("run allowed after the CPU quota was used up")
+	}
+
+	if reset := tr.Reset(); !reset.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("reset = %v", reset)
+	}
+}
+
+func TestTrackerRefundRun(t *testing.T) {
+	tr := NewTracker(Limits{}, nil)
+	limits := Limits{RunsPerDay: 1}
+
+	if _, ok := tr.TakeRun("u", limits); !ok {
+		t.Fatal("run rejected")
+	}
+	if u := tr.RefundRun("u"); u.Runs != 0 {
+		t.Fatalf("usage after the refund = %+v", u)
+	}
+	if _, ok := tr.TakeRun("u", limits); !ok {
+		t.Fatal("run rejected after the refund")
+	}
+
+	if u := tr.RefundRun("other"); u != (Usage{}) {
+		t.Errorf("refund of an unknown key = %+v", u)
+	}
+}
@@ -0,0 +1,67 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps every snippet in its own file below Dir.
+type FileStore struct {
+	Dir string
+}
+
+func (s FileStore) Put(_ context.Context, id string, data []byte) error {
+	if !ValidID(id) {
+		return fmt.Errorf("invalid snippet id %q", id)
+	}
+
+	path := s.path(id)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Written to a temporary file first, so readers never see a partial snippet.
+	tmp, err := os.CreateTemp(filepath.Dir(path), id+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write snippet: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s FileStore) Get(_ context.Context, id string) ([]byte, error) {
+	if !ValidID(id) {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+// path spreads the snippets over subdirectories by the first characters of the ID.
+func (s FileStore) path(id string) string {
+	return filepath.Join(s.Dir, id[:2], id)
+}
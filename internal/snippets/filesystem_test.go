@@ -0,0 +1,47 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	s := FileStore{Dir: t.TempDir()}
+
+	data := []byte(`{"TemplateId":"golang"}`)
+	id := ID(data)
+	if !ValidID(id) {
+		t.Fatalf("invalid id %q", id)
+	}
+	if id != ID([]byte(`{"TemplateId":"golang"}`)) {
+		t.Fatal("id of the same content differs")
+	}
+
+	if err := s.Put(ctx, id, data); err != nil {
+		t.Fatal(err)
+	}
+	// Storing the same snippet again is a no-op.
+	if err := s.Put(ctx, id, data); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("got %s, want %s", got, data)
+	}
+
+	if _, err = s.Get(ctx, ID([]byte("other"))); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing snippet: got %v, want ErrNotFound", err)
+	}
+	if _, err = s.Get(ctx, "../../etc/pa"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("path id: got %v, want ErrNotFound", err)
+	}
+	if err = s.Put(ctx, "../../etc/pa", data); err == nil {
+		t.Error("path id stored")
+	}
+}
@@ -0,0 +1,66 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const createSnippetsTable = `CREATE TABLE IF NOT EXISTS snippets (
+	id         TEXT PRIMARY KEY,
+	data       BYTEA NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// PostgresStore keeps snippets in the `snippets` table.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore connects to the database and creates the table if needed.
+func NewPostgresStore(ctx context.Context, dsn string) (*PostgresStore, error) {
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
+	}
+
+	if _, err = pool.Exec(ctx, createSnippetsTable); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("error creating the snippets table: %w", err)
+	}
+
+	return &PostgresStore{pool: pool}, nil
+}
+
+func (s *PostgresStore) Put(ctx context.Context, id string, data []byte) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO snippets (id, data) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`,
+		id, data,
+	)
+	if err != nil {
+		return fmt.Errorf("insert snippet: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) ([]byte, error) {
+	var data []byte
+
+	err := s.pool.QueryRow(ctx, `SELECT data FROM snippets WHERE id = $1`, id).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select snippet: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *PostgresStore) Close() {
+	s.pool.Close()
+}
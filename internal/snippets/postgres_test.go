@@ -0,0 +1,55 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// TestPostgresStore needs a database, it runs when CODENIRE_TEST_POSTGRES_DSN is set.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("CODENIRE_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CODENIRE_TEST_POSTGRES_DSN is not set")
+	}
+
+	ctx := context.Background()
+	s, err := NewPostgresStore(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	data := []byte(`{"TemplateId":"golang","Test":"` + t.Name() + `"}`)
+	id := ID(data)
+	t.Cleanup(func() {
+		_, _ = s.pool.Exec(ctx, `DELETE FROM snippets WHERE id = $1`, id)
+	})
+
+	if err = s.Put(ctx, id, data); err != nil {
+		t.Fatal(err)
+	}
+	// Storing the same snippet again keeps the first one.
+	if err = s.Put(ctx, id, []byte("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("got %s, want %s", got, data)
+	}
+
+	if _, err = s.Get(ctx, ID([]byte("missing"))); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing snippet: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestNewPostgresStoreError(t *testing.T) {
+	if _, err := NewPostgresStore(context.Background(), "not a dsn"); err == nil {
+		t.Error("connected with an invalid dsn")
+	}
+}
@@ -0,0 +1,104 @@
+package snippets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Store keeps snippets as objects in a bucket of an S3-compatible storage.
+type S3Store struct {
+	Client *s3.Client
+	Bucket string
+	Prefix string
+}
+
+// NewS3Store creates a store for the bucket at endpoint, or at the AWS endpoint
+// of the region if it's empty. The credentials are read from AWS_ACCESS_KEY_ID
+// and AWS_SECRET_ACCESS_KEY, without them the requests are anonymous.
+func NewS3Store(endpoint, region, bucket, prefix string) *S3Store {
+	return &S3Store{
+		Client: s3.New(s3Options(endpoint, region)),
+		Bucket: bucket,
+		Prefix: prefix,
+	}
+}
+
+// s3Options returns the client options of the store. Without an endpoint the
+// client resolves the AWS endpoint of the region.
+func s3Options(endpoint, region string) s3.Options {
+	opts := s3.Options{
+		Region:       region,
+		UsePathStyle: true,
+	}
+	if endpoint != "" {
+		opts.BaseEndpoint = aws.String(endpoint)
+	}
+
+	if key := os.Getenv("AWS_ACCESS_KEY_ID"); key != "" {
+		creds := aws.Credentials{
+			AccessKeyID:     key,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		opts.Credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return creds, nil
+		})
+	}
+
+	return opts
+}
+
+func (s *S3Store) Put(ctx context.Context, id string, data []byte) error {
+	if !ValidID(id) {
+		return fmt.Errorf("invalid snippet id %q", id)
+	}
+
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.key(id)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("put snippet: %w", err)
+	}
+
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, id string) ([]byte, error) {
+	if !ValidID(id) {
+		return nil, ErrNotFound
+	}
+
+	out, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(id)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("get snippet: %w", err)
+	}
+	defer func() {
+		_ = out.Body.Close()
+	}()
+
+	return io.ReadAll(out.Body)
+}
+
+func (s *S3Store) key(id string) string {
+	return path.Join(s.Prefix, id+".json")
+}
@@ -0,0 +1,106 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestS3Options(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+
+	opts := s3Options("", "eu-west-1")
+	if opts.BaseEndpoint != nil {
+		t.Errorf("BaseEndpoint = %q, want the endpoint of the region", *opts.BaseEndpoint)
+	}
+	if opts.Region != "eu-west-1" || !opts.UsePathStyle {
+		t.Errorf("options = %+v", opts)
+	}
+	if opts.Credentials != nil {
+		t.Error("credentials set without AWS_ACCESS_KEY_ID")
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	opts = s3Options("http://minio:9000", "us-east-1")
+	if opts.BaseEndpoint == nil || *opts.BaseEndpoint != "http://minio:9000" {
+		t.Errorf("BaseEndpoint = %v, want http://minio:9000", opts.BaseEndpoint)
+	}
+	if opts.Credentials == nil {
+		t.Fatal("no credentials")
+	}
+	creds, err := opts.Credentials.Retrieve(context.Background())
+	if err != nil || creds.AccessKeyID != "key" || creds.SecretAccessKey != "secret" {
+		t.Errorf("credentials = %+v, %v", creds, err)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	var (
+		mu      sync.Mutex
+		objects = map[string][]byte{}
+	)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = data
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer storage.Close()
+
+	ctx := context.Background()
+	s := NewS3Store(storage.URL, "us-east-1", "codenire", "snippets")
+
+	data := []byte(`{"TemplateId":"golang"}`)
+	id := ID(data)
+	if err := s.Put(ctx, id, data); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	_, stored := objects["/codenire/snippets/"+id+".json"]
+	mu.Unlock()
+	if !stored {
+		t.Error("snippet not stored as codenire/snippets/<id>.json")
+	}
+
+	got, err := s.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("got %s, want %s", got, data)
+	}
+
+	if _, err = s.Get(ctx, ID([]byte("other"))); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing snippet: got %v, want ErrNotFound", err)
+	}
+	if _, err = s.Get(ctx, "../secret"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("invalid id: got %v, want ErrNotFound", err)
+	}
+	if err = s.Put(ctx, "../secret", data); err == nil {
+		t.Error("put with an invalid id succeeded")
+	}
+}
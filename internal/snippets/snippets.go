@@ -0,0 +1,37 @@
+// Package snippets stores shared submissions under content-addressed IDs.
+package snippets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"regexp"
+)
+
+// idLength is the number of base64 characters of the content hash used as ID,
+// the same as in the Go playground.
+const idLength = 11
+
+var ErrNotFound = errors.New("snippet not found")
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Store persists snippets. Putting the same ID twice keeps the first snippet,
+// since the ID is derived from the content.
+type Store interface {
+	Put(ctx context.Context, id string, data []byte) error
+	Get(ctx context.Context, id string) ([]byte, error)
+}
+
+// ID returns the content-addressed ID of the snippet data.
+func ID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.URLEncoding.EncodeToString(sum[:])[:idLength]
+}
+
+// ValidID reports whether id can be an ID returned by ID. Stores use it to
+// reject IDs which could escape their namespace, like paths.
+func ValidID(id string) bool {
+	return len(id) == idLength && validID.MatchString(id)
+}
@@ -41,22 +41,54 @@ import (
 	"time"
 
 	"github.com/codiewio/codenire/internal/handler"
-	"github.com/codiewio/codenire/internal/images"
 )
 
 var (
-	backendURL     = flag.String("backend-url", "http://sandbox_dev", "URL for sandbox backend that runs Go binaries.")
+	backendURL     = flag.String("backend-url", "http://sandbox_dev", "Comma separated list of sandbox backend URLs which run the submissions.")
 	Port           = flag.String("port", "8081", "URL for sandbox backend that runs Go binaries.")
-	PluginHookPath = flag.String("hooks-plugins", "", "URL for sandbox backend that runs Go binaries.")
+	PluginHookPath = flag.String("hooks-plugins", "", "URL of the plugin endpoint which receives pre-run and post-run hooks")
 	FileHooksDir   = flag.String("hooks-dir", "", "Directory to search for available hooks scripts")
 
 	// deprecated
 	ExternalTemplates = flag.String("external-templates", "", "Comma separated list of templates which will handled externally (plugin for example)")
 
+	HealthCheckInterval      = flag.Duration("health-check-interval", 10*time.Second, "how often the sandbox backends are health-checked")
+	TemplatesRefreshInterval = flag.Duration("templates-refresh-interval", time.Minute, "how often the templates are reloaded from the sandbox backends")
+	AdminToken               = flag.String("admin-token", "", "bearer token to enable the admin endpoints")
+
 	ThrottleLimit = flag.Int("throttle-limit", 15, "currently processed requests at a time across all users")
+	JobsLimit     = flag.Int("jobs-limit", 15, "currently running asynchronous jobs at a time across all users")
+	JobTTL        = flag.Duration("job-ttl", time.Hour, "how long finished asynchronous jobs are kept")
 	JWTSecretKey  = flag.String("jwt-secret-key", "", "secret key to enable authentication")
 	dev           = flag.Bool("dev", false, "run in dev mode")
 
+	RateLimitClaim        = flag.String("rate-limit-claim", "user_id", "JWT claim identifying the user for rate limits and quotas, anonymous requests are limited by IP")
+	RateLimitRequests     = flag.Int("rate-limit-requests", 1, "runs per user allowed within the rate limit window")
+	RateLimitWindow       = flag.Duration("rate-limit-window", 3*time.Second, "window of the per-user rate limit")
+	QuotaRunsPerDay       = flag.Int("quota-runs-per-day", 0, "daily runs per user, 0 means unlimited")
+	QuotaCPUSecondsPerDay = flag.Float64("quota-cpu-seconds-per-day", 0, "daily CPU seconds per user, 0 means unlimited")
+	QuotaFile             = flag.String("quota-file", "", "JSON file with default and per-user daily quotas, see README")
+
+	ShareStorage     = flag.String("share-storage", "", "storage of shared snippets: fs, s3 or postgres, sharing is disabled if empty")
+	ShareDir         = flag.String("share-dir", "", "directory of shared snippets for the fs storage")
+	ShareS3Endpoint  = flag.String("share-s3-endpoint", "", "endpoint of the S3-compatible storage of shared snippets, the AWS endpoint of the region if empty")
+	ShareS3Region    = flag.String("share-s3-region", "us-east-1", "region of the S3 bucket of shared snippets")
+	ShareS3Bucket    = flag.String("share-s3-bucket", "", "S3 bucket of shared snippets")
+	ShareS3Prefix    = flag.String("share-s3-prefix", "snippets", "prefix of shared snippets in the S3 bucket")
+	SharePostgresDSN = flag.String("share-postgres-dsn", "", "DSN of the Postgres database of shared snippets")
+
+	MaxRequestSize = flag.Int64("max-request-size", handler.MaxFilesSnippetSize, "max size in bytes of a files submission request, room for one file of --max-file-size as base64 if 0")
+	MaxFiles       = flag.Int("max-files", handler.MaxFiles, "max number of files of a submission")
+	MaxFileSize    = flag.Int64("max-file-size", handler.MaxFileSize, "max size in bytes of a single submission file, after decoding binary files")
+
+	MaxArchiveSize        = flag.Int64("max-archive-size", handler.MaxArchiveSize, "max size in bytes of an uploaded project archive")
+	MaxArchiveContentSize = flag.Int64("max-archive-content-size", handler.MaxArchiveContentSize, "max total size in bytes of the files extracted from a project archive")
+
+	CacheSize = flag.Int("cache-size", 0, "results of cacheable templates kept in memory, 0 disables the cache")
+	CacheTTL  = flag.Duration("cache-ttl", 10*time.Minute, "how long cached results are served")
+
+	ValidateRequests = flag.Bool("validate-requests", true, "reject requests of the API operations which don't match the OpenAPI spec, --validate-requests=false turns it off")
+
 	CorsAllowOrigin      = flag.String("cors-allow-origin", "*", "Regular expression used to determine if the Origin header is allowed. If not, no CORS headers will be sent. By default, all origins are allowed.")
 	CorsAllowCredentials = flag.Bool("cors-allow-credentials", false, "Allow credentials by setting Access-Control-Allow-Credentials: true")
 	CorsAllowMethods     = flag.String("cors-allow-methods", "", "Comma-separated list of request methods that are included in Access-Control-Allow-Methods in addition to the ones required by tusd")
@@ -67,24 +99,51 @@ var (
 
 func main() {
 	flag.Parse()
-	log.Printf("Use backend URL on :%s ...", *backendURL)
+	log.Printf("Use backend URLs on :%s ...", *backendURL)
 
 	ShowVersion()
 
+	handler.MaxFilesSnippetSize = *MaxRequestSize
+	handler.MaxFiles = *MaxFiles
+	handler.MaxFileSize = *MaxFileSize
+	handler.MaxArchiveSize = *MaxArchiveSize
+	handler.MaxArchiveContentSize = *MaxArchiveContentSize
+
 	if err := waitForSandbox(10, 3*time.Second); err != nil {
 		log.Println(err)
 		return
 	}
 
 	cfg := handler.Config{
-		BackendURL:                       *backendURL,
+		BackendURLs:                      splitAndTrim(*backendURL),
+		HealthCheckInterval:              *HealthCheckInterval,
+		TemplatesRefreshInterval:         *TemplatesRefreshInterval,
+		AdminToken:                       *AdminToken,
 		Port:                             *Port,
 		PluginHookPath:                   *PluginHookPath,
 		FileHooksDir:                     *FileHooksDir,
 		GracefulRequestCompletionTimeout: 10 * time.Second,
 		ShutdownTimeout:                  10 * time.Second,
 		ThrottleLimit:                    *ThrottleLimit,
+		JobsLimit:                        *JobsLimit,
+		JobTTL:                           *JobTTL,
 		JWTSecretKey:                     *JWTSecretKey,
+		RateLimitClaim:                   *RateLimitClaim,
+		RateLimitRequests:                *RateLimitRequests,
+		RateLimitWindow:                  *RateLimitWindow,
+		QuotaRunsPerDay:                  *QuotaRunsPerDay,
+		QuotaCPUSecondsPerDay:            *QuotaCPUSecondsPerDay,
+		QuotaFile:                        *QuotaFile,
+		SnippetStorage:                   *ShareStorage,
+		SnippetDir:                       *ShareDir,
+		SnippetS3Endpoint:                *ShareS3Endpoint,
+		SnippetS3Region:                  *ShareS3Region,
+		SnippetS3Bucket:                  *ShareS3Bucket,
+		SnippetS3Prefix:                  *ShareS3Prefix,
+		SnippetPostgresDSN:               *SharePostgresDSN,
+		CacheSize:                        *CacheSize,
+		CacheTTL:                         *CacheTTL,
+		ValidateRequests:                 *ValidateRequests,
 		Dev:                              *dev,
 		Cors:                             getCorsConfig(),
 	}
@@ -96,14 +155,6 @@ func main() {
 
 	shutdownComplete := setupSignalHandler(cfg.ShutdownTimeout)
 
-	{
-		res, terr := images.PullImageConfigList(cfg.BackendURL)
-		if terr != nil {
-			panic("sandbox not ready yet")
-		}
-		images.ImageTemplateList = res
-	}
-
 	log.Printf("playground is running, port %s", cfg.Port)
 
 	err = s.ListenAndServe()
@@ -118,14 +169,19 @@ func main() {
 	}
 }
 
+// waitForSandbox waits until at least one of the sandbox backends is healthy.
 func waitForSandbox(maxRetries int, interval time.Duration) error {
-	for i := 0; i < maxRetries; i++ {
-		//nolint
-		resp, err := http.Get(*backendURL + "/health")
+	urls := splitAndTrim(*backendURL)
 
-		if err == nil && resp.StatusCode == http.StatusOK {
-			fmt.Println("sandbox is healthy!")
-			return nil
+	for i := 0; i < maxRetries; i++ {
+		for _, u := range urls {
+			//nolint
+			resp, err := http.Get(u + "/health")
+
+			if err == nil && resp.StatusCode == http.StatusOK {
+				fmt.Printf("sandbox %s is healthy!\n", u)
+				return nil
+			}
 		}
 		fmt.Printf("waiting for sandbox... (%d/%d)\n", i+1, maxRetries)
 		time.Sleep(interval)
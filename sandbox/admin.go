@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	contract "sandbox/api/gen"
+)
+
+// adminRoutes serves the admin API which inspects and controls the
+// containers, runs and templates of the sandbox.
+func adminRoutes(r chi.Router) {
+	r.Get("/containers", adminContainersHandler)
+	r.Post("/containers/{id}/kill", adminKillContainerHandler)
+
+	r.Get("/runs", adminRunsHandler)
+	r.Post("/runs/{id}/kill", adminKillRunHandler)
+
+	r.Get("/templates", adminTemplatesHandler)
+	r.Post("/templates/{template}/enable", adminTemplateStateHandler(contract.Active))
+	r.Post("/templates/{template}/drain", adminTemplateStateHandler(contract.Draining))
+	r.Post("/templates/{template}/disable", adminTemplateStateHandler(contract.Disabled))
+
+	r.Post("/kill-all", adminKillAllHandler)
+}
+
+// requireAdmin only passes requests with the admin token as bearer token.
+func requireAdmin(token string) func(http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := []byte(r.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(got, expected) != 1 {
+				sendProblem(w, newProblem(http.StatusUnauthorized, contract.Unauthorized, "admin token required"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// adminContainersHandler lists the warm containers by template.
+func adminContainersHandler(w http.ResponseWriter, _ *http.Request) {
+	res := make(map[string][]contract.SandboxWarmContainer)
+	for _, c := range codenireManager.WarmContainers() {
+		template := c.Image.Template
+		res[template] = append(res[template], contract.SandboxWarmContainer{
+			Id:         c.CId,
+			Template:   template,
+			StartedAt:  c.StartedAt,
+			Age:        float32(time.Since(c.StartedAt).Seconds()),
+			Generation: int64(c.Generation), //nolint:gosec
+		})
+	}
+
+	sendAdminResponse(w, res)
+}
+
+// adminKillContainerHandler stops a warm container or kills the run holding it.
+func adminKillContainerHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	killed, err := codenireManager.KillWarmContainer(id)
+	if err != nil {
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.DockerError, "kill container %s: %v", id, err))
+		return
+	}
+
+	if !killed {
+		killed = activeRuns.kill(func(run *activeRun) bool { return run.cont.CId == id }, errRunKilled) > 0
+	}
+	if !killed {
+		sendProblem(w, newProblem(http.StatusNotFound, contract.NotFound, "container %s not found", id))
+		return
+	}
+
+	log.Printf("admin killed container %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminRunsHandler(w http.ResponseWriter, _ *http.Request) {
+	sendAdminResponse(w, activeRuns.list())
+}
+
+// adminKillRunHandler kills a run, it's reported to the client as failed run.
+func adminKillRunHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if activeRuns.kill(func(run *activeRun) bool { return run.id == id }, errRunKilled) == 0 {
+		sendProblem(w, newProblem(http.StatusNotFound, contract.NotFound, "run %s not found", id))
+		return
+	}
+
+	log.Printf("admin killed run %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminTemplatesHandler(w http.ResponseWriter, _ *http.Request) {
+	templates := codenireManager.GetTemplates()
+
+	res := make([]contract.SandboxTemplateStatus, 0, len(templates))
+	for _, img := range templates {
+		res = append(res, templateStatus(img.Template))
+	}
+
+	sendAdminResponse(w, res)
+}
+
+// adminTemplateStateHandler changes the state of a template. Disabling it
+// also kills its active runs.
+func adminTemplateStateHandler(state contract.SandboxTemplateStatusState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		template := chi.URLParam(r, "template")
+
+		if err := codenireManager.SetTemplateState(template, state); err != nil {
+			sendProblem(w, newProblem(http.StatusNotFound, contract.TemplateNotFound, "%s", err.Error()))
+			return
+		}
+
+		if state == contract.Disabled {
+			activeRuns.kill(func(run *activeRun) bool { return run.template == template }, errTemplateStopped)
+		}
+
+		log.Printf("admin set template %s %s", template, state)
+		sendAdminResponse(w, templateStatus(template))
+	}
+}
+
+// adminKillAllHandler kills the active runs and stops all containers, the
+// warm containers are started again afterwards.
+func adminKillAllHandler(w http.ResponseWriter, _ *http.Request) {
+	killed := activeRuns.kill(func(*activeRun) bool { return true }, errContainersReset)
+	codenireManager.KillAll()
+
+	sendAdminResponse(w, contract.SandboxKillAllResponse{
+		Generation: int64(codenireManager.Generation()), //nolint:gosec
+		KilledRuns: killed,
+	})
+}
+
+func templateStatus(template string) contract.SandboxTemplateStatus {
+	warm := 0
+	for _, c := range codenireManager.WarmContainers() {
+		if c.Image.Template == template {
+			warm++
+		}
+	}
+
+	return contract.SandboxTemplateStatus{
+		Template:       template,
+		State:          codenireManager.TemplateState(template),
+		WarmContainers: warm,
+		ActiveRuns:     activeRuns.count(template),
+	}
+}
+
+func sendAdminResponse(w http.ResponseWriter, v interface{}) {
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "error encoding JSON"))
+		log.Printf("json marshal: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
@@ -3,6 +3,10 @@
 // Code generated by github.com/deepmap/oapi-codegen/v2 version v2.2.0 DO NOT EDIT.
 package api
 
+import (
+	"time"
+)
+
 // Defines values for ActionItemResponseEnableExternalCommands.
 const (
 	ActionItemResponseEnableExternalCommandsAll     ActionItemResponseEnableExternalCommands = "all"
@@ -11,6 +15,36 @@ const (
 	ActionItemResponseEnableExternalCommandsRun     ActionItemResponseEnableExternalCommands = "run"
 )
 
+// Defines values for ActionStepOutput.
+const (
+	ActionStepOutputAll     ActionStepOutput = "all"
+	ActionStepOutputNone    ActionStepOutput = "none"
+	ActionStepOutputOnError ActionStepOutput = "on_error"
+)
+
+// Defines values for DiagnosticSeverity.
+const (
+	Error   DiagnosticSeverity = "error"
+	Info    DiagnosticSeverity = "info"
+	Warning DiagnosticSeverity = "warning"
+)
+
+// Defines values for ExecutionStatus.
+const (
+	ExecutionStatusCompileError  ExecutionStatus = "compile_error"
+	ExecutionStatusInternalError ExecutionStatus = "internal_error"
+	ExecutionStatusOk            ExecutionStatus = "ok"
+	ExecutionStatusOomKilled     ExecutionStatus = "oom_killed"
+	ExecutionStatusRuntimeError  ExecutionStatus = "runtime_error"
+	ExecutionStatusTimeout       ExecutionStatus = "timeout"
+)
+
+// Defines values for FileMetaEncoding.
+const (
+	Base64 FileMetaEncoding = "base64"
+	Text   FileMetaEncoding = "text"
+)
+
 // Defines values for ImageActionConfigEnableExternalCommands.
 const (
 	ImageActionConfigEnableExternalCommandsAll     ImageActionConfigEnableExternalCommands = "all"
@@ -19,8 +53,102 @@ const (
 	ImageActionConfigEnableExternalCommandsRun     ImageActionConfigEnableExternalCommands = "run"
 )
 
+// Defines values for JobStatus.
+const (
+	Canceled  JobStatus = "canceled"
+	Compiling JobStatus = "compiling"
+	Done      JobStatus = "done"
+	Failed    JobStatus = "failed"
+	Queued    JobStatus = "queued"
+	Running   JobStatus = "running"
+)
+
+// Defines values for JudgeCompareMode.
+const (
+	Exact   JudgeCompareMode = "exact"
+	Float   JudgeCompareMode = "float"
+	Trimmed JudgeCompareMode = "trimmed"
+)
+
+// Defines values for JudgeVerdict.
+const (
+	JudgeVerdictAccepted         JudgeVerdict = "accepted"
+	JudgeVerdictCompilationError JudgeVerdict = "compilation_error"
+	JudgeVerdictMemoryLimit      JudgeVerdict = "memory_limit"
+	JudgeVerdictNotRun           JudgeVerdict = "not_run"
+	JudgeVerdictRuntimeError     JudgeVerdict = "runtime_error"
+	JudgeVerdictTimeLimit        JudgeVerdict = "time_limit"
+	JudgeVerdictWrongAnswer      JudgeVerdict = "wrong_answer"
+)
+
+// Defines values for ProblemCode.
+const (
+	ActionNotFound          ProblemCode = "action_not_found"
+	CapacityExceeded        ProblemCode = "capacity_exceeded"
+	DockerError             ProblemCode = "docker_error"
+	Forbidden               ProblemCode = "forbidden"
+	FormatNotSupported      ProblemCode = "format_not_supported"
+	HookFailed              ProblemCode = "hook_failed"
+	InternalError           ProblemCode = "internal_error"
+	InvalidRequest          ProblemCode = "invalid_request"
+	JobNotFound             ProblemCode = "job_not_found"
+	MethodNotAllowed        ProblemCode = "method_not_allowed"
+	NotFound                ProblemCode = "not_found"
+	QuotaExceeded           ProblemCode = "quota_exceeded"
+	RateLimited             ProblemCode = "rate_limited"
+	RejectedByHook          ProblemCode = "rejected_by_hook"
+	RequestTooLarge         ProblemCode = "request_too_large"
+	SandboxError            ProblemCode = "sandbox_error"
+	SandboxSaturated        ProblemCode = "sandbox_saturated"
+	SandboxUnavailable      ProblemCode = "sandbox_unavailable"
+	SnippetNotFound         ProblemCode = "snippet_not_found"
+	TemplateNotFound        ProblemCode = "template_not_found"
+	TemplatesRefreshFailed  ProblemCode = "templates_refresh_failed"
+	TerminalSessionNotFound ProblemCode = "terminal_session_not_found"
+	TooManyTestCases        ProblemCode = "too_many_test_cases"
+	Unauthorized            ProblemCode = "unauthorized"
+)
+
+// Defines values for SandboxTemplateStatusState.
+const (
+	Active   SandboxTemplateStatusState = "active"
+	Disabled SandboxTemplateStatusState = "disabled"
+	Draining SandboxTemplateStatusState = "draining"
+)
+
+// Defines values for StepPhase.
+const (
+	StepPhaseCompile StepPhase = "compile"
+	StepPhaseRun     StepPhase = "run"
+)
+
+// Defines values for StepResultStatus.
+const (
+	Errored  StepResultStatus = "errored"
+	Passed   StepResultStatus = "passed"
+	Skipped  StepResultStatus = "skipped"
+	TimedOut StepResultStatus = "timed_out"
+)
+
+// Defines values for TemplateChangeKind.
+const (
+	Added   TemplateChangeKind = "added"
+	Changed TemplateChangeKind = "changed"
+	Removed TemplateChangeKind = "removed"
+)
+
+// Defines values for TerminalControlType.
+const (
+	Resize TerminalControlType = "resize"
+)
+
 // ActionItemResponse defines model for ActionItemResponse.
 type ActionItemResponse struct {
+	// Artifacts globs of the files which are returned after the run, relative to the workdir (like out/*.png)
+	Artifacts *[]string `json:"Artifacts,omitempty"`
+
+	// Cacheable The programs are deterministic, so the playground may cache their results.
+	Cacheable  bool   `json:"Cacheable"`
 	CompileCmd string `json:"CompileCmd"`
 
 	// Connections Databases. Currently available only ['postgres']
@@ -31,17 +159,23 @@ type ActionItemResponse struct {
 	// EnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
 	EnableExternalCommands ActionItemResponseEnableExternalCommands `json:"EnableExternalCommands"`
 	Enabled                bool                                     `json:"Enabled"`
-	Groups                 []string                                 `json:"Groups"`
-	Id                     string                                   `json:"Id"`
-	IsDefault              bool                                     `json:"IsDefault"`
-	IsSupportPackage       bool                                     `json:"IsSupportPackage"`
-	Name                   string                                   `json:"Name"`
-	Provider               string                                   `json:"Provider"`
-	RunCmd                 string                                   `json:"RunCmd"`
-	ScriptOptions          ImageConfigScriptOptions                 `json:"ScriptOptions"`
-	Template               string                                   `json:"Template"`
-	Version                string                                   `json:"Version"`
-	Workdir                string                                   `json:"Workdir"`
+
+	// FormatCmd command formatting the files in place in the workdir, like gofmt -w .
+	FormatCmd        *string                  `json:"FormatCmd,omitempty"`
+	Groups           []string                 `json:"Groups"`
+	Id               string                   `json:"Id"`
+	IsDefault        bool                     `json:"IsDefault"`
+	IsSupportPackage bool                     `json:"IsSupportPackage"`
+	Name             string                   `json:"Name"`
+	Provider         string                   `json:"Provider"`
+	RunCmd           string                   `json:"RunCmd"`
+	ScriptOptions    ImageConfigScriptOptions `json:"ScriptOptions"`
+
+	// Steps ordered steps replacing CompileCmd and RunCmd, like install, build, test and run. ExternalOptions don't override them
+	Steps    *[]ActionStep `json:"Steps,omitempty"`
+	Template string        `json:"Template"`
+	Version  string        `json:"Version"`
+	Workdir  string        `json:"Workdir"`
 }
 
 // ActionItemResponseEnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
@@ -50,6 +184,39 @@ type ActionItemResponseEnableExternalCommands string
 // ActionListResponse defines model for ActionListResponse.
 type ActionListResponse = []ActionItemResponse
 
+// ActionStep defines model for ActionStep.
+type ActionStep struct {
+	// Cmd shell command run in the workdir, {ARGS} and {STDIN} are replaced like in RunCmd
+	Cmd string `json:"Cmd"`
+
+	// ContinueOnError a failing or timed out step doesn't stop the action and doesn't fail the run
+	ContinueOnError *bool  `json:"ContinueOnError,omitempty"`
+	Name            string `json:"Name"`
+
+	// Output all streams the output of the step, on_error only returns it if the step fails, none discards it
+	Output *ActionStepOutput `json:"Output,omitempty"`
+
+	// Phase a failing compile step is reported as compile_error and a failing run step as runtime_error, the time and usage of a step are added to the ones of its phase
+	Phase *StepPhase `json:"Phase,omitempty"`
+
+	// TTL seconds the command may run, CompileTTL or RunTTL of the template by phase if not set
+	TTL *int `json:"TTL,omitempty"`
+}
+
+// ActionStepOutput all streams the output of the step, on_error only returns it if the step fails, none discards it
+type ActionStepOutput string
+
+// Artifact File written by the program which matches an artifact glob of the action
+type Artifact struct {
+	// Content file content, empty if the file exceeds the size caps of the sandbox
+	Content  []byte `json:"Content"`
+	MimeType string `json:"MimeType"`
+
+	// Name path relative to the workdir
+	Name string `json:"Name"`
+	Size int64  `json:"Size"`
+}
+
 // CommonSubmissionRequest defines model for CommonSubmissionRequest.
 type CommonSubmissionRequest struct {
 	ActionId *string `json:"ActionId,omitempty"`
@@ -70,18 +237,88 @@ type ContainerOptions struct {
 	RunTTL      *int `json:"RunTTL,omitempty"`
 }
 
+// Diagnostic defines model for Diagnostic.
+type Diagnostic struct {
+	// Column 1-based column, 0 if the compiler doesn't report it
+	Column int `json:"Column"`
+
+	// File path of the file relative to the workdir
+	File     string             `json:"File"`
+	Line     int                `json:"Line"`
+	Message  string             `json:"Message"`
+	Severity DiagnosticSeverity `json:"Severity"`
+}
+
+// DiagnosticSeverity defines model for Diagnostic.Severity.
+type DiagnosticSeverity string
+
+// ExecutionResult defines model for ExecutionResult.
+type ExecutionResult struct {
+	ExitCode int     `json:"ExitCode"`
+	Signal   *string `json:"Signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"Status"`
+}
+
+// ExecutionStatus ok, compile_error and runtime_error if the compile or run command exited with
+// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+// internal_error if the sandbox failed to run the submission
+type ExecutionStatus string
+
+// FileMeta defines model for FileMeta.
+type FileMeta struct {
+	// Encoding base64 for binary files whose content is base64 encoded
+	Encoding *FileMetaEncoding `json:"Encoding,omitempty"`
+
+	// Mode permission bits of the file, like 493 (0755) for an executable script
+	Mode *int `json:"Mode,omitempty"`
+}
+
+// FileMetaEncoding base64 for binary files whose content is base64 encoded
+type FileMetaEncoding string
+
+// FormatRequest defines model for FormatRequest.
+type FormatRequest struct {
+	ActionId *string           `json:"ActionId,omitempty"`
+	Files    map[string]string `json:"Files"`
+
+	// FilesMeta encoding and mode of the files, by the file name
+	FilesMeta  *map[string]FileMeta `json:"FilesMeta,omitempty"`
+	TemplateId string               `json:"TemplateId"`
+}
+
+// FormatResponse defines model for FormatResponse.
+type FormatResponse struct {
+	// Error output of the formatter if it failed, like syntax errors; Files is empty then
+	Error *string `json:"Error,omitempty"`
+
+	// Files formatted text files, by the file name; binary files aren't returned
+	Files map[string]string `json:"Files"`
+}
+
 // ImageActionConfig defines model for ImageActionConfig.
 type ImageActionConfig struct {
+	// Artifacts globs of the files which are returned after the run, relative to the workdir (like out/*.png)
+	Artifacts    *[]string         `json:"Artifacts,omitempty"`
 	CompileCmd   string            `json:"CompileCmd"`
 	DefaultFiles map[string]string `json:"DefaultFiles"`
 
 	// EnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
 	EnableExternalCommands ImageActionConfigEnableExternalCommands `json:"EnableExternalCommands"`
-	Id                     string                                  `json:"Id"`
-	IsDefault              bool                                    `json:"IsDefault"`
-	Name                   string                                  `json:"Name"`
-	RunCmd                 string                                  `json:"RunCmd"`
-	ScriptOptions          ImageConfigScriptOptions                `json:"ScriptOptions"`
+
+	// FormatCmd command formatting the files in place in the workdir, like gofmt -w .
+	FormatCmd     *string                  `json:"FormatCmd,omitempty"`
+	Id            string                   `json:"Id"`
+	IsDefault     bool                     `json:"IsDefault"`
+	Name          string                   `json:"Name"`
+	RunCmd        string                   `json:"RunCmd"`
+	ScriptOptions ImageConfigScriptOptions `json:"ScriptOptions"`
+
+	// Steps ordered steps replacing CompileCmd and RunCmd, like install, build, test and run. ExternalOptions don't override them
+	Steps *[]ActionStep `json:"Steps,omitempty"`
 }
 
 // ImageActionConfigEnableExternalCommands It allows overriding CompileCmd and RunCmd in each request.
@@ -91,6 +328,9 @@ type ImageActionConfigEnableExternalCommands string
 type ImageConfig struct {
 	Actions map[string]ImageActionConfig `json:"Actions"`
 
+	// Cacheable The programs are deterministic, so the playground may cache their results.
+	Cacheable bool `json:"Cacheable"`
+
 	// Connections Databases. Currently available only ['postgres']
 	Connections      []string         `json:"Connections"`
 	ContainerOptions ContainerOptions `json:"ContainerOptions"`
@@ -110,6 +350,9 @@ type ImageConfigScriptOptions struct {
 
 // ImageTemplateConfig defines model for ImageTemplateConfig.
 type ImageTemplateConfig struct {
+	// Cacheable The programs are deterministic, so the playground may cache their results.
+	Cacheable bool `json:"Cacheable"`
+
 	// Connections Databases. Currently available only ['postgres']
 	Connections      []string         `json:"Connections"`
 	ContainerOptions ContainerOptions `json:"ContainerOptions"`
@@ -122,13 +365,201 @@ type ImageTemplateConfig struct {
 	Workdir          string           `json:"Workdir"`
 }
 
+// JobResponse defines model for JobResponse.
+type JobResponse struct {
+	CreatedAt time.Time `json:"CreatedAt"`
+	Error     *string   `json:"Error,omitempty"`
+	Id        string    `json:"Id"`
+
+	// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+	Problem   *Problem            `json:"Problem,omitempty"`
+	Response  *SubmissionResponse `json:"Response,omitempty"`
+	Status    JobStatus           `json:"Status"`
+	UpdatedAt time.Time           `json:"UpdatedAt"`
+}
+
+// JobStatus defines model for JobStatus.
+type JobStatus string
+
+// JudgeCase defines model for JudgeCase.
+type JudgeCase struct {
+	Args string `json:"Args"`
+
+	// Compare exact compares byte by byte, trimmed ignores trailing whitespace of lines and
+	// trailing empty lines, float compares numbers with the case tolerance
+	Compare        *JudgeCompareMode `json:"Compare,omitempty"`
+	ExpectedStdout string            `json:"ExpectedStdout"`
+	Stdin          string            `json:"Stdin"`
+
+	// Tolerance absolute or relative tolerance of the float mode, 1e-6 by default
+	Tolerance *float64 `json:"Tolerance,omitempty"`
+}
+
+// JudgeCaseResult defines model for JudgeCaseResult.
+type JudgeCaseResult struct {
+	ExitCode int     `json:"ExitCode"`
+	Stderr   string  `json:"Stderr"`
+	Stdout   string  `json:"Stdout"`
+	Time     float32 `json:"Time"`
+
+	// Verdict not_run is the verdict of the cases which weren't run, or not to the end, because
+	// the time of the request was used up before
+	Verdict JudgeVerdict `json:"Verdict"`
+}
+
+// JudgeCompareMode exact compares byte by byte, trimmed ignores trailing whitespace of lines and
+// trailing empty lines, float compares numbers with the case tolerance
+type JudgeCompareMode string
+
+// JudgeRequest defines model for JudgeRequest.
+type JudgeRequest struct {
+	ActionId *string     `json:"ActionId,omitempty"`
+	Args     string      `json:"Args"`
+	Cases    []JudgeCase `json:"Cases"`
+
+	// ExternalOptions external options like CompileCmd or RunCmd
+	ExternalOptions *map[string]string `json:"ExternalOptions,omitempty"`
+	Files           map[string]string  `json:"Files"`
+
+	// FilesMeta encoding and mode of the files, by the file name
+	FilesMeta *map[string]FileMeta `json:"FilesMeta,omitempty"`
+
+	// Stdin data which will available via stdin reader
+	Stdin      string `json:"Stdin"`
+	TemplateId string `json:"TemplateId"`
+
+	// TimeLimit seconds per case, limited by the RunTTL of the template
+	TimeLimit *float32 `json:"TimeLimit,omitempty"`
+}
+
+// JudgeResponse defines model for JudgeResponse.
+type JudgeResponse struct {
+	Cases          []JudgeCaseResult `json:"Cases"`
+	CompileOutput  *string           `json:"CompileOutput,omitempty"`
+	RunEnvironment RunEnvironment    `json:"RunEnvironment"`
+
+	// Verdict not_run is the verdict of the cases which weren't run, or not to the end, because
+	// the time of the request was used up before
+	Verdict JudgeVerdict `json:"Verdict"`
+}
+
+// JudgeVerdict not_run is the verdict of the cases which weren't run, or not to the end, because
+// the time of the request was used up before
+type JudgeVerdict string
+
+// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+type Problem struct {
+	// Code Stable code of the error, clients should rely on it instead of the title or detail.
+	Code   ProblemCode `json:"code"`
+	Detail *string     `json:"detail,omitempty"`
+	Status int         `json:"status"`
+	Title  string      `json:"title"`
+
+	// Type URI of the problem type, urn:codenire:problem:<code>
+	Type string `json:"type"`
+}
+
+// ProblemCode Stable code of the error, clients should rely on it instead of the title or detail.
+type ProblemCode string
+
+// ResourceUsage Resources used by the processes of a compile or run command, measured from the container stats
+type ResourceUsage struct {
+	// CPUSystemTime CPU time in kernel mode, in seconds
+	CPUSystemTime float32 `json:"CPUSystemTime"`
+
+	// CPUUserTime CPU time in user mode, in seconds
+	CPUUserTime float32 `json:"CPUUserTime"`
+
+	// PeakMemory peak memory usage of the container while the command runs, in bytes. It's the high-water
+	// mark of the memory cgroup if the command raised it, otherwise the usage without the page
+	// cache sampled while the command runs
+	PeakMemory int64 `json:"PeakMemory"`
+
+	// Processes peak number of processes started by the command sampled while it runs
+	Processes int `json:"Processes"`
+}
+
 // RunEnvironment defines model for RunEnvironment.
 type RunEnvironment struct {
 	ActionName  string  `json:"ActionName"`
 	CompileCmd  string  `json:"CompileCmd"`
 	CompileTime float32 `json:"CompileTime"`
-	RunCmd      string  `json:"RunCmd"`
-	RunTime     float32 `json:"RunTime"`
+
+	// CompileUsage Resources used by the processes of a compile or run command, measured from the container stats
+	CompileUsage *ResourceUsage `json:"CompileUsage,omitempty"`
+	RunCmd       string         `json:"RunCmd"`
+	RunTime      float32        `json:"RunTime"`
+
+	// RunUsage Resources used by the processes of a compile or run command, measured from the container stats
+	RunUsage *ResourceUsage `json:"RunUsage,omitempty"`
+
+	// Steps results of the steps of a multi-step action, in order
+	Steps *[]StepResult `json:"Steps,omitempty"`
+}
+
+// SandboxActiveRun run, judge or format request which holds a container
+type SandboxActiveRun struct {
+	Action string `json:"Action"`
+
+	// Age seconds since the request got its container
+	Age         float32   `json:"Age"`
+	ContainerId string    `json:"ContainerId"`
+	Id          string    `json:"Id"`
+	StartedAt   time.Time `json:"StartedAt"`
+	Template    string    `json:"Template"`
+}
+
+// SandboxJudgeCase defines model for SandboxJudgeCase.
+type SandboxJudgeCase struct {
+	Args  string `json:"args"`
+	Stdin string `json:"stdin"`
+}
+
+// SandboxJudgeCaseResult defines model for SandboxJudgeCaseResult.
+type SandboxJudgeCaseResult struct {
+	ExitCode int `json:"exitCode"`
+
+	// NotRun the case wasn't run, or not to the end, because the time of the request was used up
+	NotRun bool `json:"notRun"`
+
+	// OomKilled a process of the case was killed for running out of memory
+	OomKilled bool    `json:"oomKilled"`
+	Stderr    []byte  `json:"stderr"`
+	Stdout    []byte  `json:"stdout"`
+	Time      float32 `json:"time"`
+	TimedOut  bool    `json:"timedOut"`
+}
+
+// SandboxJudgeRequest defines model for SandboxJudgeRequest.
+type SandboxJudgeRequest struct {
+	Action string `json:"action"`
+	Args   string `json:"args"`
+
+	// Binary files in tar archive encoded with base64
+	Binary          string             `json:"binary"`
+	Cases           []SandboxJudgeCase `json:"cases"`
+	ExtendedOptions *map[string]string `json:"extendedOptions,omitempty"`
+	SandId          string             `json:"sandId"`
+
+	// Stdin data which will available via stdin reader
+	Stdin     string   `json:"stdin"`
+	TimeLimit *float32 `json:"timeLimit,omitempty"`
+}
+
+// SandboxJudgeResponse defines model for SandboxJudgeResponse.
+type SandboxJudgeResponse struct {
+	RunEnvironment RunEnvironment           `json:"RunEnvironment"`
+	Cases          []SandboxJudgeCaseResult `json:"cases"`
+	CompileFailed  bool                     `json:"compileFailed"`
+	CompileOutput  *[]byte                  `json:"compileOutput,omitempty"`
+	Error          *string                  `json:"error,omitempty"`
+}
+
+// SandboxKillAllResponse defines model for SandboxKillAllResponse.
+type SandboxKillAllResponse struct {
+	// Generation generation of the containers started from now on
+	Generation int64 `json:"Generation"`
+	KilledRuns int   `json:"KilledRuns"`
 }
 
 // SandboxRequest defines model for SandboxRequest.
@@ -148,10 +579,127 @@ type SandboxRequest struct {
 // SandboxResponse defines model for SandboxResponse.
 type SandboxResponse struct {
 	RunEnvironment RunEnvironment `json:"RunEnvironment"`
+	Artifacts      *[]Artifact    `json:"artifacts,omitempty"`
 	Error          *string        `json:"error,omitempty"`
 	ExitCode       int            `json:"exitCode"`
-	Stderr         []byte         `json:"stderr"`
-	Stdout         []byte         `json:"stdout"`
+	Signal         *string        `json:"signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"status"`
+	Stderr []byte          `json:"stderr"`
+	Stdout []byte          `json:"stdout"`
+}
+
+// SandboxStreamEvent defines model for SandboxStreamEvent.
+type SandboxStreamEvent struct {
+	// Artifacts artifacts of the run, sent with the done event
+	Artifacts *[]Artifact `json:"Artifacts,omitempty"`
+	Data      []byte      `json:"Data"`
+
+	// Kind stdout, stderr, phase, error or done
+	Kind string `json:"Kind"`
+
+	// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+	Problem        *Problem         `json:"Problem,omitempty"`
+	Result         *ExecutionResult `json:"Result,omitempty"`
+	RunEnvironment *RunEnvironment  `json:"RunEnvironment,omitempty"`
+}
+
+// SandboxTemplateStatus defines model for SandboxTemplateStatus.
+type SandboxTemplateStatus struct {
+	ActiveRuns int `json:"ActiveRuns"`
+
+	// State draining templates reject new runs and let the running ones finish, disabled templates also kill the running ones and aren't listed in /templates
+	State          SandboxTemplateStatusState `json:"State"`
+	Template       string                     `json:"Template"`
+	WarmContainers int                        `json:"WarmContainers"`
+}
+
+// SandboxTemplateStatusState draining templates reject new runs and let the running ones finish, disabled templates also kill the running ones and aren't listed in /templates
+type SandboxTemplateStatusState string
+
+// SandboxWarmContainer started container of a template waiting for a run
+type SandboxWarmContainer struct {
+	// Age seconds since the container was started
+	Age float32 `json:"Age"`
+
+	// Generation generation of the sandbox containers, incremented by kill-all
+	Generation int64     `json:"Generation"`
+	Id         string    `json:"Id"`
+	StartedAt  time.Time `json:"StartedAt"`
+	Template   string    `json:"Template"`
+}
+
+// ShareResponse defines model for ShareResponse.
+type ShareResponse struct {
+	// Id Snippet ID, the submission is served at /p/{Id}
+	Id string `json:"Id"`
+}
+
+// StepPhase a failing compile step is reported as compile_error and a failing run step as runtime_error, the time and usage of a step are added to the ones of its phase
+type StepPhase string
+
+// StepResult defines model for StepResult.
+type StepResult struct {
+	Cmd string `json:"Cmd"`
+
+	// ExitCode exit code of the command, -1 if it didn't exit on its own or was skipped
+	ExitCode int    `json:"ExitCode"`
+	Name     string `json:"Name"`
+
+	// Phase a failing compile step is reported as compile_error and a failing run step as runtime_error, the time and usage of a step are added to the ones of its phase
+	Phase StepPhase `json:"Phase"`
+
+	// Status skipped if an earlier step failed and stopped the action
+	Status StepResultStatus `json:"Status"`
+
+	// Time wall-clock time of the command, in seconds
+	Time float32 `json:"Time"`
+
+	// Usage Resources used by the processes of a compile or run command, measured from the container stats
+	Usage *ResourceUsage `json:"Usage,omitempty"`
+}
+
+// StepResultStatus skipped if an earlier step failed and stopped the action
+type StepResultStatus string
+
+// StreamDoneEvent defines model for StreamDoneEvent.
+type StreamDoneEvent struct {
+	ActionName  string      `json:"ActionName"`
+	Artifacts   *[]Artifact `json:"Artifacts,omitempty"`
+	CompileCmd  string      `json:"CompileCmd"`
+	CompileTime float32     `json:"CompileTime"`
+
+	// CompileUsage Resources used by the processes of a compile or run command, measured from the container stats
+	CompileUsage *ResourceUsage `json:"CompileUsage,omitempty"`
+	ExitCode     int            `json:"ExitCode"`
+	RunCmd       string         `json:"RunCmd"`
+	RunTime      float32        `json:"RunTime"`
+
+	// RunUsage Resources used by the processes of a compile or run command, measured from the container stats
+	RunUsage *ResourceUsage `json:"RunUsage,omitempty"`
+	Signal   *string        `json:"Signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"Status"`
+
+	// Steps results of the steps of a multi-step action, in order
+	Steps *[]StepResult `json:"Steps,omitempty"`
+}
+
+// SubmissionArchiveRequest defines model for SubmissionArchiveRequest.
+type SubmissionArchiveRequest struct {
+	ActionId *string `json:"ActionId,omitempty"`
+
+	// Archive .zip or .tar.gz archive of the project, sent as file part
+	Archive    string  `json:"Archive"`
+	Args       *string `json:"Args,omitempty"`
+	Stdin      *string `json:"Stdin,omitempty"`
+	TemplateId string  `json:"TemplateId"`
 }
 
 // SubmissionRequest defines model for SubmissionRequest.
@@ -163,6 +711,9 @@ type SubmissionRequest struct {
 	ExternalOptions *map[string]string `json:"ExternalOptions,omitempty"`
 	Files           map[string]string  `json:"Files"`
 
+	// FilesMeta encoding and mode of the files, by the file name
+	FilesMeta *map[string]FileMeta `json:"FilesMeta,omitempty"`
+
 	// Stdin data which will available via stdin reader
 	Stdin      string `json:"Stdin"`
 	TemplateId string `json:"TemplateId"`
@@ -170,12 +721,31 @@ type SubmissionRequest struct {
 
 // SubmissionResponse defines model for SubmissionResponse.
 type SubmissionResponse struct {
-	Events         []SubmissionResponseEvents `json:"Events"`
-	RunEnvironment RunEnvironment             `json:"RunEnvironment"`
+	// Annotations values added by the run hooks
+	Annotations *map[string]string `json:"Annotations,omitempty"`
+	Artifacts   *[]Artifact        `json:"Artifacts,omitempty"`
+
+	// Diagnostics errors and warnings parsed from the compiler output of a compile_error
+	Diagnostics *[]Diagnostic              `json:"Diagnostics,omitempty"`
+	Events      []SubmissionResponseEvents `json:"Events"`
+
+	// ExitCode exit code of the failed command, -1 if it didn't exit on its own
+	ExitCode       int            `json:"ExitCode"`
+	RunEnvironment RunEnvironment `json:"RunEnvironment"`
+
+	// Signal signal which terminated the failed command, like SIGKILL
+	Signal *string `json:"Signal,omitempty"`
+
+	// Status ok, compile_error and runtime_error if the compile or run command exited with
+	// a non-zero code, timeout, oom_killed if the container ran out of memory, and
+	// internal_error if the sandbox failed to run the submission
+	Status ExecutionStatus `json:"Status"`
 }
 
 // SubmissionResponseEvents defines model for SubmissionResponseEvents.
 type SubmissionResponseEvents struct {
+	// Delay nanoseconds to wait before showing the message, from the playback headers of faketime programs
+	Delay   int64  `json:"Delay"`
 	Kind    string `json:"Kind"`
 	Message string `json:"Message"`
 }
@@ -194,14 +764,76 @@ type SubmissionScriptRequest struct {
 	TemplateId string `json:"TemplateId"`
 }
 
+// TemplateChange defines model for TemplateChange.
+type TemplateChange struct {
+	Kind     TemplateChangeKind `json:"Kind"`
+	Template string             `json:"Template"`
+	Version  string             `json:"Version"`
+}
+
+// TemplateChangeKind defines model for TemplateChange.Kind.
+type TemplateChangeKind string
+
 // TemplateItemResponse defines model for TemplateItemResponse.
 type TemplateItemResponse struct {
 	Actions    *[]string `json:"Actions,omitempty"`
 	TemplateId *string   `json:"TemplateId,omitempty"`
 }
 
+// TerminalControl Text message sent to a terminal session to control it.
+type TerminalControl struct {
+	Cols *int                `json:"Cols,omitempty"`
+	Rows *int                `json:"Rows,omitempty"`
+	Type TerminalControlType `json:"Type"`
+}
+
+// TerminalControlType defines model for TerminalControl.Type.
+type TerminalControlType string
+
+// TerminalEvent Text message sent by a terminal session.
+type TerminalEvent struct {
+	// Kind phase, error or done, the session is closed after the done event
+	Kind string `json:"Kind"`
+
+	// Message the phase or the error
+	Message *string `json:"Message,omitempty"`
+
+	// Problem Error response in the RFC 7807 problem details format (application/problem+json).
+	Problem *Problem         `json:"Problem,omitempty"`
+	Result  *StreamDoneEvent `json:"Result,omitempty"`
+}
+
+// TerminalSession defines model for TerminalSession.
+type TerminalSession struct {
+	ExpiresAt time.Time `json:"ExpiresAt"`
+
+	// Id Session ID, the terminal is connected at /run/terminal/{Id}
+	Id string `json:"Id"`
+}
+
+// FormatCodeJSONRequestBody defines body for FormatCode for application/json ContentType.
+type FormatCodeJSONRequestBody = FormatRequest
+
+// CreateSubmissionJobJSONRequestBody defines body for CreateSubmissionJob for application/json ContentType.
+type CreateSubmissionJobJSONRequestBody = SubmissionRequest
+
+// JudgeSubmissionJSONRequestBody defines body for JudgeSubmission for application/json ContentType.
+type JudgeSubmissionJSONRequestBody = JudgeRequest
+
 // RunFilesSubmissionJSONRequestBody defines body for RunFilesSubmission for application/json ContentType.
 type RunFilesSubmissionJSONRequestBody = SubmissionRequest
 
+// RunArchiveSubmissionMultipartRequestBody defines body for RunArchiveSubmission for multipart/form-data ContentType.
+type RunArchiveSubmissionMultipartRequestBody = SubmissionArchiveRequest
+
 // RunScriptSubmissionJSONRequestBody defines body for RunScriptSubmission for application/json ContentType.
 type RunScriptSubmissionJSONRequestBody = SubmissionScriptRequest
+
+// RunFilesSubmissionStreamJSONRequestBody defines body for RunFilesSubmissionStream for application/json ContentType.
+type RunFilesSubmissionStreamJSONRequestBody = SubmissionRequest
+
+// CreateTerminalSessionJSONRequestBody defines body for CreateTerminalSession for application/json ContentType.
+type CreateTerminalSessionJSONRequestBody = SubmissionRequest
+
+// ShareSubmissionJSONRequestBody defines body for ShareSubmission for application/json ContentType.
+type ShareSubmissionJSONRequestBody = SubmissionRequest
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	contract "sandbox/api/gen"
+)
+
+// MaxArtifacts bounds the number of files returned as artifacts of a run.
+const MaxArtifacts = 20
+
+// collectArtifacts copies the files matching the artifact globs of the action
+// out of the workdir of the container. Files over the size caps are returned
+// without content.
+func (s *submission) collectArtifacts(ctx context.Context) ([]contract.Artifact, error) {
+	if s.action.Artifacts == nil || len(*s.action.Artifacts) == 0 {
+		return nil, nil
+	}
+
+	var artifacts []contract.Artifact
+	total := int64(0)
+
+	err := walkWorkdir(ctx, *s.cont, func(name string, hdr *tar.Header, r io.Reader) (bool, error) {
+		if !matchArtifact(*s.action.Artifacts, name) {
+			return true, nil
+		}
+
+		artifact := contract.Artifact{
+			Name:     name,
+			MimeType: mime.TypeByExtension(path.Ext(name)),
+			Size:     hdr.Size,
+			Content:  []byte{},
+		}
+
+		if hdr.Size <= *artifactMaxSize && total+hdr.Size <= *artifactsMaxSize {
+			content, err := io.ReadAll(io.LimitReader(r, hdr.Size))
+			if err != nil {
+				return false, fmt.Errorf("read artifact %s: %w", name, err)
+			}
+			artifact.Content = content
+			total += hdr.Size
+		} else {
+			log.Printf("artifact %s of %d bytes exceeds the size caps", name, hdr.Size)
+		}
+
+		if artifact.MimeType == "" {
+			artifact.MimeType = http.DetectContentType(artifact.Content)
+		}
+
+		artifacts = append(artifacts, artifact)
+
+		return len(artifacts) < MaxArtifacts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return artifacts, nil
+}
+
+// walkWorkdir calls fn for every regular file in the workdir of the container
+// with its path relative to the workdir, until fn returns false.
+func walkWorkdir(ctx context.Context, cont StartedContainer, fn func(name string, hdr *tar.Header, r io.Reader) (bool, error)) error {
+	workdir := path.Clean(cont.Image.Workdir)
+	rc, err := codenireManager.CopyFromContainer(ctx, cont, workdir)
+	if err != nil {
+		return fmt.Errorf("copy workdir from container: %w", err)
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read workdir archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// The entries are rooted at the base name of the workdir.
+		_, name, ok := strings.Cut(path.Clean(hdr.Name), "/")
+		if !ok {
+			continue
+		}
+
+		next, err := fn(name, hdr, tr)
+		if err != nil || !next {
+			return err
+		}
+	}
+}
+
+// addArtifacts adds the artifacts of the run to res. Failures to collect them
+// are only logged, the run itself has finished.
+func (s *submission) addArtifacts(ctx context.Context, res *contract.SandboxResponse) {
+	artifacts, err := s.collectArtifacts(ctx)
+	if err != nil {
+		log.Printf("collect artifacts of %s: %v", s.cont.CId, err)
+		return
+	}
+
+	if len(artifacts) > 0 {
+		res.Artifacts = &artifacts
+	}
+}
+
+// matchArtifact reports whether the file matches one of the globs. A glob
+// ending in "/**" matches every file below the directory.
+func matchArtifact(globs []string, name string) bool {
+	for _, glob := range globs {
+		if dir, ok := strings.CutSuffix(glob, "/**"); ok {
+			if strings.HasPrefix(name, dir+"/") {
+				return true
+			}
+			continue
+		}
+
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
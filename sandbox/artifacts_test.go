@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	contract "sandbox/api/gen"
+)
+
+func TestMatchArtifact(t *testing.T) {
+	globs := []string{"out/*.png", "plots/**"}
+
+	cases := []struct {
+		name string
+		want bool
+	}{
+		{"out/chart.png", true},
+		{"out/chart.svg", false},
+		{"out/nested/chart.png", false},
+		{"plots/a.svg", true},
+		{"plots/2024/b.svg", true},
+		{"plots", false},
+		{"plotsx/a.svg", false},
+		{"main.go", false},
+	}
+
+	for _, c := range cases {
+		if got := matchArtifact(globs, c.name); got != c.want {
+			t.Errorf("matchArtifact(%v, %q) = %v, want %v", globs, c.name, got, c.want)
+		}
+	}
+}
+
+func TestCollectArtifacts(t *testing.T) {
+	oldMax := *artifactMaxSize
+	t.Cleanup(func() { *artifactMaxSize = oldMax })
+	*artifactMaxSize = 8
+
+	useFakeOrchestrator(t, &fakeOrchestrator{workdir: workdirArchive(t, "app", map[string]string{
+		"main.py":          "print(1)",
+		"out/chart.png":    "\x89PNG",
+		"out/sub/deep.png": "\x89PNG",
+		"plots/a/b.svg":    "<svg/>",
+		"plots/large.csv":  strings.Repeat("1,2\n", 10),
+	})})
+
+	sub := &submission{
+		cont:   &StartedContainer{Image: BuiltImage{ImageConfig: contract.ImageConfig{Workdir: "/app/"}}},
+		action: contract.ImageActionConfig{Artifacts: &[]string{"out/*.png", "plots/**"}},
+	}
+
+	artifacts, err := sub.collectArtifacts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]contract.Artifact)
+	for _, a := range artifacts {
+		got[a.Name] = a
+	}
+	if len(got) != 3 {
+		t.Fatalf("artifacts = %v, want out/chart.png, plots/a/b.svg and plots/large.csv", got)
+	}
+
+	if a := got["out/chart.png"]; string(a.Content) != "\x89PNG" || a.MimeType != "image/png" {
+		t.Errorf("out/chart.png = %+v", a)
+	}
+	if a := got["plots/a/b.svg"]; string(a.Content) != "<svg/>" {
+		t.Errorf("plots/a/b.svg = %+v", a)
+	}
+	// Files over the size cap are listed without their content.
+	if a := got["plots/large.csv"]; a.Size != 40 || len(a.Content) != 0 {
+		t.Errorf("plots/large.csv = size %d, %d bytes of content, want the size only", a.Size, len(a.Content))
+	}
+}
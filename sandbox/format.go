@@ -0,0 +1,110 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	contract "sandbox/api/gen"
+	"sandbox/internal"
+)
+
+// formatHandler formats the request files with the format command of the
+// action in a warm container and returns the formatted files.
+func formatHandler(w http.ResponseWriter, r *http.Request) {
+	var req contract.SandboxRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendProblem(w, newProblem(http.StatusBadRequest, contract.InvalidRequest, "invalid request: %v", err))
+		return
+	}
+
+	res, err := formatSubmission(r.Context(), req)
+	var problem *problemError
+	if errors.As(err, &problem) {
+		sendProblem(w, problem)
+		return
+	}
+	if err != nil {
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "%v", err))
+		return
+	}
+
+	body, err := json.Marshal(res)
+	if err != nil {
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "error encoding JSON"))
+		log.Printf("json marshal: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// formatSubmission runs the format command in the workdir of the submission
+// and reads the request files back. A failing formatter, usually because of
+// syntax errors, is reported in the Error of the response.
+func formatSubmission(ctx context.Context, req contract.SandboxRequest) (*contract.FormatResponse, error) {
+	names, err := internal.RequestFileNames(req)
+	if err != nil {
+		return nil, newProblem(http.StatusBadRequest, contract.InvalidRequest, "decode files failed: %v", err)
+	}
+
+	sub, err := prepareSubmission(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.close()
+	ctx = sub.ctx
+
+	if sub.action.FormatCmd == nil || *sub.action.FormatCmd == "" {
+		return nil, newProblem(http.StatusBadRequest, contract.FormatNotSupported, "action %s of template %s has no format command", req.Action, req.SandId)
+	}
+
+	res := &contract.FormatResponse{Files: map[string]string{}}
+
+	var output bytes.Buffer
+	formatCtx := registerCmdTimeout(ctx, sub.compileTTL())
+	err = execContainerShell(formatCtx, &output, &output, *sub.cont, *sub.action.FormatCmd, sub.cont.Image)
+	if err != nil {
+		msg := output.String()
+		if errors.Is(formatCtx.Err(), context.DeadlineExceeded) {
+			msg = "timeout formatting"
+		}
+		res.Error = &msg
+		return res, nil
+	}
+
+	submitted := make(map[string]bool, len(names))
+	for _, name := range names {
+		submitted[name] = true
+	}
+
+	err = walkWorkdir(ctx, *sub.cont, func(name string, hdr *tar.Header, r io.Reader) (bool, error) {
+		if !submitted[name] {
+			return true, nil
+		}
+
+		content, err := io.ReadAll(io.LimitReader(r, hdr.Size))
+		if err != nil {
+			return false, fmt.Errorf("read formatted file %s: %w", name, err)
+		}
+
+		// Binary files aren't formatted.
+		if utf8.Valid(content) {
+			res.Files[name] = string(content)
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -20,7 +20,8 @@ import (
 	"strings"
 )
 
-const maxFilesLimit = 1 * 1024 * 1024
+// MaxFileSize limits a single file of a request, it's set from the flags.
+var MaxFileSize int64 = 1 * 1024 * 1024
 
 // DirToTar creates a tar archive from the specified directory
 func DirToTar(sourceDir string) (bytes.Buffer, error) {
@@ -138,14 +139,50 @@ func SaveRequestFiles(req contract.SandboxRequest, destDir string) (stdinFile *s
 				_ = file.Close()
 			}()
 
+			// Files are copied byte-exact, so a truncated file is an error.
+			if header.Size > MaxFileSize {
+				return nil, fmt.Errorf("file %s too large (max %d bytes)", header.Name, MaxFileSize)
+			}
+
 			// Copy the content from tar archive to the file
-			limitedReader := io.LimitReader(tarReader, maxFilesLimit)
+			limitedReader := io.LimitReader(tarReader, MaxFileSize)
 
 			if _, err4 := io.Copy(file, limitedReader); err4 != nil {
 				return nil, fmt.Errorf("error writing to file %s: %w", targetPath, err4)
 			}
+
+			// The mode given to OpenFile is reduced by the umask.
+			if err = file.Chmod(mode.Perm()); err != nil {
+				return nil, fmt.Errorf("error setting mode of %s: %w", targetPath, err)
+			}
 		}
 	}
 
 	return stdinFile, nil
 }
+
+// RequestFileNames returns the names of the regular files in the archive of
+// the request, relative to the workdir.
+func RequestFileNames(req contract.SandboxRequest) ([]string, error) {
+	tarData, err := base64.StdEncoding.DecodeString(req.Binary)
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode error: %w", err)
+	}
+
+	var names []string
+
+	tarReader := tar.NewReader(bytes.NewReader(tarData))
+	for {
+		header, err2 := tarReader.Next()
+		if err2 == io.EOF {
+			return names, nil
+		}
+		if err2 != nil {
+			return nil, fmt.Errorf("error reading header: %w", err2)
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			names = append(names, filepath.ToSlash(filepath.Clean(header.Name)))
+		}
+	}
+}
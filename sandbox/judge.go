@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os/exec"
+	"syscall"
+	"time"
+
+	contract "sandbox/api/gen"
+)
+
+// judgeHandler compiles the submission once and runs it for every test case
+// of the request. The outputs are compared with the expected ones by the
+// playground, the sandbox only reports how every run ended.
+func judgeHandler(w http.ResponseWriter, r *http.Request) {
+	var req contract.SandboxJudgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendProblem(w, newProblem(http.StatusBadRequest, contract.InvalidRequest, "invalid request: %v", err))
+		return
+	}
+
+	res, err := judgeSubmission(r.Context(), req)
+	var problem *problemError
+	if errors.As(err, &problem) {
+		sendProblem(w, problem)
+		return
+	}
+	if err != nil {
+		msg := err.Error()
+		res.Error = &msg
+	}
+
+	body, err := json.Marshal(res)
+	if err != nil {
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "error encoding JSON"))
+		log.Printf("json marshal: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func judgeSubmission(ctx context.Context, req contract.SandboxJudgeRequest) (*contract.SandboxJudgeResponse, error) {
+	res := &contract.SandboxJudgeResponse{Cases: []contract.SandboxJudgeCaseResult{}}
+
+	inputs := make([]string, len(req.Cases))
+	for i, c := range req.Cases {
+		inputs[i] = c.Stdin
+	}
+
+	sub, err := prepareSubmission(ctx, contract.SandboxRequest{
+		Action:          req.Action,
+		Args:            req.Args,
+		Binary:          req.Binary,
+		ExtendedOptions: req.ExtendedOptions,
+		SandId:          req.SandId,
+		Stdin:           req.Stdin,
+	}, inputs)
+	if err != nil {
+		return res, err
+	}
+	defer sub.close()
+	ctx = sub.ctx
+
+	compileOut := &runOutput{}
+	compileRes := &contract.SandboxResponse{}
+	// The waiting for the container counts against the deadline of the request.
+	compiled, err := sub.compile(ctx, sub.timeLeft(sub.compileTTL()), compileOut, compileRes)
+	res.RunEnvironment = compileRes.RunEnvironment
+	res.RunEnvironment.ActionName = sub.action.Name
+	if err != nil {
+		// Only a compilation timeout is reported as error, which the submission is to blame for.
+		output := []byte(err.Error())
+		res.CompileFailed = true
+		res.CompileOutput = &output
+		return res, nil
+	}
+	if !compiled {
+		output := append(compileOut.stdout.Bytes(), compileOut.stderr.Bytes()...)
+		res.CompileFailed = true
+		res.CompileOutput = &output
+		return res, nil
+	}
+
+	timeLimit := sub.runTTL()
+	if req.TimeLimit != nil && *req.TimeLimit > 0 {
+		timeLimit = min(timeLimit, time.Duration(float64(*req.TimeLimit)*float64(time.Second)))
+	}
+
+	runCmd := sub.runCommand()
+	res.RunEnvironment.RunCmd = runCmd
+
+	// The cases share the time left until the deadline of the request.
+	runCtx, cancel := context.WithDeadline(ctx, sub.deadline)
+	defer cancel()
+
+	// The usage of all cases is measured together.
+	meter := startResourceMeter(ctx, *sub.cont, "judge")
+	defer func() { res.RunEnvironment.RunUsage = meter.finish(ctx) }()
+
+	oom := newOOMDetector(ctx, *sub.cont)
+
+	for i, c := range req.Cases {
+		if ctx.Err() != nil {
+			return res, context.Cause(ctx)
+		}
+
+		// The cases left when the deadline is over aren't run.
+		if runCtx.Err() != nil {
+			res.Cases = append(res.Cases, contract.SandboxJudgeCaseResult{
+				Stdout:   []byte{},
+				Stderr:   []byte{},
+				ExitCode: -1,
+				NotRun:   true,
+			})
+			continue
+		}
+
+		result := runCase(runCtx, sub, replacePlaceholders(runCmd, c.Args, &sub.inputFiles[i]), timeLimit)
+		if result.ExitCode != 0 && !result.TimedOut {
+			result.OomKilled = oom.killed(ctx, result.ExitCode)
+		}
+		res.RunEnvironment.RunTime += result.Time
+		res.Cases = append(res.Cases, result)
+	}
+
+	return res, nil
+}
+
+// runCase runs a single test case within the time limit.
+func runCase(ctx context.Context, sub *submission, cmd string, timeLimit time.Duration) contract.SandboxJudgeCaseResult {
+	var stdout, stderr bytes.Buffer
+
+	caseCtx, cancel := context.WithTimeout(ctx, timeLimit)
+	defer cancel()
+
+	start := time.Now()
+	runErr := execContainerShell(caseCtx, &stderr, &stdout, *sub.cont, cmd, sub.cont.Image)
+	elapsed := time.Since(start)
+	codenireManager.observeExecDuration(start, "run", sub.req.SandId)
+
+	result := contract.SandboxJudgeCaseResult{
+		Stdout: stdout.Bytes(),
+		Stderr: stderr.Bytes(),
+		Time:   float32(elapsed.Seconds()),
+	}
+
+	if errors.Is(caseCtx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+		result.ExitCode = -1
+		// Killing docker exec leaves the program running in the container,
+		// where it would slow down the following cases.
+		killContainerProcesses(*sub.cont)
+
+		// A case cut short by the deadline of the request didn't time out.
+		if ctx.Err() != nil && elapsed < timeLimit {
+			result.TimedOut = false
+			result.NotRun = true
+		}
+		return result
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	} else if runErr != nil {
+		result.ExitCode = -1
+		result.Stderr = append(result.Stderr, []byte(runErr.Error())...)
+	}
+
+	return result
+}
+
+// oomDetector tells the cases killed for running out of memory apart, which
+// run one after another in the same container.
+type oomDetector struct {
+	cont StartedContainer
+	// kills is the last read OOM kill counter of the container's cgroup,
+	// counted is false when it can't be read.
+	kills   uint64
+	counted bool
+}
+
+func newOOMDetector(ctx context.Context, cont StartedContainer) *oomDetector {
+	d := &oomDetector{cont: cont}
+
+	kills, err := oomKills(ctx, cont)
+	if err != nil {
+		log.Printf("read oom kills of %s: %v", cont.CId, err)
+		return d
+	}
+	d.kills, d.counted = kills, true
+
+	return d
+}
+
+// killed reports whether the failed case which just ended was killed for
+// running out of memory: the OOM kill counter increased since the last case.
+// Without the counter the container's OOM flag is checked for a case killed
+// with SIGKILL, the flag stays set after the first OOM kill.
+func (d *oomDetector) killed(ctx context.Context, exitCode int) bool {
+	if d.counted {
+		kills, err := oomKills(ctx, d.cont)
+		if err == nil {
+			killed := kills > d.kills
+			d.kills = kills
+			return killed
+		}
+		log.Printf("read oom kills of %s: %v", d.cont.CId, err)
+	}
+
+	if exitCode != 128+int(syscall.SIGKILL) {
+		return false
+	}
+
+	oomKilled, err := codenireManager.OOMKilled(ctx, d.cont)
+	if err != nil {
+		log.Printf("inspect container %s: %v", d.cont.CId, err)
+	}
+
+	return oomKilled
+}
+
+// killContainerProcesses kills every process of the container except its init process.
+func killContainerProcesses(cont StartedContainer) {
+	//nolint
+	if out, err := exec.Command("docker", "exec", cont.CId, "sh", "-c", "kill -9 -1").CombinedOutput(); err != nil {
+		log.Printf("kill processes of %s: %v, %s", cont.CId, err, out)
+	}
+}
@@ -74,12 +74,22 @@ var (
 	s3DockerfilesBucket   = flag.String("s3DockerfilesBucket", "", "s3 bucket with templates")
 	s3DockerfilesPrefix   = flag.String("s3DockerfilesPrefix", "", "prefix aka directory with templates")
 
+	maxFileSize = flag.Int64("maxFileSize", internal.MaxFileSize, "max size in bytes of a single submission file, at least the --max-file-size of the playground")
+
+	artifactMaxSize  = flag.Int64("artifactMaxSize", 1<<20, "max size in bytes of a single artifact returned after the run")
+	artifactsMaxSize = flag.Int64("artifactsMaxSize", 4<<20, "max total size in bytes of the artifacts of a run")
+
+	adminToken = flag.String("adminToken", "", "bearer token to enable the admin endpoints")
+
+	terminalIdleTimeout = flag.Duration("terminalIdleTimeout", time.Minute, "stop terminal sessions without input or output for this long")
+
 	runSem       chan struct{}
 	graceTimeout = 15 * time.Second
 )
 
 func main() {
 	flag.Parse()
+	internal.MaxFileSize = *maxFileSize
 
 	checkIsolation()
 
@@ -117,8 +127,19 @@ func main() {
 	h.Get("/health", healthHandler)
 
 	h.Post("/run", runHandler)
+	h.Post("/run/stream", runStreamHandler)
+	h.Get("/run/terminal", terminalHandler)
+	h.Post("/judge", judgeHandler)
+	h.Post("/fmt", formatHandler)
 	h.Get("/templates", listTemplatesHandler)
 
+	if *adminToken != "" {
+		h.Route("/admin", func(r chi.Router) {
+			r.Use(requireAdmin(*adminToken))
+			adminRoutes(r)
+		})
+	}
+
 	h.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		updateDatabaseCountMetric()
 		promhttp.Handler().ServeHTTP(w, r)
@@ -14,10 +14,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +29,7 @@ import (
 	docker "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/prometheus/client_golang/prometheus"
 
 	contract "sandbox/api/gen"
@@ -38,6 +41,10 @@ const imageTagPrefix = "codenire_play/"
 const codenireConfigName = "config.json"
 const defaultMemoryLimit = 100 << 20
 
+// warmPollInterval is how often the keepers of a paused template check
+// whether they may start containers again.
+const warmPollInterval = time.Second
+
 type StartedContainer struct {
 	CId    string
 	Image  BuiltImage
@@ -45,6 +52,14 @@ type StartedContainer struct {
 	DBName string
 }
 
+// WarmContainer is a started container waiting to be taken by a run.
+type WarmContainer struct {
+	StartedContainer
+
+	StartedAt  time.Time
+	Generation uint64
+}
+
 type BuiltImage struct {
 	contract.ImageConfig
 
@@ -57,6 +72,7 @@ type BuiltImage struct {
 type MetricsAware interface {
 	RegisterMetrics(registry prometheus.Registerer)
 	observeExecDuration(start time.Time, label, language string)
+	observeResourceUsage(label, language string, usage contract.ResourceUsage)
 }
 
 type ContainerOrchestrator interface {
@@ -68,6 +84,18 @@ type ContainerOrchestrator interface {
 	GetContainer(ctx context.Context, id string) (*StartedContainer, error)
 	KillAll()
 	KillContainer(StartedContainer) error
+	Generation() uint64
+	WarmContainers() []WarmContainer
+	KillWarmContainer(id string) (bool, error)
+	TemplateState(template string) contract.SandboxTemplateStatusState
+	SetTemplateState(template string, state contract.SandboxTemplateStatusState) error
+	OOMKilled(ctx context.Context, c StartedContainer) (bool, error)
+	ReadMemoryCgroup(ctx context.Context, c StartedContainer, v2File, v1File string) ([]byte, error)
+	Stats(ctx context.Context, c StartedContainer) (*docker.StatsResponse, error)
+	CopyFromContainer(ctx context.Context, c StartedContainer, path string) (io.ReadCloser, error)
+	ExecTerminal(ctx context.Context, c StartedContainer, cmd string) (*TerminalExec, error)
+	ResizeTerminal(ctx context.Context, execID string, rows, cols uint) error
+	ExecExitCode(ctx context.Context, execID string) (int, error)
 }
 
 type CodenireOrchestrator struct {
@@ -78,14 +106,26 @@ type CodenireOrchestrator struct {
 	imageContainers     map[string]chan StartedContainer
 	imgs                []BuiltImage
 
+	// The warm containers by ID. A container taken from imageContainers
+	// which isn't warm anymore has been stopped while it was waiting.
+	warm map[string]WarmContainer
+	// generation is incremented by KillAll, the resets of the templates are
+	// closed to make their keepers drop the containers they hold.
+	generation uint64
+	resets     map[string]chan struct{}
+	states     map[string]contract.SandboxTemplateStatusState
+
 	dockerClient *client.Client
-	killSignal   bool
+	killing      bool
 	isolated     bool
 
 	dockerFilesPath string
 
 	execDurationMetric  *prometheus.SummaryVec
 	runContainersMetric prometheus.Gauge
+	cpuTimeMetric       *prometheus.HistogramVec
+	peakMemoryMetric    *prometheus.HistogramVec
+	processesMetric     *prometheus.HistogramVec
 }
 
 func NewCodenireOrchestrator() *CodenireOrchestrator {
@@ -105,6 +145,24 @@ func NewCodenireOrchestrator() *CodenireOrchestrator {
 		Help: "Current number of run containers.",
 	})
 
+	cpuTimeMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sand_exec_cpu_seconds",
+		Help:    "CPU time (user and system) of compile and run commands in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"operation", "language"})
+
+	peakMemoryMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sand_exec_peak_memory_bytes",
+		Help:    "Peak memory usage of compile and run commands in bytes.",
+		Buckets: prometheus.ExponentialBuckets(1<<20, 2, 12),
+	}, []string{"operation", "language"})
+
+	processesMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sand_exec_processes",
+		Help:    "Peak number of processes of compile and run commands.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	}, []string{"operation", "language"})
+
 	c, err := client.NewClientWithOpts(client.WithVersion("1.41"))
 	if err != nil {
 		panic("fail on createDB docker client")
@@ -115,12 +173,18 @@ func NewCodenireOrchestrator() *CodenireOrchestrator {
 	return &CodenireOrchestrator{
 		dockerClient:        c,
 		imageContainers:     make(map[string]chan StartedContainer),
+		warm:                make(map[string]WarmContainer),
+		resets:              make(map[string]chan struct{}),
+		states:              make(map[string]contract.SandboxTemplateStatusState),
 		numSysWorkers:       runtime.NumCPU(),
 		idleContainersCount: *replicaContainerCnt,
 		dockerFilesPath:     *dockerFilesPath,
 		isolated:            *isolated,
 		execDurationMetric:  execDurationMetric,
 		runContainersMetric: runContainersMetric,
+		cpuTimeMetric:       cpuTimeMetric,
+		peakMemoryMetric:    peakMemoryMetric,
+		processesMetric:     processesMetric,
 	}
 }
 
@@ -131,9 +195,18 @@ func (m *CodenireOrchestrator) observeExecDuration(start time.Time, label, lang
 	m.execDurationMetric.WithLabelValues(label, lang).Observe(ms)
 }
 
+func (m *CodenireOrchestrator) observeResourceUsage(label, lang string, usage contract.ResourceUsage) {
+	m.cpuTimeMetric.WithLabelValues(label, lang).Observe(float64(usage.CPUUserTime + usage.CPUSystemTime))
+	m.peakMemoryMetric.WithLabelValues(label, lang).Observe(float64(usage.PeakMemory))
+	m.processesMetric.WithLabelValues(label, lang).Observe(float64(usage.Processes))
+}
+
 func (m *CodenireOrchestrator) RegisterMetrics(registry prometheus.Registerer) {
 	registry.MustRegister(m.execDurationMetric)
 	registry.MustRegister(m.runContainersMetric)
+	registry.MustRegister(m.cpuTimeMetric)
+	registry.MustRegister(m.peakMemoryMetric)
+	registry.MustRegister(m.processesMetric)
 	registry.MustRegister(dbCountMetric)
 }
 
@@ -188,24 +261,39 @@ func (m *CodenireOrchestrator) GetTemplates() []BuiltImage {
 }
 
 func (m *CodenireOrchestrator) GetContainer(ctx context.Context, id string) (*StartedContainer, error) {
-	select {
-	case c := <-m.getContainer(id):
-		return &c, nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	for {
+		select {
+		case c := <-m.getContainer(id):
+			if !m.takeWarm(c.CId) {
+				// Stopped by KillAll, a drain or the admin while it was waiting.
+				continue
+			}
+			return &c, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
 }
 
+// KillAll stops all sandbox containers and starts a new generation of warm
+// containers. It's safe to call while the sandbox is serving, runs holding a
+// container fail once it's stopped.
 func (m *CodenireOrchestrator) KillAll() {
 	m.Lock()
-	defer m.Unlock()
-
-	m.killSignal = true
+	m.killing = true
+	m.generation++
+	for template, reset := range m.resets {
+		close(reset)
+		delete(m.resets, template)
+	}
+	m.warm = make(map[string]WarmContainer)
+	m.Unlock()
 
 	defer func() {
 		// TODO:: remove tmp dirs
-		m.imageContainers = make(map[string]chan StartedContainer)
-		m.killSignal = false
+		m.Lock()
+		m.killing = false
+		m.Unlock()
 	}()
 
 	ctx := context.Background()
@@ -240,9 +328,225 @@ func (m *CodenireOrchestrator) KillAll() {
 		})
 	}
 	pool.StopAndWait()
+
+	// The containers of the runs are stopped as well, so
+	// KillContainer won't decrement the gauge for them.
+	m.runContainersMetric.Set(0)
 	log.Println("Killed all images")
 }
 
+// Generation returns the generation of the warm containers.
+func (m *CodenireOrchestrator) Generation() uint64 {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.generation
+}
+
+// WarmContainers returns the warm containers sorted by template and age.
+func (m *CodenireOrchestrator) WarmContainers() []WarmContainer {
+	m.Lock()
+	res := make([]WarmContainer, 0, len(m.warm))
+	for _, c := range m.warm {
+		res = append(res, c)
+	}
+	m.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Image.Template != res[j].Image.Template {
+			return res[i].Image.Template < res[j].Image.Template
+		}
+		return res[i].StartedAt.Before(res[j].StartedAt)
+	})
+
+	return res
+}
+
+// KillWarmContainer stops the warm container, false if there is none with the ID.
+// Its keeper starts another one.
+func (m *CodenireOrchestrator) KillWarmContainer(id string) (bool, error) {
+	m.Lock()
+	c, ok := m.warm[id]
+	delete(m.warm, id)
+	m.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	return true, m.KillContainer(c.StartedContainer)
+}
+
+// TemplateState returns whether the template is active, draining or disabled.
+func (m *CodenireOrchestrator) TemplateState(template string) contract.SandboxTemplateStatusState {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.templateState(template)
+}
+
+func (m *CodenireOrchestrator) templateState(template string) contract.SandboxTemplateStatusState {
+	if state, ok := m.states[template]; ok {
+		return state
+	}
+
+	return contract.Active
+}
+
+// SetTemplateState changes the state of the template. The warm containers of
+// a template which isn't active anymore are stopped and not replaced until
+// it's active again.
+func (m *CodenireOrchestrator) SetTemplateState(template string, state contract.SandboxTemplateStatusState) error {
+	found := false
+	for _, img := range m.imgs {
+		found = found || img.Template == template
+	}
+	if !found {
+		return fmt.Errorf("template `%s` not found", template)
+	}
+
+	m.Lock()
+	m.states[template] = state
+
+	var stopped []WarmContainer
+	if state != contract.Active {
+		if reset, ok := m.resets[template]; ok {
+			close(reset)
+			delete(m.resets, template)
+		}
+
+		for id, c := range m.warm {
+			if c.Image.Template == template {
+				stopped = append(stopped, c)
+				delete(m.warm, id)
+			}
+		}
+	}
+	m.Unlock()
+
+	for _, c := range stopped {
+		if err := m.KillContainer(c.StartedContainer); err != nil {
+			log.Printf("kill warm container %s: %v", c.CId, err)
+		}
+	}
+
+	return nil
+}
+
+// OOMKilled reports whether a process of the container was killed for running out of memory.
+func (m *CodenireOrchestrator) OOMKilled(ctx context.Context, c StartedContainer) (bool, error) {
+	info, err := m.dockerClient.ContainerInspect(ctx, c.CId)
+	if err != nil {
+		return false, err
+	}
+
+	return info.State != nil && info.State.OOMKilled, nil
+}
+
+// ReadMemoryCgroup reads a file of the memory cgroup of the container, which
+// it sees below /sys/fs/cgroup: v2File on cgroup v2, otherwise v1File.
+func (m *CodenireOrchestrator) ReadMemoryCgroup(ctx context.Context, c StartedContainer, v2File, v1File string) ([]byte, error) {
+	cmd := fmt.Sprintf("cat /sys/fs/cgroup/%s 2>/dev/null || cat /sys/fs/cgroup/memory/%s", v2File, v1File)
+
+	created, err := m.dockerClient.ContainerExecCreate(ctx, c.CId, docker.ExecOptions{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          []string{"sh", "-c", cmd},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create exec: %w", err)
+	}
+
+	resp, err := m.dockerClient.ContainerExecAttach(ctx, created.ID, docker.ExecAttachOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("attach exec: %w", err)
+	}
+	defer resp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err = stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return nil, fmt.Errorf("read exec output: %w", err)
+	}
+
+	// Neither file exists, for example in a gVisor container.
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("read memory cgroup: %s", bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Stats reads the current resource usage of the container.
+func (m *CodenireOrchestrator) Stats(ctx context.Context, c StartedContainer) (*docker.StatsResponse, error) {
+	resp, err := m.dockerClient.ContainerStatsOneShot(ctx, c.CId)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var stats docker.StatsResponse
+	if err = json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("decode stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// CopyFromContainer returns the tar archive of the path in the container.
+func (m *CodenireOrchestrator) CopyFromContainer(ctx context.Context, c StartedContainer, path string) (io.ReadCloser, error) {
+	rc, _, err := m.dockerClient.CopyFromContainer(ctx, c.CId, path)
+	return rc, err
+}
+
+// TerminalExec is a command running with a TTY in a container, its input
+// is written to and its output read from the hijacked connection.
+type TerminalExec struct {
+	types.HijackedResponse
+
+	ID string
+}
+
+// ExecTerminal starts the shell command in the workdir of the container
+// with a TTY attached to its standard streams.
+func (m *CodenireOrchestrator) ExecTerminal(ctx context.Context, c StartedContainer, cmd string) (*TerminalExec, error) {
+	created, err := m.dockerClient.ContainerExecCreate(ctx, c.CId, docker.ExecOptions{
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		WorkingDir:   c.Image.Workdir,
+		Cmd:          []string{"sh", "-c", cmd},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create exec: %w", err)
+	}
+
+	resp, err := m.dockerClient.ContainerExecAttach(ctx, created.ID, docker.ExecAttachOptions{Tty: true})
+	if err != nil {
+		return nil, fmt.Errorf("attach exec: %w", err)
+	}
+
+	return &TerminalExec{HijackedResponse: resp, ID: created.ID}, nil
+}
+
+// ResizeTerminal changes the size of the TTY of the exec.
+func (m *CodenireOrchestrator) ResizeTerminal(ctx context.Context, execID string, rows, cols uint) error {
+	return m.dockerClient.ContainerExecResize(ctx, execID, docker.ResizeOptions{Height: rows, Width: cols})
+}
+
+// ExecExitCode returns the exit code of a finished exec.
+func (m *CodenireOrchestrator) ExecExitCode(ctx context.Context, execID string) (int, error) {
+	info, err := m.dockerClient.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return 0, err
+	}
+	if info.Running {
+		return 0, fmt.Errorf("exec %s is still running", execID)
+	}
+
+	return info.ExitCode, nil
+}
+
 func (m *CodenireOrchestrator) KillContainer(c StartedContainer) (err error) {
 	defer func() {
 		m.removeSandboxDB(c.DBName)
@@ -460,26 +764,86 @@ func (m *CodenireOrchestrator) startContainers() {
 
 	for _, img := range m.imgs {
 		for i := 0; i < m.idleContainersCount; i++ {
-			go func() {
-				for {
-					if m.killSignal {
-						continue
-					}
-
-					c, err := m.runSndContainer(img)
-					if err != nil {
-						log.Printf("[DEBUG] Run container error. Template: %s. Error: %s", img.Template, err.Error())
-						time.Sleep(10 * time.Second)
-						continue
-					}
-
-					m.runContainersMetric.Inc()
-
-					m.getContainer(img.Template) <- *c
-				}
-			}()
+			go m.keepWarm(img)
+		}
+	}
+}
+
+// keepWarm keeps a started container of the template until a run takes it.
+// A container dropped by KillAll or a state change has been stopped already.
+func (m *CodenireOrchestrator) keepWarm(img BuiltImage) {
+	for {
+		gen, reset, ok := m.warmingAllowed(img.Template)
+		if !ok {
+			time.Sleep(warmPollInterval)
+			continue
 		}
+
+		c, err := m.runSndContainer(img)
+		if err != nil {
+			log.Printf("[DEBUG] Run container error. Template: %s. Error: %s", img.Template, err.Error())
+			time.Sleep(10 * time.Second)
+			continue
+		}
+
+		m.runContainersMetric.Inc()
+
+		if !m.addWarm(*c, gen) {
+			// KillAll or a drain started while the container was created.
+			if kErr := m.KillContainer(*c); kErr != nil {
+				log.Printf("kill warm container %s: %v", c.CId, kErr)
+			}
+			continue
+		}
+
+		select {
+		case m.getContainer(img.Template) <- *c:
+		case <-reset:
+		}
+	}
+}
+
+// warmingAllowed returns the generation and reset of the template if its
+// containers may be started.
+func (m *CodenireOrchestrator) warmingAllowed(template string) (uint64, chan struct{}, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.killing || m.templateState(template) != contract.Active {
+		return 0, nil, false
+	}
+
+	reset, ok := m.resets[template]
+	if !ok {
+		reset = make(chan struct{})
+		m.resets[template] = reset
+	}
+
+	return m.generation, reset, true
+}
+
+func (m *CodenireOrchestrator) addWarm(c StartedContainer, gen uint64) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.killing || gen != m.generation || m.templateState(c.Image.Template) != contract.Active {
+		return false
 	}
+
+	m.warm[c.CId] = WarmContainer{StartedContainer: c, StartedAt: time.Now(), Generation: gen}
+
+	return true
+}
+
+// takeWarm removes the container from the warm ones, false if it was stopped already.
+func (m *CodenireOrchestrator) takeWarm(id string) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	_, ok := m.warm[id]
+	delete(m.warm, id)
+
+	return ok
 }
 
 func (m *CodenireOrchestrator) getContainer(template string) chan StartedContainer {
@@ -529,12 +893,12 @@ func parseConfigFiles(root string) []contract.ImageConfig {
 
 		info, err := os.Stat(dir)
 		if err != nil {
-			log.Printf("err1", err)
+			log.Printf("err1: %s", err)
 			continue
 		}
 
 		if !info.IsDir() {
-			log.Printf("not dir", err)
+			log.Printf("not dir: %s", dir)
 			continue
 		}
 
@@ -572,6 +936,17 @@ func parseConfigFiles(root string) []contract.ImageConfig {
 			config.ContainerOptions.MemoryLimit = &memoryLimit
 		}
 
+		stepsValid := true
+		for _, actionConfig := range config.Actions {
+			if err := validateSteps(actionConfig, config.ContainerOptions); err != nil {
+				log.Printf("Invalid steps in %s: %s", config.Template, err.Error())
+				stepsValid = false
+			}
+		}
+		if !stepsValid {
+			continue
+		}
+
 		{
 			_, defaultExists := config.Actions[DefaultActionName]
 			var first *contract.ImageActionConfig
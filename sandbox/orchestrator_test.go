@@ -0,0 +1,99 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	docker "github.com/docker/docker/api/types/container"
+
+	contract "sandbox/api/gen"
+)
+
+// fakeOrchestrator serves the workdir, the stats and the memory cgroup of
+// every container from its fields, the other methods of ContainerOrchestrator
+// aren't implemented.
+type fakeOrchestrator struct {
+	ContainerOrchestrator
+
+	// workdir is the tar archive returned for the workdir.
+	workdir []byte
+
+	mu sync.Mutex
+	// stats are returned by Stats and cgroupFiles by ReadMemoryCgroup in turn,
+	// the last one is repeated.
+	stats       []docker.StatsResponse
+	cgroupFiles []string
+}
+
+func (f *fakeOrchestrator) Stats(context.Context, StartedContainer) (*docker.StatsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.stats) == 0 {
+		return nil, errors.New("no stats")
+	}
+	stats := f.stats[0]
+	if len(f.stats) > 1 {
+		f.stats = f.stats[1:]
+	}
+
+	return &stats, nil
+}
+
+func (f *fakeOrchestrator) ReadMemoryCgroup(context.Context, StartedContainer, string, string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.cgroupFiles) == 0 {
+		return nil, errors.New("read memory cgroup: no such file")
+	}
+	data := f.cgroupFiles[0]
+	if len(f.cgroupFiles) > 1 {
+		f.cgroupFiles = f.cgroupFiles[1:]
+	}
+
+	return []byte(data), nil
+}
+
+func (f *fakeOrchestrator) observeResourceUsage(string, string, contract.ResourceUsage) {}
+
+func (f *fakeOrchestrator) CopyFromContainer(context.Context, StartedContainer, string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(f.workdir)), nil
+}
+
+// useFakeOrchestrator replaces codenireManager until the end of the test.
+func useFakeOrchestrator(t *testing.T, f *fakeOrchestrator) {
+	old := codenireManager
+	codenireManager = f
+	t.Cleanup(func() { codenireManager = old })
+}
+
+// workdirArchive returns a tar archive of the files like docker returns the
+// workdir: rooted at its base name, with the directories.
+func workdirArchive(t *testing.T, root string, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	if err := tw.WriteHeader(&tar.Header{Name: root + "/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		hdr := &tar.Header{Name: root + "/" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	contract "sandbox/api/gen"
+)
+
+// problemError is a failure of a request which is reported to the playground
+// as RFC 7807 problem details instead of a run result.
+type problemError struct {
+	status     int
+	code       contract.ProblemCode
+	detail     string
+	retryAfter time.Duration
+}
+
+func newProblem(status int, code contract.ProblemCode, format string, args ...interface{}) *problemError {
+	return &problemError{status: status, code: code, detail: fmt.Sprintf(format, args...)}
+}
+
+func (e *problemError) Error() string {
+	return e.detail
+}
+
+func (e *problemError) problem() contract.Problem {
+	detail := e.detail
+	return contract.Problem{
+		Type:   "urn:codenire:problem:" + string(e.code),
+		Title:  http.StatusText(e.status),
+		Status: e.status,
+		Code:   e.code,
+		Detail: &detail,
+	}
+}
+
+func sendProblem(w http.ResponseWriter, p *problemError) {
+	body, _ := json.Marshal(p.problem())
+
+	if p.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(p.retryAfter.Seconds())))
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	contract "sandbox/api/gen"
+	"sandbox/internal"
+)
+
+var (
+	errRunKilled       = errors.New("run killed by the sandbox admin")
+	errTemplateStopped = errors.New("template disabled by the sandbox admin")
+	errContainersReset = errors.New("sandbox containers were reset by the admin")
+)
+
+// activeRun is a run, judge or format request which holds a container.
+type activeRun struct {
+	id        string
+	template  string
+	action    string
+	cont      StartedContainer
+	startedAt time.Time
+	cancel    context.CancelCauseFunc
+}
+
+// runRegistry tracks the active runs, so the admin can list and kill them.
+type runRegistry struct {
+	mu   sync.Mutex
+	runs map[string]*activeRun
+}
+
+var activeRuns = &runRegistry{runs: make(map[string]*activeRun)}
+
+// add registers the run of the request in the container. The returned
+// context is canceled when the run is killed, the returned func removes it.
+func (r *runRegistry) add(ctx context.Context, req contract.SandboxRequest, cont StartedContainer) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	run := &activeRun{
+		id:        internal.RandHex(8),
+		template:  req.SandId,
+		action:    req.Action,
+		cont:      cont,
+		startedAt: time.Now(),
+		cancel:    cancel,
+	}
+
+	r.mu.Lock()
+	r.runs[run.id] = run
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.runs, run.id)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// list returns the active runs, the oldest first.
+func (r *runRegistry) list() []contract.SandboxActiveRun {
+	r.mu.Lock()
+	res := make([]contract.SandboxActiveRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		res = append(res, contract.SandboxActiveRun{
+			Id:          run.id,
+			Template:    run.template,
+			Action:      run.action,
+			ContainerId: run.cont.CId,
+			StartedAt:   run.startedAt,
+			Age:         float32(time.Since(run.startedAt).Seconds()),
+		})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].StartedAt.Before(res[j].StartedAt)
+	})
+
+	return res
+}
+
+// kill cancels the runs matching the filter and returns how many were killed.
+// The runs stop their commands and their containers once they notice it.
+func (r *runRegistry) kill(match func(*activeRun) bool, cause error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, run := range r.runs {
+		if match(run) {
+			run.cancel(cause)
+			n++
+		}
+	}
+
+	return n
+}
+
+func (r *runRegistry) count(template string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, run := range r.runs {
+		if run.template == template {
+			n++
+		}
+	}
+
+	return n
+}
@@ -38,12 +38,16 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"syscall"
 	"time"
 
 	contract "sandbox/api/gen"
 	"sandbox/internal"
+
+	"golang.org/x/sys/unix"
 )
 
 const (
@@ -53,151 +57,410 @@ const (
 
 func rootHandler(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+		sendProblem(w, newProblem(http.StatusNotFound, contract.NotFound, "no route for %s %s", r.Method, r.URL.Path))
 		return
 	}
 	_, _ = io.WriteString(w, "Hi from sandbox\n")
 }
 
 func runHandler(w http.ResponseWriter, r *http.Request) {
-	var err error
-
 	var req contract.SandboxRequest
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendProblem(w, newProblem(http.StatusBadRequest, contract.InvalidRequest, "invalid request: %v", err))
 		return
 	}
 
-	tmpDir, err := os.MkdirTemp("", "tmp_sandbox")
+	res, err := runSubmission(r.Context(), req, &runOutput{})
+	var problem *problemError
+	if errors.As(err, &problem) {
+		sendProblem(w, problem)
+		return
+	}
 	if err != nil {
-		http.Error(w, "createDB tmp dir failed", http.StatusInternalServerError)
+		sendRunError(w, err.Error(), res)
 		return
 	}
-	defer os.RemoveAll(tmpDir)
 
-	stdinFile, err := internal.SaveRequestFiles(req, tmpDir)
-	if err != nil {
-		sendRunError(w, "encode  files failed", nil)
+	sendResponse(w, res)
+}
+
+// runStreamHandler runs the submission like runHandler, but sends the output
+// as server-sent events while the commands are still executing. The stream
+// always ends with a "done" event which carries the RunEnvironment and the
+// execution result. Problems found before the first event are sent as
+// problem details instead of a stream.
+func runStreamHandler(w http.ResponseWriter, r *http.Request) {
+	var req contract.SandboxRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendProblem(w, newProblem(http.StatusBadRequest, contract.InvalidRequest, "invalid request: %v", err))
 		return
 	}
 
-	cont, err := codenireManager.GetContainer(r.Context(), req.SandId)
-	if err != nil {
-		sendRunError(w, fmt.Sprintf("get container %s failed with %s", req.SandId, err.Error()), nil)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "streaming unsupported"))
 		return
 	}
 
-	defer func() {
-		err = codenireManager.KillContainer(*cont)
-		if err != nil {
-			sendRunError(w, fmt.Sprintf("kill contaier err: %s", err.Error()), nil)
+	started := false
+	send := func(ev contract.SandboxStreamEvent) {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+		}
+
+		if err := writeStreamEvent(w, ev); err != nil {
+			log.Printf("write stream event: %v", err)
 			return
 		}
-	}()
+		flusher.Flush()
+	}
 
-	// Bound the number of requests being processed at once.
-	// (Before we slurp the binary into memory)
-	select {
-	case runSem <- struct{}{}:
-	case <-r.Context().Done():
-		return
+	out := &runOutput{
+		emit: func(kind string, data []byte) {
+			send(contract.SandboxStreamEvent{Kind: kind, Data: data})
+		},
 	}
-	defer func() { <-runSem }()
 
-	action, exists := cont.Image.Actions[req.Action]
-	if !exists {
-		sendRunError(w, fmt.Sprintf("action %s not found with template %s", req.Action, req.SandId), nil)
+	res, err := runSubmission(r.Context(), req, out)
+	var problem *problemError
+	if errors.As(err, &problem) && !started {
+		sendProblem(w, problem)
 		return
 	}
+	if err != nil {
+		out.event(StreamKindError, []byte(err.Error()))
+	}
 
-	//nolint
-	out, err := exec.Command(
-		"docker",
-		"cp",
-		tmpDir+"/.",
-		cont.CId+":"+cont.Image.Workdir,
-	).CombinedOutput()
+	send(doneEvent(res))
+}
 
+// runSubmission copies the request files into a warm container of the
+// requested template and executes the compile and run commands of the action
+// there. The output of both commands is written to out while they are running.
+//
+// A returned error means that the submission couldn't be completed (including
+// timeouts); its text is reported to the client as the run error.
+func runSubmission(ctx context.Context, req contract.SandboxRequest, out *runOutput) (*contract.SandboxResponse, error) {
+	sub, err := prepareSubmission(ctx, req, nil)
 	if err != nil {
-		log.Printf("Invalid request 7")
-		sendRunError(w, fmt.Sprintf("failed to connect to docker: %v, %s", err, out), nil)
-		return
+		return nil, err
 	}
+	defer sub.close()
+	ctx = sub.ctx
 
-	totalTimeout := time.Duration(*cont.Image.ContainerOptions.CompileTTL+*cont.Image.ContainerOptions.RunTTL) * time.Second
-	timeoutCtx := registerCmdTimeout(r.Context(), totalTimeout)
+	totalTimeout := sub.compileTTL() + sub.runTTL()
+	timeoutCtx := registerCmdTimeout(ctx, totalTimeout)
 
 	res := &contract.SandboxResponse{}
-	res.RunEnvironment.ActionName = action.Name
-	var stdout, stderr bytes.Buffer
-
-	compileCmd := getCommand(action.CompileCmd, CompileCmd, req.ExtendedOptions, action)
-	if compileCmd != "" {
-		compileCtx := registerCmdTimeout(r.Context(), totalTimeout)
-		{
-			start := time.Now()
-			parsedCmd := replacePlaceholders(compileCmd, req.Args, nil)
-			runErr := execContainerShell(
-				compileCtx,
-				&stderr,
-				&stdout,
-				*cont,
-				parsedCmd,
-				cont.Image,
-			)
-
-			res.RunEnvironment.CompileCmd = compileCmd
-			res.RunEnvironment.CompileTime = float32(time.Since(start).Seconds())
-			codenireManager.observeExecDuration(start, "compile", req.SandId)
-
-			if runErr != nil {
-				if errors.Is(compileCtx.Err(), context.DeadlineExceeded) {
-					sendRunError(w, "timeout compilation", res)
-					return
-				}
-
-				flushStdWithErr(res, stderr, stdout)
-				sendResponse(w, res)
-				return
-			}
-		}
+	res.RunEnvironment.ActionName = sub.action.Name
+
+	compiled, err := sub.compile(ctx, totalTimeout, out, res)
+	if !compiled && sub.hasSteps() {
+		skipSteps(res, sub.steps(contract.StepPhaseRun))
+	}
+	if err != nil {
+		return res, err
+	}
+	if !compiled {
+		flushStdWithErr(res, out.stderr, out.stdout)
+		return res, nil
 	}
 
 	// TODO:: disconnect?
 
-	runTTL := time.Duration(*cont.Image.ContainerOptions.RunTTL) * time.Second
-	runTimeoutCtx := registerCmdTimeout(timeoutCtx, runTTL)
-	runCmd := getCommand(action.RunCmd, RunCmd, req.ExtendedOptions, action)
+	if sub.hasSteps() {
+		ran, stepsErr := sub.runSteps(ctx, contract.StepPhaseRun, out, res)
+		if stepsErr != nil {
+			return res, stepsErr
+		}
+		if !ran {
+			flushStdWithErr(res, out.stderr, out.stdout)
+			sub.addArtifacts(ctx, res)
+			return res, nil
+		}
+
+		res.Status = contract.ExecutionStatusOk
+		flushStd(res, out.stderr, out.stdout)
+		sub.addArtifacts(ctx, res)
+		return res, nil
+	}
+
+	out.event(StreamKindPhase, []byte(PhaseRun))
+
+	runCmd := getCommand(sub.action.RunCmd, RunCmd, req.ExtendedOptions, sub.action)
 	{
 		start := time.Now()
-		parsedRunCmd := replacePlaceholders(runCmd, req.Args, stdinFile)
+		runTimeoutCtx := registerCmdTimeout(timeoutCtx, sub.runTTL())
+		meter := startResourceMeter(ctx, *sub.cont, "run")
 		runErr := execContainerShell(
 			runTimeoutCtx,
-			&stderr,
-			&stdout,
-			*cont,
-			parsedRunCmd,
-			cont.Image,
+			out.Stderr(),
+			out.Stdout(),
+			*sub.cont,
+			replacePlaceholders(runCmd, req.Args, sub.stdinFile),
+			sub.cont.Image,
 		)
 
 		res.RunEnvironment.RunCmd = runCmd
 		res.RunEnvironment.RunTime = float32(time.Since(start).Seconds())
+		res.RunEnvironment.RunUsage = meter.finish(ctx)
 		codenireManager.observeExecDuration(start, "run", req.SandId)
 
 		if runErr != nil {
+			if ctx.Err() != nil {
+				return res, context.Cause(ctx)
+			}
 			if errors.Is(runTimeoutCtx.Err(), context.DeadlineExceeded) {
-				sendRunError(w, "timeout execute", res)
-				return
+				setTimeoutStatus(res)
+				return res, errors.New("timeout execute")
 			}
 
-			flushStdWithErr(res, stderr, stdout)
-			sendResponse(w, res)
-			return
+			sub.setFailedStatus(ctx, res, contract.ExecutionStatusRuntimeError, runErr)
+			flushStdWithErr(res, out.stderr, out.stdout)
+			// Artifacts written before the failure may help to find out what went wrong.
+			sub.addArtifacts(ctx, res)
+			return res, nil
 		}
 	}
 
-	flushStd(res, stderr, stdout)
-	sendResponse(w, res)
+	res.Status = contract.ExecutionStatusOk
+	flushStd(res, out.stderr, out.stdout)
+	sub.addArtifacts(ctx, res)
+	return res, nil
+}
+
+// submission is a request whose files have been copied into a warm container.
+type submission struct {
+	req    contract.SandboxRequest
+	cont   *StartedContainer
+	action contract.ImageActionConfig
+
+	// ctx of the request, canceled if the admin kills the run.
+	ctx context.Context
+	// deadline is when the runs have to be over for the response
+	// to arrive before the playground gives up on the request.
+	deadline time.Time
+
+	// stdinFile holds the request stdin, inputFiles the additional
+	// inputs passed to prepareSubmission, relative to the workdir.
+	stdinFile  *string
+	inputFiles []string
+
+	cleanup []func()
+}
+
+// prepareSubmission takes a warm container for the request and copies the
+// request files into it. Every input is saved to a file of its own, so it can
+// be used as stdin of a run. The submission must be closed after use, its
+// context is used for the commands, so the admin can kill them.
+func prepareSubmission(ctx context.Context, req contract.SandboxRequest, inputs []string) (*submission, error) {
+	sub := &submission{
+		req:      req,
+		deadline: time.Now().Add(PlaygroundTimeout - ResponseMargin),
+	}
+
+	tmpDir, err := os.MkdirTemp("", "tmp_sandbox")
+	if err != nil {
+		return nil, newProblem(http.StatusInternalServerError, contract.InternalError, "create tmp dir failed: %v", err)
+	}
+	sub.cleanup = append(sub.cleanup, func() { _ = os.RemoveAll(tmpDir) })
+
+	sub.stdinFile, err = internal.SaveRequestFiles(req, tmpDir)
+	if err != nil {
+		sub.close()
+		return nil, newProblem(http.StatusBadRequest, contract.InvalidRequest, "decode files failed: %v", err)
+	}
+
+	for i, input := range inputs {
+		name := fmt.Sprintf("input_%s_%d.txt", internal.RandHex(8), i)
+		if err = os.WriteFile(filepath.Join(tmpDir, name), []byte(input), 0644); err != nil {
+			sub.close()
+			return nil, newProblem(http.StatusInternalServerError, contract.InternalError, "error writing to %s: %v", name, err)
+		}
+		sub.inputFiles = append(sub.inputFiles, name)
+	}
+
+	if !templateExists(req.SandId) {
+		sub.close()
+		return nil, newProblem(http.StatusBadRequest, contract.TemplateNotFound, "template `%s` not found", req.SandId)
+	}
+	if codenireManager.TemplateState(req.SandId) == contract.Draining {
+		sub.close()
+		return nil, newProblem(http.StatusServiceUnavailable, contract.SandboxUnavailable, "template `%s` is draining", req.SandId)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, ContainerWaitTimeout)
+	sub.cont, err = codenireManager.GetContainer(waitCtx, req.SandId)
+	cancel()
+	if err != nil {
+		sub.close()
+		if ctx.Err() == nil {
+			p := newProblem(http.StatusServiceUnavailable, contract.SandboxSaturated, "no free container of template `%s`", req.SandId)
+			p.retryAfter = time.Second
+			return nil, p
+		}
+
+		return nil, fmt.Errorf("get container %s failed with %s", req.SandId, err.Error())
+	}
+
+	cont := *sub.cont
+	sub.cleanup = append(sub.cleanup, func() {
+		if kErr := codenireManager.KillContainer(cont); kErr != nil {
+			log.Printf("kill contaier err: %s", kErr.Error())
+		}
+	})
+
+	var unregister func()
+	sub.ctx, unregister = activeRuns.add(ctx, req, cont)
+	sub.cleanup = append(sub.cleanup, unregister)
+	ctx = sub.ctx
+
+	// Bound the number of requests being processed at once.
+	// (Before we slurp the binary into memory)
+	select {
+	case runSem <- struct{}{}:
+	case <-ctx.Done():
+		sub.close()
+		return nil, ctx.Err()
+	}
+	sub.cleanup = append(sub.cleanup, func() { <-runSem })
+
+	action, exists := sub.cont.Image.Actions[req.Action]
+	if !exists {
+		sub.close()
+		return nil, newProblem(http.StatusBadRequest, contract.ActionNotFound, "action %s not found with template %s", req.Action, req.SandId)
+	}
+	sub.action = action
+
+	//nolint
+	cpOut, err := exec.Command(
+		"docker",
+		"cp",
+		tmpDir+"/.",
+		sub.cont.CId+":"+sub.cont.Image.Workdir,
+	).CombinedOutput()
+
+	if err != nil {
+		sub.close()
+		return nil, newProblem(http.StatusInternalServerError, contract.DockerError, "failed to copy files into the container: %v, %s", err, cpOut)
+	}
+
+	return sub, nil
+}
+
+// close releases the container and the temporary files in reverse order of their acquisition.
+func (s *submission) close() {
+	for i := len(s.cleanup) - 1; i >= 0; i-- {
+		s.cleanup[i]()
+	}
+	s.cleanup = nil
+}
+
+func (s *submission) compileTTL() time.Duration {
+	return time.Duration(*s.cont.Image.ContainerOptions.CompileTTL) * time.Second
+}
+
+func (s *submission) runTTL() time.Duration {
+	return time.Duration(*s.cont.Image.ContainerOptions.RunTTL) * time.Second
+}
+
+// timeLeft returns the time left until the deadline of the submission, capped to ttl.
+func (s *submission) timeLeft(ttl time.Duration) time.Duration {
+	return max(min(ttl, time.Until(s.deadline)), 0)
+}
+
+// compile runs the compile command of the action, if it has one, and records
+// it in res. It returns false if the command failed, its output is in out then.
+// The compile steps of an action with steps have their own TTLs.
+func (s *submission) compile(ctx context.Context, timeout time.Duration, out *runOutput, res *contract.SandboxResponse) (bool, error) {
+	if s.hasSteps() {
+		return s.runSteps(ctx, contract.StepPhaseCompile, out, res)
+	}
+
+	compileCmd := getCommand(s.action.CompileCmd, CompileCmd, s.req.ExtendedOptions, s.action)
+	if compileCmd == "" {
+		return true, nil
+	}
+
+	out.event(StreamKindPhase, []byte(PhaseCompile))
+
+	compileCtx := registerCmdTimeout(ctx, timeout)
+	meter := startResourceMeter(ctx, *s.cont, "compile")
+	start := time.Now()
+	runErr := execContainerShell(
+		compileCtx,
+		out.Stderr(),
+		out.Stdout(),
+		*s.cont,
+		replacePlaceholders(compileCmd, s.req.Args, nil),
+		s.cont.Image,
+	)
+
+	res.RunEnvironment.CompileCmd = compileCmd
+	res.RunEnvironment.CompileTime = float32(time.Since(start).Seconds())
+	res.RunEnvironment.CompileUsage = meter.finish(ctx)
+	codenireManager.observeExecDuration(start, "compile", s.req.SandId)
+
+	if runErr != nil {
+		if ctx.Err() != nil {
+			return false, context.Cause(ctx)
+		}
+		if errors.Is(compileCtx.Err(), context.DeadlineExceeded) {
+			setTimeoutStatus(res)
+			return false, errors.New("timeout compilation")
+		}
+
+		s.setFailedStatus(ctx, res, contract.ExecutionStatusCompileError, runErr)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// setFailedStatus records how a command failed: its exit code, the signal
+// which terminated it and whether the container ran out of memory.
+func (s *submission) setFailedStatus(ctx context.Context, res *contract.SandboxResponse, status contract.ExecutionStatus, err error) {
+	exitCode := -1
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+
+	s.setExitStatus(ctx, res, status, exitCode)
+}
+
+// setExitStatus records the exit code of a failed command like setFailedStatus.
+func (s *submission) setExitStatus(ctx context.Context, res *contract.SandboxResponse, status contract.ExecutionStatus, exitCode int) {
+	res.Status = status
+	res.ExitCode = exitCode
+	res.Signal = nil
+
+	// docker exec reports the exit code of `sh -c`, which is 128+n
+	// for a command terminated by signal n.
+	if res.ExitCode > 128 && res.ExitCode <= 128+64 {
+		if name := unix.SignalName(syscall.Signal(res.ExitCode - 128)); name != "" {
+			res.Signal = &name
+		}
+	}
+
+	oomKilled, inspectErr := codenireManager.OOMKilled(ctx, *s.cont)
+	if inspectErr != nil {
+		log.Printf("inspect container %s: %v", s.cont.CId, inspectErr)
+	}
+	if oomKilled {
+		res.Status = contract.ExecutionStatusOomKilled
+	}
+}
+
+func setTimeoutStatus(res *contract.SandboxResponse) {
+	res.Status = contract.ExecutionStatusTimeout
+	res.ExitCode = -1
+	res.Signal = nil
 }
 
 func getCommand(cmd string, key string, externalData *map[string]string, action contract.ImageActionConfig) string {
@@ -220,10 +483,34 @@ func getCommand(cmd string, key string, externalData *map[string]string, action
 	return cmd
 }
 
+// templateExists reports whether the sandbox serves the template,
+// disabled templates aren't served.
+func templateExists(id string) bool {
+	for _, img := range servedTemplates() {
+		if img.Template == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// servedTemplates returns the templates which aren't disabled.
+func servedTemplates() []BuiltImage {
+	res := make([]BuiltImage, 0)
+	for _, img := range codenireManager.GetTemplates() {
+		if codenireManager.TemplateState(img.Template) != contract.Disabled {
+			res = append(res, img)
+		}
+	}
+
+	return res
+}
+
 func sendResponse(w http.ResponseWriter, res *contract.SandboxResponse) {
 	body, err := json.MarshalIndent(res, "", "  ")
 	if err != nil {
-		http.Error(w, "error encoding JSON", http.StatusInternalServerError)
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "error encoding JSON"))
 		log.Printf("json marshal: %v", err)
 		return
 	}
@@ -232,11 +519,22 @@ func sendResponse(w http.ResponseWriter, res *contract.SandboxResponse) {
 	_, _ = w.Write(body)
 }
 
+// sendRunError reports a submission which couldn't be completed. The error is
+// also written to stderr for the clients which don't check the status.
 func sendRunError(w http.ResponseWriter, err string, ctxRes *contract.SandboxResponse) {
-	res := &contract.SandboxResponse{}
+	res := &contract.SandboxResponse{
+		Error:    &err,
+		ExitCode: -1,
+		Status:   contract.ExecutionStatusInternalError,
+	}
 	res.Stderr = []byte(err)
 	if ctxRes != nil {
 		res.RunEnvironment = ctxRes.RunEnvironment
+		if ctxRes.Status != "" {
+			res.Status = ctxRes.Status
+			res.ExitCode = ctxRes.ExitCode
+			res.Signal = ctxRes.Signal
+		}
 	}
 
 	sendRunResponse(w, res)
@@ -254,7 +552,7 @@ func flushStdWithErr(res *contract.SandboxResponse, stderr bytes.Buffer, stdout
 	res.Stdout = nil
 }
 
-func execContainerShell(ctx context.Context, stderr *bytes.Buffer, stdout *bytes.Buffer, container StartedContainer, runCmd string, cfg BuiltImage) error {
+func execContainerShell(ctx context.Context, stderr io.Writer, stdout io.Writer, container StartedContainer, runCmd string, cfg BuiltImage) error {
 	sh := fmt.Sprintf("cd %s && %s", cfg.Workdir, runCmd)
 
 	//nolint
@@ -292,7 +590,7 @@ func registerCmdTimeout(ctx context.Context, timeout time.Duration) context.Cont
 func sendRunResponse(w http.ResponseWriter, r *contract.SandboxResponse) {
 	body, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
-		http.Error(w, "error encoding JSON", http.StatusInternalServerError)
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "error encoding JSON"))
 		log.Printf("json marshal: %v", err)
 		return
 	}
@@ -319,9 +617,9 @@ func replacePlaceholders(input string, args string, stdinFileName *string) strin
 }
 
 func listTemplatesHandler(w http.ResponseWriter, _ *http.Request) {
-	body, err := json.MarshalIndent(codenireManager.GetTemplates(), "", "  ")
+	body, err := json.MarshalIndent(servedTemplates(), "", "  ")
 	if err != nil {
-		http.Error(w, "error encoding JSON", http.StatusInternalServerError)
+		sendProblem(w, newProblem(http.StatusInternalServerError, contract.InternalError, "error encoding JSON"))
 		log.Printf("json marshal: %v", err)
 		return
 	}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubmissionTimeLeft(t *testing.T) {
+	cases := []struct {
+		name     string
+		deadline time.Duration
+		ttl      time.Duration
+		want     time.Duration
+	}{
+		{"ttl", time.Minute, 5 * time.Second, 5 * time.Second},
+		{"deadline", 3 * time.Second, 30 * time.Second, 3 * time.Second},
+		{"over", -time.Second, 5 * time.Second, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sub := &submission{deadline: time.Now().Add(c.deadline)}
+
+			got := sub.timeLeft(c.ttl)
+			if got > c.want || got < c.want-time.Second {
+				t.Errorf("timeLeft(%s) = %s, want %s", c.ttl, got, c.want)
+			}
+		})
+	}
+}
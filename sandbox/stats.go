@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	docker "github.com/docker/docker/api/types/container"
+
+	contract "sandbox/api/gen"
+)
+
+// statsSampleInterval is how often the container stats are read while a
+// command is running to find its process count, and its peak memory usage
+// when the memory cgroup can't be read.
+const statsSampleInterval = 100 * time.Millisecond
+
+// resourceMeter measures the resources used by a command from the stats of
+// its container. The CPU times are the difference of the cgroup counters
+// before and after the command. The peak memory is the high-water mark of the
+// memory cgroup if the command raised it, the peaks are sampled otherwise.
+type resourceMeter struct {
+	cont      StartedContainer
+	operation string
+
+	before     *docker.StatsResponse
+	peakMemory uint64
+	peakPids   uint64
+
+	// highWater is the high-water mark of the memory usage before the
+	// command, hasHighWater is false when it can't be read.
+	highWater    uint64
+	hasHighWater bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startResourceMeter starts measuring the container for the operation
+// (compile or run). It must be finished after the command exited.
+func startResourceMeter(ctx context.Context, cont StartedContainer, operation string) *resourceMeter {
+	m := &resourceMeter{
+		cont:      cont,
+		operation: operation,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	before, err := codenireManager.Stats(ctx, cont)
+	if err != nil {
+		log.Printf("read stats of %s: %v", cont.CId, err)
+		close(m.done)
+		return m
+	}
+	m.before = before
+
+	if hw, hwErr := memoryHighWater(ctx, cont, before); hwErr == nil {
+		m.highWater, m.hasHighWater = hw, true
+	}
+
+	go m.sample(ctx)
+
+	return m
+}
+
+func (m *resourceMeter) sample(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(statsSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if stats, err := codenireManager.Stats(ctx, m.cont); err == nil {
+				m.record(stats)
+			}
+		}
+	}
+}
+
+func (m *resourceMeter) record(stats *docker.StatsResponse) {
+	m.peakMemory = max(m.peakMemory, memoryUsage(stats.MemoryStats))
+	m.peakPids = max(m.peakPids, stats.PidsStats.Current)
+}
+
+// finish stops the sampling and returns the resources used since the start,
+// or nil if the stats of the container couldn't be read.
+func (m *resourceMeter) finish(ctx context.Context) *contract.ResourceUsage {
+	if m.before == nil {
+		return nil
+	}
+
+	close(m.stop)
+	<-m.done
+
+	after, err := codenireManager.Stats(ctx, m.cont)
+	if err != nil {
+		log.Printf("read stats of %s: %v", m.cont.CId, err)
+		return nil
+	}
+	m.record(after)
+
+	peakMemory := m.peakMemory
+	if m.hasHighWater {
+		if hw, hwErr := memoryHighWater(ctx, m.cont, after); hwErr == nil {
+			peakMemory = commandPeakMemory(m.highWater, hw, m.peakMemory)
+		}
+	}
+
+	cpuBefore, cpuAfter := m.before.CPUStats.CPUUsage, after.CPUStats.CPUUsage
+	usage := contract.ResourceUsage{
+		CPUUserTime:   cpuSeconds(cpuAfter.UsageInUsermode, cpuBefore.UsageInUsermode),
+		CPUSystemTime: cpuSeconds(cpuAfter.UsageInKernelmode, cpuBefore.UsageInKernelmode),
+		PeakMemory:    int64(peakMemory),
+		Processes:     int(m.peakPids - min(m.peakPids, m.before.PidsStats.Current)),
+	}
+
+	codenireManager.observeResourceUsage(m.operation, m.cont.Image.Template, usage)
+
+	return &usage
+}
+
+// memoryHighWater returns the high-water mark of the memory usage of the
+// container: max_usage_in_bytes of cgroup v1, which the stats carry, or
+// memory.peak of cgroup v2.
+func memoryHighWater(ctx context.Context, cont StartedContainer, stats *docker.StatsResponse) (uint64, error) {
+	if stats.MemoryStats.MaxUsage > 0 {
+		return stats.MemoryStats.MaxUsage, nil
+	}
+
+	data, err := codenireManager.ReadMemoryCgroup(ctx, cont, "memory.peak", "memory.max_usage_in_bytes")
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 64)
+}
+
+// commandPeakMemory returns the peak memory of a command from the high-water
+// marks of the container before and after it. The mark isn't reset between
+// the commands of a container, it only tells the peak of a command which
+// raised it. The sampled peak is used otherwise.
+func commandPeakMemory(before, after, sampled uint64) uint64 {
+	if after > before {
+		return after
+	}
+
+	return sampled
+}
+
+// memoryUsage returns the memory used by the container without the inactive
+// page cache, like `docker stats` does.
+func memoryUsage(stats docker.MemoryStats) uint64 {
+	inactive, ok := stats.Stats["inactive_file"] // cgroup v2
+	if !ok {
+		inactive = stats.Stats["total_inactive_file"] // cgroup v1
+	}
+
+	if inactive > stats.Usage {
+		return 0
+	}
+
+	return stats.Usage - inactive
+}
+
+// cpuSeconds returns the difference of two cgroup CPU counters in seconds.
+func cpuSeconds(after, before uint64) float32 {
+	if after < before {
+		return 0
+	}
+
+	return float32(time.Duration(after - before).Seconds())
+}
+
+// oomKills returns how many processes of the container the kernel has killed
+// for running out of memory.
+func oomKills(ctx context.Context, cont StartedContainer) (uint64, error) {
+	data, err := codenireManager.ReadMemoryCgroup(ctx, cont, "memory.events", "memory.oom_control")
+	if err != nil {
+		return 0, err
+	}
+
+	return cgroupValue(data, "oom_kill")
+}
+
+// cgroupValue returns the value of the key of a flat keyed cgroup file,
+// like memory.events.
+func cgroupValue(data []byte, key string) (uint64, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		k, v, ok := bytes.Cut(bytes.TrimSpace(scanner.Bytes()), []byte(" "))
+		if ok && string(k) == key {
+			return strconv.ParseUint(string(v), 10, 64)
+		}
+	}
+
+	return 0, fmt.Errorf("no %s in the cgroup file", key)
+}
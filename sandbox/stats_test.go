@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	docker "github.com/docker/docker/api/types/container"
+)
+
+func TestCgroupValue(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		want    uint64
+		wantErr bool
+	}{
+		{"v2", "low 0\nhigh 0\nmax 3\noom 2\noom_kill 2\noom_group_kill 0\n", 2, false},
+		{"v1", "oom_kill_disable 0\nunder_oom 0\noom_kill 1\n", 1, false},
+		{"missing", "low 0\nhigh 0\n", 0, true},
+		{"invalid", "oom_kill x\n", 0, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := cgroupValue([]byte(c.data), "oom_kill")
+			if (err != nil) != c.wantErr || got != c.want {
+				t.Errorf("cgroupValue = %d, %v, want %d (error %v)", got, err, c.want, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandPeakMemory(t *testing.T) {
+	cases := []struct {
+		name                   string
+		before, after, sampled uint64
+		want                   uint64
+	}{
+		{"raised", 100, 500, 300, 500},
+		{"below the mark", 500, 500, 300, 300},
+	}
+
+	for _, c := range cases {
+		if got := commandPeakMemory(c.before, c.after, c.sampled); got != c.want {
+			t.Errorf("%s: commandPeakMemory(%d, %d, %d) = %d, want %d", c.name, c.before, c.after, c.sampled, got, c.want)
+		}
+	}
+}
+
+func TestResourceMeter(t *testing.T) {
+	stats := func(usage, maxUsage, userNs uint64) docker.StatsResponse {
+		var s docker.StatsResponse
+		s.MemoryStats.Usage = usage
+		s.MemoryStats.MaxUsage = maxUsage
+		s.CPUStats.CPUUsage.UsageInUsermode = userNs
+		return s
+	}
+
+	cases := []struct {
+		name string
+		fake *fakeOrchestrator
+		want int64
+	}{
+		{
+			// The short run ended before any sample, the stats after it only
+			// show the baseline of the container.
+			name: "cgroup v2",
+			fake: &fakeOrchestrator{
+				stats:       []docker.StatsResponse{stats(10, 0, 0), stats(12, 0, 5e8)},
+				cgroupFiles: []string{"20\n", "800\n"},
+			},
+			want: 800,
+		},
+		{
+			name: "cgroup v1",
+			fake: &fakeOrchestrator{
+				stats: []docker.StatsResponse{stats(10, 20, 0), stats(12, 700, 5e8)},
+			},
+			want: 700,
+		},
+		{
+			name: "below the mark",
+			fake: &fakeOrchestrator{
+				stats: []docker.StatsResponse{stats(10, 900, 0), stats(12, 900, 5e8)},
+			},
+			want: 12,
+		},
+		{
+			name: "no cgroup",
+			fake: &fakeOrchestrator{
+				stats: []docker.StatsResponse{stats(10, 0, 0), stats(12, 0, 5e8)},
+			},
+			want: 12,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			useFakeOrchestrator(t, c.fake)
+
+			ctx := context.Background()
+			usage := startResourceMeter(ctx, StartedContainer{}, "run").finish(ctx)
+			if usage == nil {
+				t.Fatal("no usage")
+			}
+			if usage.PeakMemory != c.want || usage.CPUUserTime != 0.5 {
+				t.Errorf("usage = %+v, want peak memory %d and 0.5s user time", *usage, c.want)
+			}
+		})
+	}
+}
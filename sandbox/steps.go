@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	contract "sandbox/api/gen"
+)
+
+// stepPhase returns the phase of the step, steps run by default.
+func stepPhase(step contract.ActionStep) contract.StepPhase {
+	if step.Phase == nil {
+		return contract.StepPhaseRun
+	}
+
+	return *step.Phase
+}
+
+// validateSteps checks the steps of an action: every step has a name and a
+// command, the names are unique and the compile steps go first. The TTLs of
+// the steps, the ones of the template by default, mustn't add up to more than
+// the playground waits for the response.
+func validateSteps(action contract.ImageActionConfig, opts contract.ContainerOptions) error {
+	if action.Steps == nil {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	running := false
+	var total time.Duration
+	for i, step := range *action.Steps {
+		if step.Name == "" || step.Cmd == "" {
+			return fmt.Errorf("step %d of action %s needs a name and a command", i, action.Name)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("step %s of action %s is defined twice", step.Name, action.Name)
+		}
+		names[step.Name] = true
+
+		switch stepPhase(step) {
+		case contract.StepPhaseRun:
+			running = true
+		case contract.StepPhaseCompile:
+			if running {
+				return fmt.Errorf("compile step %s of action %s follows a run step", step.Name, action.Name)
+			}
+		default:
+			return fmt.Errorf("step %s of action %s has unknown phase %s", step.Name, action.Name, *step.Phase)
+		}
+
+		total += stepTTL(step, opts)
+	}
+
+	if budget := PlaygroundTimeout - ResponseMargin; total > budget {
+		return fmt.Errorf("steps of action %s take up to %s, more than the %s of a request", action.Name, total, budget)
+	}
+
+	return nil
+}
+
+// stepTTL returns the TTL of the step, the TTL of its phase by default.
+func stepTTL(step contract.ActionStep, opts contract.ContainerOptions) time.Duration {
+	if step.TTL != nil && *step.TTL > 0 {
+		return time.Duration(*step.TTL) * time.Second
+	}
+
+	ttl := opts.RunTTL
+	if stepPhase(step) == contract.StepPhaseCompile {
+		ttl = opts.CompileTTL
+	}
+	if ttl == nil {
+		return 0
+	}
+
+	return time.Duration(*ttl) * time.Second
+}
+
+// hasSteps reports whether the action is made of steps instead of
+// its compile and run commands.
+func (s *submission) hasSteps() bool {
+	return s.action.Steps != nil && len(*s.action.Steps) > 0
+}
+
+// steps returns the steps of the action in the phase.
+func (s *submission) steps(phase contract.StepPhase) []contract.ActionStep {
+	var res []contract.ActionStep
+	for _, step := range *s.action.Steps {
+		if stepPhase(step) == phase {
+			res = append(res, step)
+		}
+	}
+
+	return res
+}
+
+// runCommand returns the run command of the action. The run steps of an
+// action with steps are joined into a single command.
+func (s *submission) runCommand() string {
+	if !s.hasSteps() {
+		return getCommand(s.action.RunCmd, RunCmd, s.req.ExtendedOptions, s.action)
+	}
+
+	var cmds []string
+	for _, step := range s.steps(contract.StepPhaseRun) {
+		cmds = append(cmds, step.Cmd)
+	}
+
+	return strings.Join(cmds, " && ")
+}
+
+// runSteps runs the steps of the phase in order and records them in res.
+// It returns false if a step failed which doesn't continue on error, the
+// remaining steps are recorded as skipped then.
+func (s *submission) runSteps(ctx context.Context, phase contract.StepPhase, out *runOutput, res *contract.SandboxResponse) (bool, error) {
+	steps := s.steps(phase)
+	if len(steps) == 0 {
+		return true, nil
+	}
+
+	out.event(StreamKindPhase, []byte(phase))
+
+	for i, step := range steps {
+		result, runErr := s.runStep(ctx, step, phase, out, res)
+
+		if runErr == nil || (step.ContinueOnError != nil && *step.ContinueOnError) {
+			continue
+		}
+
+		skipSteps(res, steps[i+1:])
+
+		if ctx.Err() != nil {
+			return false, context.Cause(ctx)
+		}
+		if result.Status == contract.TimedOut {
+			setTimeoutStatus(res)
+			return false, fmt.Errorf("timeout of step %s", step.Name)
+		}
+
+		status := contract.ExecutionStatusRuntimeError
+		if phase == contract.StepPhaseCompile {
+			status = contract.ExecutionStatusCompileError
+		}
+		s.setFailedStatus(ctx, res, status, runErr)
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// runStep runs the command of the step and adds its result, time and usage to res.
+func (s *submission) runStep(
+	ctx context.Context,
+	step contract.ActionStep,
+	phase contract.StepPhase,
+	out *runOutput,
+	res *contract.SandboxResponse,
+) (contract.StepResult, error) {
+	// All steps together have to be over by the deadline of the request.
+	ttl := s.timeLeft(stepTTL(step, s.cont.Image.ContainerOptions))
+
+	output := contract.ActionStepOutputAll
+	if step.Output != nil {
+		output = *step.Output
+	}
+
+	var stdout, stderr io.Writer
+	var stdoutBuf, stderrBuf bytes.Buffer
+	switch output {
+	case contract.ActionStepOutputNone:
+		stdout, stderr = io.Discard, io.Discard
+	case contract.ActionStepOutputOnError:
+		stdout, stderr = &stdoutBuf, &stderrBuf
+	case contract.ActionStepOutputAll:
+		stdout, stderr = out.Stdout(), out.Stderr()
+	}
+
+	cmd := replacePlaceholders(step.Cmd, s.req.Args, s.stdinFile)
+
+	stepCtx := registerCmdTimeout(ctx, ttl)
+	meter := startResourceMeter(ctx, *s.cont, string(phase))
+	start := time.Now()
+	runErr := execContainerShell(stepCtx, stderr, stdout, *s.cont, cmd, s.cont.Image)
+
+	result := contract.StepResult{
+		Name:   step.Name,
+		Cmd:    step.Cmd,
+		Phase:  phase,
+		Status: contract.Passed,
+		Time:   float32(time.Since(start).Seconds()),
+		Usage:  meter.finish(ctx),
+	}
+	codenireManager.observeExecDuration(start, string(phase), s.req.SandId)
+
+	if runErr != nil {
+		result.Status = contract.Errored
+		result.ExitCode = -1
+
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+		}
+		if errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+			result.Status = contract.TimedOut
+			result.ExitCode = -1
+		}
+
+		if output == contract.ActionStepOutputOnError {
+			_, _ = out.Stdout().Write(stdoutBuf.Bytes())
+			_, _ = out.Stderr().Write(stderrBuf.Bytes())
+		}
+	}
+
+	addStepResult(res, result)
+
+	return result, runErr
+}
+
+// addStepResult records the step and adds its command,
+// time and usage to the ones of its phase.
+func addStepResult(res *contract.SandboxResponse, result contract.StepResult) {
+	env := &res.RunEnvironment
+
+	if env.Steps == nil {
+		env.Steps = &[]contract.StepResult{}
+	}
+	*env.Steps = append(*env.Steps, result)
+
+	if result.Status == contract.Skipped {
+		return
+	}
+
+	joinCmd := func(cmds, cmd string) string {
+		if cmds == "" {
+			return cmd
+		}
+		return cmds + " && " + cmd
+	}
+
+	switch result.Phase {
+	case contract.StepPhaseCompile:
+		env.CompileCmd = joinCmd(env.CompileCmd, result.Cmd)
+		env.CompileTime += result.Time
+		env.CompileUsage = addUsage(env.CompileUsage, result.Usage)
+	case contract.StepPhaseRun:
+		env.RunCmd = joinCmd(env.RunCmd, result.Cmd)
+		env.RunTime += result.Time
+		env.RunUsage = addUsage(env.RunUsage, result.Usage)
+	}
+}
+
+// skipSteps records the steps as skipped.
+func skipSteps(res *contract.SandboxResponse, steps []contract.ActionStep) {
+	for _, step := range steps {
+		addStepResult(res, contract.StepResult{
+			Name:     step.Name,
+			Cmd:      step.Cmd,
+			Phase:    stepPhase(step),
+			Status:   contract.Skipped,
+			ExitCode: -1,
+		})
+	}
+}
+
+// addUsage sums the CPU times of the steps of a phase and keeps the peaks.
+// Steps whose usage couldn't be measured are left out.
+func addUsage(total, usage *contract.ResourceUsage) *contract.ResourceUsage {
+	if usage == nil {
+		return total
+	}
+	if total == nil {
+		u := *usage
+		return &u
+	}
+
+	return &contract.ResourceUsage{
+		CPUUserTime:   total.CPUUserTime + usage.CPUUserTime,
+		CPUSystemTime: total.CPUSystemTime + usage.CPUSystemTime,
+		PeakMemory:    max(total.PeakMemory, usage.PeakMemory),
+		Processes:     max(total.Processes, usage.Processes),
+	}
+}
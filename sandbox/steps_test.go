@@ -0,0 +1,176 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	contract "sandbox/api/gen"
+)
+
+func TestValidateSteps(t *testing.T) {
+	compile, run, unknown := contract.StepPhaseCompile, contract.StepPhaseRun, contract.StepPhase("test")
+	ttl := func(seconds int) *int { return &seconds }
+
+	cases := []struct {
+		name    string
+		steps   *[]contract.ActionStep
+		wantErr string
+	}{
+		{"no steps", nil, ""},
+		{"valid", &[]contract.ActionStep{
+			{Name: "install", Cmd: "pip install -r requirements.txt", Phase: &compile, TTL: ttl(10)},
+			{Name: "build", Cmd: "make", Phase: &compile},
+			{Name: "test", Cmd: "make test", Phase: &run},
+			{Name: "run", Cmd: "./main"},
+		}, ""},
+		{"no name", &[]contract.ActionStep{{Cmd: "make"}}, "needs a name and a command"},
+		{"no command", &[]contract.ActionStep{{Name: "build"}}, "needs a name and a command"},
+		{"duplicate", &[]contract.ActionStep{
+			{Name: "build", Cmd: "make"},
+			{Name: "build", Cmd: "make all"},
+		}, "defined twice"},
+		{"compile after run", &[]contract.ActionStep{
+			{Name: "run", Cmd: "./main"},
+			{Name: "build", Cmd: "make", Phase: &compile},
+		}, "follows a run step"},
+		{"unknown phase", &[]contract.ActionStep{{Name: "lint", Cmd: "make lint", Phase: &unknown}}, "unknown phase"},
+		{"within the request", &[]contract.ActionStep{
+			{Name: "build", Cmd: "make", Phase: &compile},
+			{Name: "test", Cmd: "make test", TTL: ttl(20)},
+			{Name: "run", Cmd: "./main"},
+		}, ""},
+		{"longer than the request", &[]contract.ActionStep{
+			{Name: "build", Cmd: "make", Phase: &compile},
+			{Name: "test", Cmd: "make test", TTL: ttl(30)},
+			{Name: "run", Cmd: "./main"},
+		}, "more than the 58s of a request"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateSteps(
+				contract.ImageActionConfig{Name: "default", Steps: c.steps},
+				contract.ContainerOptions{CompileTTL: ttl(30), RunTTL: ttl(5)},
+			)
+			if c.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateSteps: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("validateSteps = %v, want an error containing %q", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestStepTTL(t *testing.T) {
+	compile := contract.StepPhaseCompile
+	ttl := func(seconds int) *int { return &seconds }
+	opts := contract.ContainerOptions{CompileTTL: ttl(30), RunTTL: ttl(5)}
+
+	cases := []struct {
+		name string
+		step contract.ActionStep
+		want time.Duration
+	}{
+		{"compile", contract.ActionStep{Phase: &compile}, 30 * time.Second},
+		{"run", contract.ActionStep{}, 5 * time.Second},
+		{"own", contract.ActionStep{Phase: &compile, TTL: ttl(10)}, 10 * time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := stepTTL(c.step, opts); got != c.want {
+				t.Errorf("stepTTL = %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAddUsage(t *testing.T) {
+	cases := []struct {
+		name         string
+		total, usage *contract.ResourceUsage
+		want         *contract.ResourceUsage
+	}{
+		{"none", nil, nil, nil},
+		{"first", nil, &contract.ResourceUsage{CPUUserTime: 1, PeakMemory: 10, Processes: 2},
+			&contract.ResourceUsage{CPUUserTime: 1, PeakMemory: 10, Processes: 2}},
+		{"not measured", &contract.ResourceUsage{CPUUserTime: 1, PeakMemory: 10}, nil,
+			&contract.ResourceUsage{CPUUserTime: 1, PeakMemory: 10}},
+		{"sum", &contract.ResourceUsage{CPUUserTime: 1, CPUSystemTime: 0.5, PeakMemory: 10, Processes: 4},
+			&contract.ResourceUsage{CPUUserTime: 2, CPUSystemTime: 0.25, PeakMemory: 30, Processes: 1},
+			&contract.ResourceUsage{CPUUserTime: 3, CPUSystemTime: 0.75, PeakMemory: 30, Processes: 4}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := addUsage(c.total, c.usage)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("addUsage = %+v, want %+v", got, c.want)
+			}
+			if got != nil && got == c.usage {
+				t.Error("addUsage returned the usage of the step, which later steps would change")
+			}
+		})
+	}
+}
+
+func TestAddStepResult(t *testing.T) {
+	res := &contract.SandboxResponse{}
+
+	addStepResult(res, contract.StepResult{
+		Name: "install", Cmd: "npm ci", Phase: contract.StepPhaseCompile, Status: contract.Passed, Time: 2,
+		Usage: &contract.ResourceUsage{CPUUserTime: 1, PeakMemory: 100},
+	})
+	addStepResult(res, contract.StepResult{
+		Name: "build", Cmd: "npm run build", Phase: contract.StepPhaseCompile, Status: contract.Passed, Time: 3,
+		Usage: &contract.ResourceUsage{CPUUserTime: 2, PeakMemory: 50},
+	})
+	addStepResult(res, contract.StepResult{
+		Name: "test", Cmd: "npm test", Phase: contract.StepPhaseRun, Status: contract.Errored, Time: 1, ExitCode: 1,
+	})
+	skipSteps(res, []contract.ActionStep{{Name: "run", Cmd: "node main.js"}})
+
+	env := res.RunEnvironment
+	if env.CompileCmd != "npm ci && npm run build" || env.CompileTime != 5 {
+		t.Errorf("compile = %q %v, want both steps", env.CompileCmd, env.CompileTime)
+	}
+	if want := (&contract.ResourceUsage{CPUUserTime: 3, PeakMemory: 100}); !reflect.DeepEqual(env.CompileUsage, want) {
+		t.Errorf("compile usage = %+v, want %+v", env.CompileUsage, want)
+	}
+	// The skipped step isn't part of the run command.
+	if env.RunCmd != "npm test" || env.RunTime != 1 || env.RunUsage != nil {
+		t.Errorf("run = %q %v %+v, want the test step only", env.RunCmd, env.RunTime, env.RunUsage)
+	}
+
+	if env.Steps == nil || len(*env.Steps) != 4 {
+		t.Fatalf("steps = %+v, want 4", env.Steps)
+	}
+	skipped := (*env.Steps)[3]
+	if skipped.Name != "run" || skipped.Status != contract.Skipped || skipped.ExitCode != -1 || skipped.Phase != contract.StepPhaseRun {
+		t.Errorf("skipped step = %+v", skipped)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	compile := contract.StepPhaseCompile
+
+	sub := &submission{action: contract.ImageActionConfig{RunCmd: "./main {ARGS}"}}
+	if got := sub.runCommand(); got != "./main {ARGS}" {
+		t.Errorf("without steps: runCommand = %q", got)
+	}
+
+	sub.action.Steps = &[]contract.ActionStep{
+		{Name: "build", Cmd: "make", Phase: &compile},
+		{Name: "test", Cmd: "make test"},
+		{Name: "run", Cmd: "./main {ARGS}"},
+	}
+	if got := sub.runCommand(); got != "make test && ./main {ARGS}" {
+		t.Errorf("with steps: runCommand = %q, want the run steps", got)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	contract "sandbox/api/gen"
+)
+
+// runOutput collects stdout and stderr of the commands executed for a
+// submission. When emit is set, every chunk is also passed to it as soon as
+// it's written, so the output can be streamed while the program is running.
+type runOutput struct {
+	mu     sync.Mutex
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	emit   func(kind string, data []byte)
+}
+
+func (o *runOutput) Stdout() io.Writer {
+	return &runOutputWriter{o: o, kind: StreamKindStdout, buf: &o.stdout}
+}
+
+func (o *runOutput) Stderr() io.Writer {
+	return &runOutputWriter{o: o, kind: StreamKindStderr, buf: &o.stderr}
+}
+
+// event passes a non-output event (phase change, error) to the stream, if any.
+func (o *runOutput) event(kind string, data []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.emit != nil {
+		o.emit(kind, data)
+	}
+}
+
+type runOutputWriter struct {
+	o    *runOutput
+	kind string
+	buf  *bytes.Buffer
+}
+
+func (w *runOutputWriter) Write(p []byte) (int, error) {
+	w.o.mu.Lock()
+	defer w.o.mu.Unlock()
+
+	w.buf.Write(p)
+	if w.o.emit != nil {
+		w.o.emit(w.kind, p)
+	}
+
+	return len(p), nil
+}
+
+func writeStreamEvent(w io.Writer, ev contract.SandboxStreamEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Kind, body)
+	return err
+}
+
+// doneEvent returns the event which ends the stream of a run. Without
+// a result the run is reported as internal error.
+func doneEvent(res *contract.SandboxResponse) contract.SandboxStreamEvent {
+	if res == nil {
+		res = &contract.SandboxResponse{}
+	}
+	if res.Status == "" {
+		res.Status = contract.ExecutionStatusInternalError
+		res.ExitCode = -1
+	}
+
+	return contract.SandboxStreamEvent{
+		Kind:           StreamKindDone,
+		Data:           []byte{},
+		RunEnvironment: &res.RunEnvironment,
+		Artifacts:      res.Artifacts,
+		Result: &contract.ExecutionResult{
+			Status:   res.Status,
+			ExitCode: res.ExitCode,
+			Signal:   res.Signal,
+		},
+	}
+}
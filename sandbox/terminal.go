@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	contract "sandbox/api/gen"
+)
+
+const (
+	// terminalStdin replaces the {STDIN} placeholder of the run command,
+	// the program of a terminal session reads its input from the TTY.
+	terminalStdin = "/dev/stdin"
+
+	// maxTerminalRequestSize limits the first message of a terminal session,
+	// which carries the files, maxTerminalMessageSize the input and control
+	// messages afterwards.
+	maxTerminalRequestSize = 32 << 20
+	maxTerminalMessageSize = 64 << 10
+
+	terminalRequestTimeout = 10 * time.Second
+	terminalWriteTimeout   = 10 * time.Second
+)
+
+var errTerminalClosed = errors.New("terminal closed by the client")
+
+var terminalUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// terminalHandler runs a submission with a TTY over a WebSocket. The first
+// message of the client is the SandboxRequest. The compile command runs like
+// in /run/stream, then the input and output of the run command are passed
+// through the WebSocket as binary messages until it exits, its RunTTL is over
+// or the terminal is idle for longer than terminalIdleTimeout. The client
+// resizes the terminal with TerminalControl text messages.
+//
+// Events are sent as SandboxStreamEvent text messages, the session ends with
+// the done event, or with an error event carrying the problem if the
+// submission couldn't be run at all.
+func terminalHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := terminalUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("upgrade terminal: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	t := &terminal{conn: conn, input: make(chan []byte, 64)}
+	defer t.close()
+
+	req, err := t.readRequest()
+	if err != nil {
+		t.problem(newProblem(http.StatusBadRequest, contract.InvalidRequest, "invalid request: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+
+	go t.readInput(ctx, cancel)
+
+	res, err := t.run(ctx, req)
+	var problem *problemError
+	if errors.As(err, &problem) && res == nil {
+		t.problem(problem)
+		return
+	}
+	if err != nil {
+		t.event(contract.SandboxStreamEvent{Kind: StreamKindError, Data: []byte(err.Error())})
+	}
+
+	t.event(doneEvent(res))
+}
+
+// terminal is the WebSocket of a terminal session.
+type terminal struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	// input holds the input of the client until the program reads it.
+	input chan []byte
+
+	// The size of the terminal, applied to the exec once it's started.
+	mu     sync.Mutex
+	rows   uint
+	cols   uint
+	execID string
+
+	// lastActive is the time of the last input or output in unix nanoseconds.
+	lastActive atomic.Int64
+}
+
+func (t *terminal) readRequest() (contract.SandboxRequest, error) {
+	var req contract.SandboxRequest
+
+	t.conn.SetReadLimit(maxTerminalRequestSize)
+	_ = t.conn.SetReadDeadline(time.Now().Add(terminalRequestTimeout))
+
+	_, data, err := t.conn.ReadMessage()
+	if err != nil {
+		return req, err
+	}
+
+	_ = t.conn.SetReadDeadline(time.Time{})
+	t.conn.SetReadLimit(maxTerminalMessageSize)
+
+	return req, json.Unmarshal(data, &req)
+}
+
+// readInput reads the messages of the client until the connection is closed,
+// which stops the run.
+func (t *terminal) readInput(ctx context.Context, cancel context.CancelCauseFunc) {
+	defer cancel(errTerminalClosed)
+	defer close(t.input)
+
+	for {
+		kind, data, err := t.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		t.touch()
+
+		switch kind {
+		case websocket.BinaryMessage:
+			select {
+			case t.input <- data:
+			case <-ctx.Done():
+				return
+			}
+		case websocket.TextMessage:
+			var ctl contract.TerminalControl
+			if err = json.Unmarshal(data, &ctl); err != nil {
+				log.Printf("invalid terminal control message: %v", err)
+				continue
+			}
+			if ctl.Type == contract.Resize && ctl.Rows != nil && ctl.Cols != nil {
+				t.resize(ctx, *ctl.Rows, *ctl.Cols)
+			}
+		}
+	}
+}
+
+// run compiles the submission and runs it with a TTY.
+func (t *terminal) run(ctx context.Context, req contract.SandboxRequest) (*contract.SandboxResponse, error) {
+	sub, err := prepareSubmission(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.close()
+	ctx = sub.ctx
+
+	res := &contract.SandboxResponse{}
+	res.RunEnvironment.ActionName = sub.action.Name
+
+	out := &runOutput{emit: t.emit}
+	compiled, err := sub.compile(ctx, sub.compileTTL(), out, res)
+	if !compiled && sub.hasSteps() {
+		skipSteps(res, sub.steps(contract.StepPhaseRun))
+	}
+	if err != nil || !compiled {
+		return res, err
+	}
+
+	out.event(StreamKindPhase, []byte(PhaseRun))
+
+	if err = t.exec(ctx, sub, res); err != nil {
+		return res, err
+	}
+
+	sub.addArtifacts(ctx, res)
+	return res, nil
+}
+
+// exec runs the run command of the submission with a TTY and passes its input
+// and output through the WebSocket until it exits. It's stopped after the
+// RunTTL of the template and when the terminal is idle for too long.
+func (t *terminal) exec(ctx context.Context, sub *submission, res *contract.SandboxResponse) error {
+	runCmd := sub.runCommand()
+	stdin := terminalStdin
+
+	meter := startResourceMeter(ctx, *sub.cont, "run")
+	start := time.Now()
+
+	te, err := codenireManager.ExecTerminal(ctx, *sub.cont, replacePlaceholders(runCmd, sub.req.Args, &stdin))
+	if err != nil {
+		meter.finish(ctx)
+		return err
+	}
+	defer te.Close()
+
+	t.touch()
+	t.attach(ctx, te.ID)
+
+	go t.forwardInput(te.Conn)
+
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		t.forwardOutput(te.Reader)
+	}()
+
+	stopErr := t.wait(ctx, exited, sub.runTTL())
+
+	res.RunEnvironment.RunCmd = runCmd
+	res.RunEnvironment.RunTime = float32(time.Since(start).Seconds())
+	res.RunEnvironment.RunUsage = meter.finish(ctx)
+	codenireManager.observeExecDuration(start, "run", sub.req.SandId)
+
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if stopErr != nil {
+		setTimeoutStatus(res)
+		return stopErr
+	}
+
+	exitCode, err := waitExitCode(ctx, te.ID)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		sub.setExitStatus(ctx, res, contract.ExecutionStatusRuntimeError, exitCode)
+		return nil
+	}
+
+	res.Status = contract.ExecutionStatusOk
+	return nil
+}
+
+// wait waits until the command exited. Otherwise it returns why the command
+// has to be stopped: the run was killed, timed out or the terminal is idle.
+func (t *terminal) wait(ctx context.Context, exited <-chan struct{}, ttl time.Duration) error {
+	runTimer := time.NewTimer(ttl)
+	defer runTimer.Stop()
+	// The idle time is checked every second, or as often as it may pass.
+	idleCheck := time.Second
+	if *terminalIdleTimeout > 0 {
+		idleCheck = min(idleCheck, *terminalIdleTimeout)
+	}
+	idleTicker := time.NewTicker(idleCheck)
+	defer idleTicker.Stop()
+
+	for {
+		select {
+		case <-exited:
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-runTimer.C:
+			return errors.New("timeout execute")
+		case <-idleTicker.C:
+			if idle := t.idle(); idle > *terminalIdleTimeout {
+				return fmt.Errorf("terminal idle for %s", idle.Round(time.Second))
+			}
+		}
+	}
+}
+
+// waitExitCode returns the exit code of the exec whose output has ended,
+// docker may take a moment to record that it exited.
+func waitExitCode(ctx context.Context, execID string) (int, error) {
+	var err error
+	for range 10 {
+		var exitCode int
+		if exitCode, err = codenireManager.ExecExitCode(ctx, execID); err == nil {
+			return exitCode, nil
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return -1, err
+}
+
+// attach applies the size of the terminal to the started exec
+// and to the resizes requested from now on.
+func (t *terminal) attach(ctx context.Context, execID string) {
+	t.mu.Lock()
+	t.execID = execID
+	rows, cols := t.rows, t.cols
+	t.mu.Unlock()
+
+	if rows > 0 && cols > 0 {
+		if err := codenireManager.ResizeTerminal(ctx, execID, rows, cols); err != nil {
+			log.Printf("resize terminal %s: %v", execID, err)
+		}
+	}
+}
+
+func (t *terminal) resize(ctx context.Context, rows, cols int) {
+	if rows <= 0 || cols <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.rows, t.cols = uint(rows), uint(cols)
+	execID := t.execID
+	t.mu.Unlock()
+
+	if execID == "" {
+		return
+	}
+	if err := codenireManager.ResizeTerminal(ctx, execID, uint(rows), uint(cols)); err != nil {
+		log.Printf("resize terminal %s: %v", execID, err)
+	}
+}
+
+func (t *terminal) forwardInput(w io.Writer) {
+	for data := range t.input {
+		if _, err := w.Write(data); err != nil {
+			return
+		}
+	}
+}
+
+func (t *terminal) forwardOutput(r io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			t.touch()
+			t.write(websocket.BinaryMessage, buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// emit passes the output and events of the compile command to the client.
+func (t *terminal) emit(kind string, data []byte) {
+	switch kind {
+	case StreamKindStdout, StreamKindStderr:
+		// The compile command has no TTY, its lines end with \n only.
+		t.write(websocket.BinaryMessage, bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n")))
+	default:
+		t.event(contract.SandboxStreamEvent{Kind: kind, Data: data})
+	}
+}
+
+func (t *terminal) problem(p *problemError) {
+	problem := p.problem()
+	t.event(contract.SandboxStreamEvent{Kind: StreamKindError, Data: []byte(p.detail), Problem: &problem})
+}
+
+func (t *terminal) event(ev contract.SandboxStreamEvent) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("json marshal: %v", err)
+		return
+	}
+
+	t.write(websocket.TextMessage, body)
+}
+
+func (t *terminal) write(kind int, data []byte) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	_ = t.conn.SetWriteDeadline(time.Now().Add(terminalWriteTimeout))
+	if err := t.conn.WriteMessage(kind, data); err != nil {
+		log.Printf("write terminal message: %v", err)
+	}
+}
+
+// close ends the session with a close message, the connection is closed by the handler.
+func (t *terminal) close() {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = t.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(terminalWriteTimeout))
+}
+
+func (t *terminal) touch() {
+	t.lastActive.Store(time.Now().UnixNano())
+}
+
+func (t *terminal) idle() time.Duration {
+	return time.Since(time.Unix(0, t.lastActive.Load()))
+}
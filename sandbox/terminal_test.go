@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTerminalWait(t *testing.T) {
+	oldIdle := *terminalIdleTimeout
+	t.Cleanup(func() { *terminalIdleTimeout = oldIdle })
+	*terminalIdleTimeout = 50 * time.Millisecond
+
+	errKilled := errors.New("killed by the admin")
+
+	cases := []struct {
+		name string
+		ttl  time.Duration
+		// active keeps the terminal active while waiting, so only the
+		// run TTL can stop it.
+		active  bool
+		exit    bool
+		cancel  bool
+		wantErr string
+	}{
+		{name: "exited", ttl: time.Minute, exit: true},
+		{name: "killed", ttl: time.Minute, cancel: true, wantErr: errKilled.Error()},
+		{name: "run ttl", ttl: 200 * time.Millisecond, active: true, wantErr: "timeout execute"},
+		{name: "idle", ttl: time.Minute, wantErr: "terminal idle"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			term := &terminal{}
+			term.touch()
+
+			exited := make(chan struct{})
+			stop := make(chan struct{})
+			defer close(stop)
+
+			go func() {
+				ticker := time.NewTicker(10 * time.Millisecond)
+				defer ticker.Stop()
+
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					case <-ticker.C:
+					}
+
+					if c.active {
+						term.touch()
+					}
+					if i == 2 && c.exit {
+						close(exited)
+					}
+					if i == 2 && c.cancel {
+						cancel(errKilled)
+					}
+				}
+			}()
+
+			err := term.wait(ctx, exited, c.ttl)
+
+			if c.wantErr == "" {
+				if err != nil {
+					t.Errorf("wait = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("wait = %v, want %q", err, c.wantErr)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package main
 
+import "time"
+
 const (
 	ExternalCommandsModeNode    = "none"
 	ExternalCommandsModeAll     = "all"
@@ -8,3 +10,28 @@ const (
 )
 
 const DefaultActionName = "default"
+
+const (
+	StreamKindStdout = "stdout"
+	StreamKindStderr = "stderr"
+	StreamKindPhase  = "phase"
+	StreamKindError  = "error"
+	StreamKindDone   = "done"
+)
+
+const (
+	PhaseCompile = "compile"
+	PhaseRun     = "run"
+)
+
+// PlaygroundTimeout is how long the playground waits for the response of the
+// sandbox (its client.SandboxBackendClient), ResponseMargin of it is left to
+// send the response. Runs still going on then are cut short.
+const (
+	PlaygroundTimeout = 60 * time.Second
+	ResponseMargin    = 2 * time.Second
+)
+
+// ContainerWaitTimeout bounds the wait for a free container of a template,
+// requests waiting longer are rejected as sandbox_saturated.
+const ContainerWaitTimeout = 20 * time.Second